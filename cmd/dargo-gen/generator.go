@@ -0,0 +1,474 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const iocImportPath = "github.com/jwells131313/dargo/ioc"
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// injectField is a field of a structure with an inject tag
+type injectField struct {
+	Name string
+	Tag  string
+
+	// Literal is the Go source of the ioc.InjectTag parsed from Tag
+	Literal string
+}
+
+// injectStruct is a structure with at least one field with an inject tag
+type injectStruct struct {
+	Name   string
+	Fields []*injectField
+}
+
+// Fingerprint returns the ioc.InjectFingerprint of the fields of the structure
+func (is *injectStruct) Fingerprint() string {
+	namesAndTags := make([]string, 0, 2*len(is.Fields))
+	for _, field := range is.Fields {
+		namesAndTags = append(namesAndTags, field.Name, field.Tag)
+	}
+
+	return ioc.InjectFingerprint(namesAndTags...)
+}
+
+// generatedPackage is everything found in a package that code is generated for
+type generatedPackage struct {
+	Name          string
+	Structs       []*injectStruct
+	BinderMethods []string
+}
+
+// scanPackage parses the non-test Go files of the directory that are built for the
+// current build context, such as with the GOOS, GOARCH and build tags of go generate,
+// other than the files being generated.  It finds the structures with inject tags
+// and the functions that are BinderMethods
+func scanPackage(dir string, skip ...string) (*generatedPackage, error) {
+	pkg, err := build.Default.ImportDir(dir, 0)
+	if err != nil {
+		var noGo *build.NoGoError
+		if errors.As(err, &noGo) {
+			return nil, fmt.Errorf("directory %s has no Go files", dir)
+		}
+
+		return nil, err
+	}
+
+	skipped := make(map[string]bool)
+	for _, name := range skip {
+		skipped[filepath.Base(name)] = true
+	}
+
+	retVal := &generatedPackage{
+		Name: pkg.Name,
+	}
+
+	names := make([]string, 0, len(pkg.GoFiles)+len(pkg.CgoFiles))
+	names = append(names, pkg.GoFiles...)
+	names = append(names, pkg.CgoFiles...)
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	for _, name := range names {
+		if skipped[name] {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+
+		err = retVal.scanFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+	}
+
+	sort.Slice(retVal.Structs, func(i, j int) bool {
+		return retVal.Structs[i].Name < retVal.Structs[j].Name
+	})
+	sort.Strings(retVal.BinderMethods)
+
+	return retVal, nil
+}
+
+func (gp *generatedPackage) scanFile(file *ast.File) error {
+	imports := fileImports(file)
+
+	iocAlias := ""
+	for alias, importPath := range imports {
+		if importPath == iocImportPath {
+			iocAlias = alias
+		}
+	}
+
+	for _, decl := range file.Decls {
+		switch typed := decl.(type) {
+		case *ast.GenDecl:
+			if typed.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range typed.Specs {
+				err := gp.scanType(spec.(*ast.TypeSpec))
+				if err != nil {
+					return err
+				}
+			}
+		case *ast.FuncDecl:
+			if iocAlias != "" && isBinderMethod(typed, iocAlias) {
+				gp.BinderMethods = append(gp.BinderMethods, typed.Name.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (gp *generatedPackage) scanType(spec *ast.TypeSpec) error {
+	structType, ok := spec.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+
+	injected := &injectStruct{
+		Name: spec.Name.Name,
+	}
+
+	for _, field := range structType.Fields.List {
+		if field.Tag == nil {
+			continue
+		}
+
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			return err
+		}
+
+		injectTag, found := reflect.StructTag(tag).Lookup("inject")
+		if !found {
+			continue
+		}
+
+		names := make([]string, 0, len(field.Names)+1)
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		if len(names) == 0 {
+			names = append(names, embeddedName(field.Type))
+		}
+
+		parsed, err := ioc.ParseInjectTag(injectTag)
+		if err != nil {
+			return fmt.Errorf("field %s of %s has an invalid inject tag: %v", names[0], spec.Name.Name, err)
+		}
+
+		for _, name := range names {
+			injected.Fields = append(injected.Fields, &injectField{
+				Name:    name,
+				Tag:     injectTag,
+				Literal: injectTagLiteral(parsed),
+			})
+		}
+	}
+
+	if len(injected.Fields) == 0 {
+		return nil
+	}
+
+	if spec.TypeParams != nil && len(spec.TypeParams.List) > 0 {
+		return fmt.Errorf("generic structure %s can not have a generated creator", spec.Name.Name)
+	}
+
+	gp.Structs = append(gp.Structs, injected)
+
+	return nil
+}
+
+// embeddedName returns the name of an embedded field, which is the name of its type
+func embeddedName(expr ast.Expr) string {
+	switch typed := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(typed.X)
+	case *ast.SelectorExpr:
+		return typed.Sel.Name
+	case *ast.Ident:
+		return typed.Name
+	case *ast.IndexExpr:
+		return embeddedName(typed.X)
+	case *ast.IndexListExpr:
+		return embeddedName(typed.X)
+	default:
+		return ""
+	}
+}
+
+// injectTagLiteral returns the Go source of the parsed inject tag
+func injectTagLiteral(tag ioc.InjectTag) string {
+	parts := make([]string, 0)
+	if tag.Namespace != "" {
+		parts = append(parts, "Namespace: "+strconv.Quote(tag.Namespace))
+	}
+	if tag.Name != "" {
+		parts = append(parts, "Name: "+strconv.Quote(tag.Name))
+	}
+	if len(tag.Qualifiers) > 0 {
+		quoted := make([]string, 0, len(tag.Qualifiers))
+		for _, qualifier := range tag.Qualifiers {
+			quoted = append(quoted, strconv.Quote(qualifier))
+		}
+
+		parts = append(parts, "Qualifiers: []string{"+strings.Join(quoted, ", ")+"}")
+	}
+
+	options := []struct {
+		name string
+		set  bool
+	}{
+		{"Optional", tag.Optional},
+		{"ByType", tag.ByType},
+		{"All", tag.All},
+		{"Strict", tag.Strict},
+		{"Proxy", tag.Proxy},
+	}
+	for _, option := range options {
+		if option.set {
+			parts = append(parts, option.name+": true")
+		}
+	}
+
+	if tag.MapKey != "" {
+		parts = append(parts, "MapKey: "+strconv.Quote(tag.MapKey))
+	}
+
+	return "ioc.InjectTag{" + strings.Join(parts, ", ") + "}"
+}
+
+// fileImports returns the import paths of the file by the name they are used with
+func fileImports(file *ast.File) map[string]string {
+	retVal := make(map[string]string)
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+
+		if spec.Name != nil {
+			retVal[spec.Name.Name] = importPath
+			continue
+		}
+
+		retVal[guessPackageName(importPath)] = importPath
+	}
+
+	return retVal
+}
+
+// guessPackageName returns the usual name of the package with the import path,
+// which is the last element of the path without a major version or a go- prefix
+func guessPackageName(importPath string) string {
+	retVal := path.Base(importPath)
+	if majorVersion.MatchString(retVal) && strings.Contains(importPath, "/") {
+		retVal = path.Base(path.Dir(importPath))
+	}
+
+	if index := strings.Index(retVal, ".v"); index > 0 {
+		retVal = retVal[:index]
+	}
+
+	retVal = strings.TrimPrefix(retVal, "go-")
+
+	return strings.ReplaceAll(retVal, "-", "_")
+}
+
+// isBinderMethod returns true if the function has the signature of an ioc.BinderMethod
+func isBinderMethod(fn *ast.FuncDecl, iocAlias string) bool {
+	if fn.Recv != nil || (fn.Type.TypeParams != nil && len(fn.Type.TypeParams.List) > 0) {
+		return false
+	}
+
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) > 1 {
+		return false
+	}
+
+	selector, ok := params[0].Type.(*ast.SelectorExpr)
+	if !ok || selector.Sel.Name != "Binder" {
+		return false
+	}
+
+	pkg, ok := selector.X.(*ast.Ident)
+	if !ok || pkg.Name != iocAlias {
+		return false
+	}
+
+	results := fn.Type.Results
+	if results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return false
+	}
+
+	result, ok := results.List[0].Type.(*ast.Ident)
+
+	return ok && result.Name == "error"
+}
+
+var creatorsTemplate = template.Must(template.New("creators").Parse(`// Code generated by dargo-gen. DO NOT EDIT.
+
+package {{ .Name }}
+
+import (
+	"github.com/jwells131313/dargo/ioc"
+)
+
+func init() {
+{{- range .Structs }}
+	if err := ioc.RegisterGeneratedCreator[{{ .Name }}](dargoCreate{{ .Name }}, "{{ .Fingerprint }}"); err != nil {
+		panic(err)
+	}
+{{- end }}
+}
+{{ range .Structs }}
+// dargoCreate{{ .Name }} creates a {{ .Name }} and injects its fields without reflection
+func dargoCreate{{ .Name }}(locator ioc.ServiceLocator, desc ioc.Descriptor) (*{{ .Name }}, error) {
+	service := &{{ .Name }}{}
+	errs := ioc.NewMultiError()
+{{ range .Fields }}
+	ioc.InjectInto(locator, desc, &service.{{ .Name }}, {{ .Literal }}, errs)
+{{- end }}
+
+	return ioc.FinishGenerated(locator, desc, service, errs)
+}
+{{ end -}}
+`))
+
+var verifyTemplate = template.Must(template.New("verify").Parse(`// Code generated by dargo-gen. DO NOT EDIT.
+
+package {{ .Name }}
+
+import (
+	"github.com/jwells131313/dargo/ioc"
+	"testing"
+)
+
+// TestDargoGenVerify binds the services of every BinderMethod of the package and
+// fails if ioc.Verify finds a problem, such as an inject tag naming a service
+// that is not bound
+func TestDargoGenVerify(t *testing.T) {
+	locator, err := ioc.CreateAndBind("{{ .VerifyLocator }}", func(binder ioc.Binder) error {
+		for _, method := range []ioc.BinderMethod{
+{{- range .BinderMethods }}
+			{{ . }},
+{{- end }}
+		} {
+			err := method(binder)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locator.Shutdown()
+
+	err = ioc.Verify(locator)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+`))
+
+// VerifyLocator returns the name of the locator used by the verification test
+func (gp *generatedPackage) VerifyLocator() string {
+	return "DargoGenVerify_" + gp.Name
+}
+
+// generateCreators returns the source of the file of generated creators
+func generateCreators(gp *generatedPackage) ([]byte, error) {
+	return render(creatorsTemplate, gp)
+}
+
+// generateVerifyTest returns the source of the verification test, or nil if the
+// package has no BinderMethods
+func generateVerifyTest(gp *generatedPackage) ([]byte, error) {
+	if len(gp.BinderMethods) == 0 {
+		return nil, nil
+	}
+
+	return render(verifyTemplate, gp)
+}
+
+func render(tmpl *template.Template, gp *generatedPackage) ([]byte, error) {
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, gp)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not compile: %v\n%s", err, buf.String())
+	}
+
+	return retVal, nil
+}
@@ -0,0 +1,203 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package main
+
+import (
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const exampleDir = "../../examples/generated"
+
+// TestExampleIsCurrent fails if the generated files of the example
+// package are not what dargo-gen generates for it now
+func TestExampleIsCurrent(t *testing.T) {
+	out := t.TempDir()
+
+	err := run(exampleDir, filepath.Join(out, "dargo_gen.go"), filepath.Join(out, "dargo_gen_test.go"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	for _, name := range []string{"dargo_gen.go", "dargo_gen_test.go"} {
+		expected, err := os.ReadFile(filepath.Join(exampleDir, name))
+		if !assert.Nil(t, err) {
+			continue
+		}
+
+		actual, err := os.ReadFile(filepath.Join(out, name))
+		if assert.Nil(t, err) {
+			assert.Equal(t, string(expected), string(actual), "%s is out of date, run go generate", name)
+		}
+	}
+}
+
+const scannedSource = `package scanned
+
+import (
+	dargo "github.com/jwells131313/dargo/ioc"
+	"io"
+)
+
+type Base struct {
+}
+
+type WithEmbedded struct {
+	*Base ` + "`inject:\"Base\"`" + `
+	io.Writer ` + "`inject:\"Writer,optional\"`" + `
+	A, B *Base ` + "`inject:\"Base\"`" + `
+	Ignored *Base ` + "`json:\"ignored\"`" + `
+}
+
+type NotInjected struct {
+	Name string
+}
+
+func Bind(binder dargo.Binder) error {
+	return nil
+}
+
+func (wb *WithEmbedded) Bind(binder dargo.Binder) error {
+	return nil
+}
+
+func notBinder(binder dargo.Binder) {
+}
+`
+
+func TestScanPackage(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "scanned.go"), []byte(scannedSource), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	gp, err := scanPackage(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, "scanned", gp.Name)
+	assert.Equal(t, []string{"Bind"}, gp.BinderMethods)
+	if !assert.Equal(t, 1, len(gp.Structs)) {
+		return
+	}
+
+	names := make([]string, 0)
+	for _, field := range gp.Structs[0].Fields {
+		names = append(names, field.Name+"="+field.Tag)
+	}
+	assert.Equal(t, []string{"Base=Base", "Writer=Writer,optional", "A=Base", "B=Base"}, names)
+	assert.Equal(t, `ioc.InjectTag{Namespace: "default", Name: "Writer", Optional: true}`,
+		gp.Structs[0].Fields[1].Literal)
+	assert.Equal(t, ioc.InjectFingerprint("Base", "Base", "Writer", "Writer,optional", "A", "Base", "B", "Base"),
+		gp.Structs[0].Fingerprint())
+
+	_, err = generateCreators(gp)
+	assert.Nil(t, err)
+}
+
+func TestInjectTagLiteral(t *testing.T) {
+	tag, err := ioc.ParseInjectTag("ns#Name@Q1@Q2,all,strict,mapkey=region")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, `ioc.InjectTag{Namespace: "ns", Name: "Name", Qualifiers: []string{"Q1", "Q2"}, `+
+		`All: true, Strict: true, MapKey: "region"}`, injectTagLiteral(tag))
+}
+
+func TestScanHonorsBuildConstraints(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "scanned.go"), []byte(scannedSource), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = os.WriteFile(filepath.Join(dir, "ignored.go"), []byte("//go:build ignore\n\npackage main\n\n"+
+		"type Ignored struct {\n\tValue *int `inject:\"Value\"`\n}\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	gp, err := scanPackage(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	if assert.Equal(t, 1, len(gp.Structs), "files excluded by build constraints are not scanned") {
+		assert.Equal(t, "WithEmbedded", gp.Structs[0].Name)
+	}
+}
+
+func TestScanBadInjectTag(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "bad.go"), []byte("package bad\n\n"+
+		"type Bad struct {\n\tValue *int `inject:\"Value,nonsense\"`\n}\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = scanPackage(dir)
+	assert.NotNil(t, err, "inject tags are checked when the creator is generated")
+}
+
+func TestScanGenericStruct(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "generic.go"), []byte("package generic\n\n"+
+		"type Holder[T any] struct {\n\tValue T `inject:\"Value\"`\n}\n"), 0600)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = scanPackage(dir)
+	assert.NotNil(t, err)
+}
+
+func TestGuessPackageName(t *testing.T) {
+	assert.Equal(t, "ioc", guessPackageName("github.com/jwells131313/dargo/ioc"))
+	assert.Equal(t, "yaml", guessPackageName("gopkg.in/yaml.v3"))
+	assert.Equal(t, "errors", guessPackageName("github.com/pkg/errors/v2"))
+	assert.Equal(t, "difflib", guessPackageName("github.com/pmezard/go-difflib"))
+}
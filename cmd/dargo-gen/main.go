@@ -0,0 +1,113 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+// Command dargo-gen generates creators for the structures of a Go package that
+// have inject tags.  The creators set the fields of the structures without
+// reflection, and are registered with ioc.RegisterGeneratedCreator so that
+// ioc.Binder.Bind uses them in place of reflection.  It also generates a test
+// that binds the services of every ioc.BinderMethod function of the package and
+// fails if ioc.Verify finds a problem, such as an inject tag naming a service
+// that is never bound.  It is usually run with go generate:
+//
+//	//go:generate dargo-gen
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	dir := flag.String("dir", ".", "the directory of the package to generate code for")
+	output := flag.String("output", "dargo_gen.go", "the file to write the generated creators to")
+	testOutput := flag.String("test", "dargo_gen_test.go",
+		"the file to write the verification test to, or empty to not write the test")
+	flag.Parse()
+
+	err := run(*dir, *output, *testOutput)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "dargo-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run generates the files for the package in the directory.  Output files that
+// are not given as absolute paths are written into the directory
+func run(dir string, output string, testOutput string) error {
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(dir, output)
+	}
+	if testOutput != "" && !filepath.IsAbs(testOutput) {
+		testOutput = filepath.Join(dir, testOutput)
+	}
+
+	gp, err := scanPackage(dir, output)
+	if err != nil {
+		return err
+	}
+
+	if len(gp.Structs) > 0 {
+		creators, err := generateCreators(gp)
+		if err != nil {
+			return err
+		}
+
+		err = os.WriteFile(output, creators, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	if testOutput == "" {
+		return nil
+	}
+
+	verifyTest, err := generateVerifyTest(gp)
+	if err != nil {
+		return err
+	}
+	if verifyTest == nil {
+		return nil
+	}
+
+	return os.WriteFile(testOutput, verifyTest, 0644)
+}
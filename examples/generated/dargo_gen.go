@@ -0,0 +1,25 @@
+// Code generated by dargo-gen. DO NOT EDIT.
+
+package generated
+
+import (
+	"github.com/jwells131313/dargo/ioc"
+)
+
+func init() {
+	if err := ioc.RegisterGeneratedCreator[GreeterData](dargoCreateGreeterData, "514ea35d8e06b7e3b66fdd84cfd275be"); err != nil {
+		panic(err)
+	}
+}
+
+// dargoCreateGreeterData creates a GreeterData and injects its fields without reflection
+func dargoCreateGreeterData(locator ioc.ServiceLocator, desc ioc.Descriptor) (*GreeterData, error) {
+	service := &GreeterData{}
+	errs := ioc.NewMultiError()
+
+	ioc.InjectInto(locator, desc, &service.Punctuation, ioc.InjectTag{Namespace: "default", Name: "Punctuation"}, errs)
+	ioc.InjectInto(locator, desc, &service.Audit, ioc.InjectTag{Namespace: "default", Name: "Audit"}, errs)
+	ioc.InjectInto(locator, desc, &service.Missing, ioc.InjectTag{Namespace: "default", Name: "NoSuchService", Optional: true}, errs)
+
+	return ioc.FinishGenerated(locator, desc, service, errs)
+}
@@ -0,0 +1,35 @@
+// Code generated by dargo-gen. DO NOT EDIT.
+
+package generated
+
+import (
+	"github.com/jwells131313/dargo/ioc"
+	"testing"
+)
+
+// TestDargoGenVerify binds the services of every BinderMethod of the package and
+// fails if ioc.Verify finds a problem, such as an inject tag naming a service
+// that is not bound
+func TestDargoGenVerify(t *testing.T) {
+	locator, err := ioc.CreateAndBind("DargoGenVerify_generated", func(binder ioc.Binder) error {
+		for _, method := range []ioc.BinderMethod{
+			BindGreeter,
+		} {
+			err := method(binder)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer locator.Shutdown()
+
+	err = ioc.Verify(locator)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
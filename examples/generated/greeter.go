@@ -0,0 +1,121 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+// Package generated shows creators generated by dargo-gen.  The dargo_gen.go
+// and dargo_gen_test.go files are written by running go generate
+package generated
+
+//go:generate go run github.com/jwells131313/dargo/cmd/dargo-gen
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+)
+
+// Greeter says hello
+type Greeter interface {
+	Greet(name string) string
+}
+
+// Punctuation is the service that ends greetings
+type Punctuation struct {
+	Mark string
+}
+
+// GreeterData is the Greeter, which is injected with the Punctuation
+// and with a Provider of the Audit service
+type GreeterData struct {
+	Punctuation *Punctuation `inject:"Punctuation"`
+	Audit       ioc.Provider `inject:"Audit"`
+	Missing     *Punctuation `inject:"NoSuchService,optional"`
+}
+
+// Greet returns the greeting for the name, and adds it to the audit
+func (gd *GreeterData) Greet(name string) string {
+	greeting := fmt.Sprintf("Hello, %s%s", name, gd.Punctuation.Mark)
+
+	audit, err := gd.Audit.Get()
+	if err == nil {
+		audit.(*AuditData).Record(greeting)
+	}
+
+	return greeting
+}
+
+// AuditData records the greetings made
+type AuditData struct {
+	Greetings []string
+}
+
+// Record adds the greeting to the audit
+func (ad *AuditData) Record(greeting string) {
+	ad.Greetings = append(ad.Greetings, greeting)
+}
+
+// BindGreeter is the BinderMethod of the services of this package
+func BindGreeter(binder ioc.Binder) error {
+	binder.Bind("Greeter", GreeterData{})
+	binder.BindConstant("Punctuation", &Punctuation{Mark: "!"})
+	binder.Bind("Audit", AuditData{})
+
+	return nil
+}
+
+func runExample() (string, []string, error) {
+	locator, err := ioc.CreateAndBind("GeneratedExample", BindGreeter)
+	if err != nil {
+		return "", nil, err
+	}
+	defer locator.Shutdown()
+
+	greeter, err := ioc.GetD[Greeter](locator, "Greeter")
+	if err != nil {
+		return "", nil, err
+	}
+
+	greeting := greeter.Greet("World")
+
+	audit, err := ioc.GetD[*AuditData](locator, "Audit")
+	if err != nil {
+		return "", nil, err
+	}
+
+	return greeting, audit.Greetings, nil
+}
@@ -7,6 +7,7 @@ require (
 	github.com/pkg/errors v0.8.0
 	github.com/sirupsen/logrus v1.0.6
 	github.com/stretchr/testify v1.2.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
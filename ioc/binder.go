@@ -40,7 +40,11 @@
 
 package ioc
 
-import "reflect"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+)
 
 // BinderMethod is the method signature for binding services into the ServiceLocator
 type BinderMethod func(Binder) error
@@ -51,22 +55,70 @@ type Binder interface {
 	// or can be a pointer to the struct to be created.  prototype is NOT the structure
 	// that will be used as a service by Dargo.  If prototype implements DargoInitializer
 	// then the DargoInitialize method will be called on it prior to being given to other
-	// services
+	// services.  The service can also be found by the pointer to the struct type or by
+	// any interface the pointer to the struct implements
 	Bind(name string, prototype interface{}) Binder
 	// BindWithCreator binds the given name to a creation function
 	BindWithCreator(name string, bindMethod func(ServiceLocator, Descriptor) (interface{}, error)) Binder
+	// BindConstructor binds the given name to a constructor function, which may take any
+	// number of parameters and must return either the service or the service and an error.
+	// Each parameter is found by its type, unless parameters are given, in which case there
+	// must be one for each parameter of the constructor.  A parameter string has the same
+	// format as an inject tag, so it can name the service, be empty to find it by type and
+	// have the optional directive.  If the constructor returns a non-nil error the creation
+	// of the service fails.  If the returned service implements DargoInitializer then the
+	// DargoInitialize method will be called on it prior to being given to other services
+	BindConstructor(name string, constructor interface{}, parameters ...string) Binder
 	// BindConstant binds the exact constant as-is into the ServiceLocator
 	BindConstant(name string, constant interface{}) Binder
+	// BindFactory binds the given name to the Factory registered with RegisterFactory
+	// under the factory name.  The factory name is kept in the metadata of the service
+	// with the key FactoryMetadata, which allows manifest.ExportManifest to write the service
+	BindFactory(name string, factoryName string) Binder
 	// InScope changes the scope to the given scope.  The default scope is Singleton
 	InScope(string) Binder
 	// InNamespace changes the namespace to the given value.  The default namespace is default
 	InNamespace(string) Binder
 	// QualifiedBy adds the given qualifier name
 	QualifiedBy(string) Binder
+	// AlsoAs advertises the service under the additional names given.  A name
+	// may be of the form namespace#name, otherwise it is in the namespace of the
+	// service.  The same instance of the service is returned no matter which of
+	// its names is used to look it up.  AlsoAs panics if a name is not valid
+	AlsoAs(names ...string) Binder
+	// Decorates makes this service, which must implement Decorator, decorate the
+	// services with the names given.  A name may be of the form namespace#name,
+	// otherwise it is in the namespace of this service
+	Decorates(names ...string) Binder
+	// SubscribesTo makes this service, which must implement Subscriber, get the
+	// events published to the topics given.  A topic may be of the form namespace#name,
+	// otherwise it is in the default namespace.  A topic may have qualifiers, such as
+	// Orders@Priority, in which case only events published to a topic with all of
+	// those qualifiers are delivered
+	SubscribesTo(topics ...string) Binder
 	// Ranked changes the rank to the given rank.  Higher ranks are preferred over lower ranks
 	Ranked(int32) Binder
 	// AndDestroyWith sets the destroyer function to the given function
 	AndDestroyWith(func(ServiceLocator, Descriptor, interface{}) error) Binder
+	// WithMetadata adds the given values to the metadata of the service under the given key.
+	// Metadata can be used as the key of a map injected with the mapkey option
+	WithMetadata(key string, values ...string) Binder
+	// AtRunLevel puts the service in the RunLevelScope at the given level, which
+	// must be zero or higher.  The service is started when the RunLevelController
+	// proceeds to that level and is stopped when it goes below it
+	AtRunLevel(level int) Binder
+	// When adds a condition to the service, which is only available while all
+	// of its conditions are true.  Conditions are evaluated when the services of
+	// the locator or its active profiles change
+	When(condition Condition) Binder
+	// InProfile makes the service only available while at least one of the given
+	// profiles is active in the locator.  The profiles are kept in the metadata of
+	// the service with the key ProfileMetadata
+	InProfile(profiles ...string) Binder
+	// WithVisibility changes the visibility to either NormalVisibility or LocalVisibility.
+	// Services with LocalVisibility are not visible to child locators.  The default
+	// visibility is NormalVisibility
+	WithVisibility(int) Binder
 }
 
 // DargoInitializer is used when using Binder.Bind and need
@@ -82,6 +134,20 @@ type DargoInitializer interface {
 	DargoInitialize(Descriptor) error
 }
 
+// DargoDestroyer is used when a service needs to clean up after itself
+// when it is removed from its scope.  It is the counterpart of
+// DargoInitializer and does not need a destroy function to be set
+// with Binder.AndDestroyWith
+type DargoDestroyer interface {
+	// DargoDestroy is a method that will be called when the service
+	// is being destroyed by its scope.  It is not called if the
+	// descriptor of the service has a destroy function, in which case
+	// only the destroy function is called.  If this method returns
+	// a non-nil error that error is given to the ErrorService
+	// The descriptor passed in is the descriptor of the service
+	DargoDestroy(Descriptor) error
+}
+
 type binder struct {
 	parent      *serviceLocatorData
 	descriptors []Descriptor
@@ -118,9 +184,25 @@ func (binder *binder) BindWithCreator(name string, cf func(ServiceLocator, Descr
 }
 
 func (binder *binder) BindConstant(name string, constant interface{}) Binder {
-	return binder.BindWithCreator(name, func(ServiceLocator, Descriptor) (interface{}, error) {
+	binder.BindWithCreator(name, func(ServiceLocator, Descriptor) (interface{}, error) {
 		return constant, nil
 	})
+
+	binder.current.(WriteableTypedDescriptor).SetImplementationType(reflect.TypeOf(constant))
+	binder.current.(*writeableDescriptorImpl).setConstant()
+
+	return binder
+}
+
+func (binder *binder) BindFactory(name string, factoryName string) Binder {
+	factory := GetFactory(factoryName)
+	if factory == nil {
+		panic(fmt.Sprintf("no factory is registered with name %s", factoryName))
+	}
+
+	binder.BindWithCreator(name, factory)
+
+	return binder.WithMetadata(FactoryMetadata, factoryName)
 }
 
 func (binder *binder) Bind(name string, str interface{}) Binder {
@@ -144,16 +226,35 @@ func (binder *binder) Bind(name string, str interface{}) Binder {
 	}
 
 	cf := newCreatorFunc(ty, binder.parent)
+	if generated := getGeneratedCreator(ty); generated != nil {
+		cf = preferGenerated(generated, cf)
+	}
 
 	binder.current = NewWriteableDescriptor()
 	binder.current.SetCreateFunction(cf)
 	binder.current.SetName(name)
+	binder.current.(WriteableTypedDescriptor).SetImplementationType(reflect.PtrTo(ty))
+	binder.current.(injectionInformation).setInjectionPoints(structInjectionPoints(ty))
 
 	binder.qualifiers = make([]string, 0)
 
 	return binder
 }
 
+func (binder *binder) BindConstructor(name string, constructor interface{}, parameters ...string) Binder {
+	cf, ty, points, err := newConstructorFunc(constructor, parameters)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	binder.BindWithCreator(name, cf)
+
+	binder.current.(WriteableTypedDescriptor).SetImplementationType(ty)
+	binder.current.(injectionInformation).setInjectionPoints(points)
+
+	return binder
+}
+
 func (binder *binder) InScope(scope string) Binder {
 	if binder.current == nil {
 		panic("must call bind before this method")
@@ -184,6 +285,30 @@ func (binder *binder) QualifiedBy(qualifier string) Binder {
 	return binder
 }
 
+func (binder *binder) AlsoAs(names ...string) Binder {
+	if binder.current == nil {
+		panic("must call bind before this method")
+	}
+
+	contractDesc := binder.current.(WriteableContractDescriptor)
+
+	contracts := append(contractDesc.GetContracts(), names...)
+	err := contractDesc.SetContracts(contracts)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return binder
+}
+
+func (binder *binder) Decorates(names ...string) Binder {
+	return binder.WithMetadata(DecoratesMetadata, names...)
+}
+
+func (binder *binder) SubscribesTo(topics ...string) Binder {
+	return binder.WithMetadata(SubscribesToMetadata, topics...)
+}
+
 func (binder *binder) Ranked(rank int32) Binder {
 	if binder.current == nil {
 		panic("must call bind before this method")
@@ -205,6 +330,61 @@ func (binder *binder) AndDestroyWith(destroyer func(ServiceLocator, Descriptor,
 	return binder
 }
 
+func (binder *binder) WithMetadata(key string, values ...string) Binder {
+	if binder.current == nil {
+		panic("must call bind before this method")
+	}
+
+	metadata := binder.current.GetMetadata()
+	metadata[key] = append(metadata[key], values...)
+	binder.current.SetMetadata(metadata)
+
+	return binder
+}
+
+func (binder *binder) AtRunLevel(level int) Binder {
+	if binder.current == nil {
+		panic("must call bind before this method")
+	}
+	if level <= RunLevelNone {
+		panic(fmt.Sprintf("the run level %d must be higher than %d", level, RunLevelNone))
+	}
+
+	metadata := binder.current.GetMetadata()
+	metadata[RunLevelMetadata] = []string{strconv.Itoa(level)}
+	binder.current.SetMetadata(metadata)
+	binder.current.SetScope(RunLevelScope)
+
+	return binder
+}
+
+func (binder *binder) When(condition Condition) Binder {
+	if binder.current == nil {
+		panic("must call bind before this method")
+	}
+	if condition == nil {
+		panic("the condition may not be nil")
+	}
+
+	binder.current.(conditionInformation).addCondition(condition)
+
+	return binder
+}
+
+func (binder *binder) InProfile(profiles ...string) Binder {
+	return binder.WithMetadata(ProfileMetadata, profiles...)
+}
+
+func (binder *binder) WithVisibility(visibility int) Binder {
+	if binder.current == nil {
+		panic("must call bind before this method")
+	}
+
+	binder.current.SetVisibility(visibility)
+
+	return binder
+}
+
 func (binder *binder) finish() []Descriptor {
 	if binder.current != nil {
 		if len(binder.qualifiers) > 0 {
@@ -216,3 +396,22 @@ func (binder *binder) finish() []Descriptor {
 
 	return binder.descriptors
 }
+
+// stamp adds the given values to the metadata of every descriptor bound so far
+// under the given key
+func (binder *binder) stamp(key string, values ...string) {
+	binder.finish()
+	binder.current = nil
+	binder.qualifiers = nil
+
+	for _, desc := range binder.descriptors {
+		wd, ok := desc.(WriteableDescriptor)
+		if !ok {
+			continue
+		}
+
+		metadata := wd.GetMetadata()
+		metadata[key] = append(metadata[key], values...)
+		wd.SetMetadata(metadata)
+	}
+}
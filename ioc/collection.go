@@ -0,0 +1,176 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// collectionElementType returns the type of the services to put into a
+// slice or map injection point given the all option
+func collectionElementType(ty reflect.Type, pd *parseData) (reflect.Type, error) {
+	switch ty.Kind() {
+	case reflect.Slice:
+		if pd.mapKey != "" {
+			return nil, fmt.Errorf("the %s option may only be used with a map", injectMapKeyOption)
+		}
+
+		return ty.Elem(), nil
+	case reflect.Map:
+		if ty.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("a map injected with the all option must have string keys, not %v", ty.Key())
+		}
+
+		return ty.Elem(), nil
+	}
+
+	return nil, fmt.Errorf("the all option may only be used with a slice or a map of string, not %v", ty)
+}
+
+// collectionFilter returns the filter for the services that go into a slice
+// or map injection point
+func collectionFilter(elemType reflect.Type, pd *parseData) Filter {
+	if pd.byType {
+		return NewTypeFilter(elemType, pd.qualifiers...)
+	}
+
+	return NewServiceKeyFilter(pd.serviceKey)
+}
+
+// collectionKey returns the key of the service in a map injection point,
+// which is the first value of the metadata given with the mapkey option
+// or else the first qualifier of the service
+func collectionKey(desc Descriptor, pd *parseData) (string, bool) {
+	if pd.mapKey != "" {
+		values := desc.GetMetadata()[pd.mapKey]
+		if len(values) == 0 {
+			return "", false
+		}
+
+		return values[0], true
+	}
+
+	qualifiers := desc.GetQualifiers()
+	if len(qualifiers) == 0 {
+		return "", false
+	}
+
+	return qualifiers[0], true
+}
+
+// getCollectionFor creates every service matching the injection point and
+// returns them in a slice in rank order, or in a map keyed by collectionKey.
+// A service that fails to be created, or that is not of the element type,
+// is given to the error handlers and is left out unless the strict option was
+// given, in which case the whole injection fails.  In a map the service with the highest rank is
+// kept when two services have the same key, and services with no key are
+// left out
+func (locator *serviceLocatorData) getCollectionFor(ty reflect.Type, pd *parseData,
+	forMe Descriptor) (*reflect.Value, error) {
+	elemType, err := collectionElementType(ty, pd)
+	if err != nil {
+		return nil, err
+	}
+
+	err = locator.checkState()
+	if err != nil {
+		return nil, err
+	}
+
+	descs, err := locator.getDescriptorsFor(collectionFilter(elemType, pd), forMe)
+	if err != nil {
+		return nil, err
+	}
+
+	var retVal reflect.Value
+	if ty.Kind() == reflect.Slice {
+		retVal = reflect.MakeSlice(ty, 0, len(descs))
+	} else {
+		retVal = reflect.MakeMap(ty)
+	}
+
+	errs := NewMultiError()
+	for _, desc := range descs {
+		var key reflect.Value
+		if ty.Kind() == reflect.Map {
+			name, hasKey := collectionKey(desc, pd)
+			if !hasKey {
+				continue
+			}
+
+			// The key type may be any type whose kind is string
+			key = reflect.ValueOf(name).Convert(ty.Key())
+			if retVal.MapIndex(key).IsValid() {
+				continue
+			}
+		}
+
+		locator.recordDependency(forMe, desc)
+
+		service, err := locator.createService(desc)
+		if err == nil && (service == nil || !reflect.TypeOf(service).AssignableTo(elemType)) {
+			err = NewServiceTypeError(descriptorKey(desc), elemType, service)
+
+			locator.runErrorHandlers(ServiceCreationFailure, desc, ty, forMe, err)
+		}
+
+		if err != nil {
+			if pd.strict {
+				errs.AddError(err)
+			}
+
+			continue
+		}
+
+		if ty.Kind() == reflect.Slice {
+			retVal = reflect.Append(retVal, reflect.ValueOf(service))
+		} else {
+			retVal.SetMapIndex(key, reflect.ValueOf(service))
+		}
+	}
+
+	if errs.HasError() {
+		return nil, errs
+	}
+
+	return &retVal, nil
+}
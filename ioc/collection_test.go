@@ -0,0 +1,261 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	collectionLocator1 = "CollectionLocator1"
+	collectionLocator2 = "CollectionLocator2"
+	collectionLocator3 = "CollectionLocator3"
+	collectionLocator4 = "CollectionLocator4"
+
+	collectionPluginName = "Plugin"
+	regionMetadata       = "region"
+)
+
+type collectionPlugin interface {
+	PluginName() string
+}
+
+type namedPlugin struct {
+	name string
+}
+
+func (np *namedPlugin) DargoInitialize(desc Descriptor) error {
+	np.name = desc.GetQualifiers()[0]
+	return nil
+}
+
+func (np *namedPlugin) PluginName() string {
+	return np.name
+}
+
+type failingPlugin struct {
+}
+
+func (fp *failingPlugin) DargoInitialize(desc Descriptor) error {
+	return fmt.Errorf(ExpectedPanicMessage)
+}
+
+func (fp *failingPlugin) PluginName() string {
+	return "failing"
+}
+
+type notAPlugin struct {
+}
+
+type pluginHost struct {
+	ByName   []collectionPlugin          `inject:"Plugin,all"`
+	ByType   []collectionPlugin          `inject:",all"`
+	ByQual   map[string]collectionPlugin `inject:"Plugin,all"`
+	ByRegion map[string]collectionPlugin `inject:"Plugin,all,mapkey=region"`
+	Empty    []collectionPlugin          `inject:"NoSuchPlugin,all"`
+}
+
+type pluginRegion string
+
+type regionPluginHost struct {
+	ByRegion map[pluginRegion]collectionPlugin `inject:"Plugin,all,mapkey=region"`
+}
+
+type strictPluginHost struct {
+	Plugins []collectionPlugin `inject:"Plugin,all,strict"`
+}
+
+func pluginNames(plugins []collectionPlugin) []string {
+	retVal := make([]string, 0)
+	for _, plugin := range plugins {
+		retVal = append(retVal, plugin.PluginName())
+	}
+
+	return retVal
+}
+
+func TestCollectionInjection(t *testing.T) {
+	locator, err := CreateAndBind(collectionLocator1, func(binder Binder) error {
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("low").Ranked(1).
+			WithMetadata(regionMetadata, "east")
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("high").Ranked(10).
+			WithMetadata(regionMetadata, "east")
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("middle").Ranked(5).
+			WithMetadata(regionMetadata, "west")
+		binder.Bind("Host", &pluginHost{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	host, err := GetD[*pluginHost](locator, "Host")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"high", "middle", "low"}, pluginNames(host.ByName))
+	assert.Equal(t, []string{"high", "middle", "low"}, pluginNames(host.ByType))
+
+	if assert.Equal(t, 3, len(host.ByQual)) {
+		assert.Equal(t, "low", host.ByQual["low"].PluginName())
+		assert.Equal(t, "middle", host.ByQual["middle"].PluginName())
+	}
+
+	if assert.Equal(t, 2, len(host.ByRegion)) {
+		assert.Equal(t, "high", host.ByRegion["east"].PluginName(), "highest rank wins for the same key")
+		assert.Equal(t, "middle", host.ByRegion["west"].PluginName())
+	}
+
+	assert.NotNil(t, host.Empty)
+	assert.Equal(t, 0, len(host.Empty))
+
+	assert.Nil(t, Verify(locator))
+}
+
+func TestCollectionInjectionFailures(t *testing.T) {
+	lastErrorInformation = make([]ErrorInformation, 0)
+
+	locator, err := CreateAndBind(collectionLocator2, func(binder Binder) error {
+		binder.Bind(ErrorServiceName, errorServiceData{}).InNamespace(UserServicesNamespace)
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("good")
+		binder.Bind(collectionPluginName, &failingPlugin{}).QualifiedBy("bad").Ranked(5)
+		binder.Bind(collectionPluginName, &notAPlugin{}).QualifiedBy("wrong").Ranked(3)
+		binder.Bind("Host", &pluginHost{})
+		binder.Bind("StrictHost", &strictPluginHost{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	host, err := GetD[*pluginHost](locator, "Host")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"good"}, pluginNames(host.ByName))
+
+	failed := 0
+	wrong := 0
+	for _, ei := range lastErrorInformation {
+		if ei.GetType() != ServiceCreationFailure {
+			continue
+		}
+
+		switch ei.GetDescriptor().GetQualifiers()[0] {
+		case "bad":
+			failed++
+		case "wrong":
+			_, ok := ei.GetAssociatedError().(ServiceTypeInfo)
+			assert.True(t, ok, "a plugin of the wrong type should be a type error")
+			wrong++
+		}
+	}
+	assert.True(t, failed > 0, "the failing plugin should be given to the ErrorService")
+	assert.True(t, wrong > 0, "the plugin of the wrong type should be given to the ErrorService")
+
+	_, err = locator.GetDService("StrictHost")
+	assert.NotNil(t, err, "strict injection should fail when a plugin fails")
+}
+
+func TestCollectionConstructorParameter(t *testing.T) {
+	locator, err := CreateAndBind(collectionLocator3, func(binder Binder) error {
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("one").Ranked(2)
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("two").Ranked(1)
+		binder.BindConstructor("Host", func(plugins []collectionPlugin) *strictPluginHost {
+			return &strictPluginHost{
+				Plugins: plugins,
+			}
+		}, ",all")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	host, err := GetD[*strictPluginHost](locator, "Host")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"one", "two"}, pluginNames(host.Plugins))
+
+	assert.Panics(t, func() {
+		BindIntoLocator(locator, func(binder Binder) error {
+			binder.BindConstructor("BadHost", func(plugins collectionPlugin) *strictPluginHost {
+				return nil
+			}, ",all")
+			return nil
+		})
+	})
+}
+
+func TestCollectionNamedKeyType(t *testing.T) {
+	locator, err := CreateAndBind(collectionLocator4, func(binder Binder) error {
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("east").
+			WithMetadata(regionMetadata, "us-east")
+		binder.Bind(collectionPluginName, &namedPlugin{}).QualifiedBy("west").
+			WithMetadata(regionMetadata, "us-west")
+		binder.Bind("Host", &regionPluginHost{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	assert.Nil(t, Verify(locator))
+
+	host, err := GetD[*regionPluginHost](locator, "Host")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	if assert.Equal(t, 2, len(host.ByRegion)) {
+		assert.Equal(t, "east", host.ByRegion[pluginRegion("us-east")].PluginName())
+		assert.Equal(t, "west", host.ByRegion[pluginRegion("us-west")].PluginName())
+	}
+}
@@ -0,0 +1,538 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package config
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/jwells131313/goethe"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ConfigServiceName is the name of the ConfigService in the default namespace
+	ConfigServiceName = "ConfigService"
+
+	// ConfigListenerName the name an implementation of ConfigListener must have
+	ConfigListenerName = "ConfigListener"
+
+	// VerificationMissingConfiguration is returned by VerificationInfo.GetVerificationType
+	// when a required configuration key has no value and no default
+	VerificationMissingConfiguration = "MISSING_CONFIGURATION"
+
+	// VerificationInvalidConfiguration is returned by VerificationInfo.GetVerificationType
+	// when a configuration value or default can not be converted to the type of the field
+	VerificationInvalidConfiguration = "INVALID_CONFIGURATION"
+)
+
+// ConfigService holds the configuration loaded from the sources given to EnableConfig
+type ConfigService interface {
+	// Get returns the value of the dotted key, such as server.port.  The
+	// value of a key with children is a map[string]interface{}
+	Get(key string) (interface{}, bool)
+
+	// Unmarshal converts the value of the dotted key into the value pointed
+	// to by target, in the same way as values are injected with the config tag
+	Unmarshal(key string, target interface{}) error
+
+	// Keys returns the dotted keys of all of the values that have no children
+	Keys() []string
+
+	// Reload loads all of the sources again.  If any values changed the
+	// ConfigListener services are told.  If any source fails to load the
+	// current configuration is kept and the error is returned
+	Reload() error
+
+	// OnChange adds a function that is called, after the ConfigListener services,
+	// each time a reload changes at least one value.  It is the place to re-read
+	// values held by services in scopes wider than PerLookup
+	OnChange(callback func(ChangeEvent))
+}
+
+// ConfigListener is a service that must be in namespace ioc.UserServicesNamespace
+// and have name ConfigListenerName.  It is told when the configuration changes
+type ConfigListener interface {
+	// ConfigChanged is called after the configuration has been reloaded
+	// and at least one value was added, changed or removed
+	ConfigChanged(event ChangeEvent)
+
+	// ConfigReloadFailed is called when a reload started by polling fails,
+	// in which case the configuration is not changed
+	ConfigReloadFailed(err error)
+}
+
+type boundConfigListener struct {
+	desc     ioc.Descriptor
+	listener ConfigListener
+}
+
+// ChangeEvent describes a change to the configuration
+type ChangeEvent interface {
+	// GetChangedKeys returns the dotted keys of the values that were added,
+	// changed or removed, in sorted order
+	GetChangedKeys() []string
+
+	// GetAffected returns the services that were injected with a changed value,
+	// or with a value that has a changed value as a child.  PerLookup services get
+	// the new values the next time they are looked up, while services in wider
+	// scopes keep the values they were created with
+	GetAffected() []ioc.Descriptor
+
+	// GetService returns the ConfigService that changed
+	GetService() ConfigService
+}
+
+type changeEventData struct {
+	keys     []string
+	affected []ioc.Descriptor
+	service  ConfigService
+}
+
+func (ced *changeEventData) GetChangedKeys() []string {
+	return ced.keys
+}
+
+func (ced *changeEventData) GetAffected() []ioc.Descriptor {
+	return ced.affected
+}
+
+func (ced *changeEventData) GetService() ConfigService {
+	return ced.service
+}
+
+type configServiceData struct {
+	locator ioc.ServiceLocator
+	desc    ioc.Descriptor
+	sources []Source
+
+	lock      sync.RWMutex
+	values    map[string]interface{}
+	injected  map[string]map[ioc.Descriptor]bool
+	callbacks []func(ChangeEvent)
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// EnableConfig adds a ConfigService made from the sources to the locator, along
+// with an InjectionResolver for the config tag and a Verifier that checks that the
+// values of all required keys are present.  Values of later sources override those
+// of earlier sources.  If pollInterval is positive the sources are checked for
+// changes at that interval, and are reloaded when any of them has changed, until
+// the locator is shut down
+//
+// A reload does not re-create services.  PerLookup services get the new values the
+// next time they are looked up, but services in wider scopes such as Singleton keep
+// the values they were created with.  Those services can implement ConfigListener,
+// or be given a function with ConfigService.OnChange, to pick up the new values.
+// Panics in either are given to the ErrorService as ioc.ListenerFailure
+//
+// A field with a config tag such as `config:"server.port"` is injected with the
+// value of that key, converted to the type of the field.  The tag may also have a
+// required option, and a default option which must come last, such as
+// `config:"server.port,required"` or `config:"server.host,default=localhost"`.
+// A field whose key has no value and no default is left alone unless it is required,
+// in which case creating the service fails
+func EnableConfig(locator ioc.ServiceLocator, pollInterval time.Duration, sources ...Source) (ConfigService, error) {
+	service := &configServiceData{
+		locator:  locator,
+		sources:  sources,
+		injected: make(map[string]map[ioc.Descriptor]bool),
+		stop:     make(chan struct{}),
+	}
+
+	values, err := service.load()
+	if err != nil {
+		return nil, err
+	}
+	service.values = values
+
+	err = ioc.BindIntoLocator(locator, func(binder ioc.Binder) error {
+		binder.BindConstant(ConfigServiceName, service)
+		binder.BindConstant(ioc.InjectionResolverName, &configResolver{service: service}).
+			InNamespace(ioc.UserServicesNamespace)
+		binder.BindConstant(ioc.VerifierName, &configVerifier{service: service}).
+			InNamespace(ioc.UserServicesNamespace)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	service.desc, err = locator.GetBestDescriptor(ioc.NewSingleFilter(ioc.DefaultNamespace, ConfigServiceName))
+	if err != nil {
+		return nil, err
+	}
+
+	// Looking the service up makes the locator destroy it, which stops polling, on shutdown
+	_, err = locator.GetDService(ConfigServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	if pollInterval > 0 {
+		_, err = goethe.GG().Go(service.poll, pollInterval)
+		if err != nil {
+			service.stopPolling()
+			return nil, err
+		}
+	}
+
+	return service, nil
+}
+
+func (service *configServiceData) load() (map[string]interface{}, error) {
+	retVal := make(map[string]interface{})
+	for _, source := range service.sources {
+		values, err := source.Load()
+		if err != nil {
+			return nil, fmt.Errorf("could not load configuration from %s: %v", source.GetName(), err)
+		}
+
+		merge(retVal, values)
+	}
+
+	return retVal, nil
+}
+
+func (service *configServiceData) Get(key string) (interface{}, bool) {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	return getPath(service.values, key)
+}
+
+func (service *configServiceData) Unmarshal(key string, target interface{}) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() {
+		return fmt.Errorf("target of configuration key %s must be a non-nil pointer", key)
+	}
+
+	raw, found := service.Get(key)
+	if !found {
+		return fmt.Errorf("there is no configuration value for key %s", key)
+	}
+
+	value, err := convert(raw, targetValue.Elem().Type())
+	if err != nil {
+		return fmt.Errorf("configuration key %s: %v", key, err)
+	}
+
+	targetValue.Elem().Set(value)
+
+	return nil
+}
+
+func (service *configServiceData) Keys() []string {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	flat := make(map[string]interface{})
+	flatten("", service.values, flat)
+
+	retVal := make([]string, 0, len(flat))
+	for key := range flat {
+		retVal = append(retVal, key)
+	}
+	sort.Strings(retVal)
+
+	return retVal
+}
+
+func (service *configServiceData) Reload() error {
+	values, err := service.load()
+	if err != nil {
+		return err
+	}
+
+	service.lock.Lock()
+	oldValues := service.values
+	service.values = values
+	service.lock.Unlock()
+
+	changed := changedKeys(oldValues, values)
+	if len(changed) == 0 {
+		return nil
+	}
+
+	event := &changeEventData{
+		keys:     changed,
+		affected: service.affected(changed),
+		service:  service,
+	}
+
+	listeners, err := service.getListeners()
+	if err != nil {
+		return err
+	}
+
+	for _, bound := range listeners {
+		ret := &errorReturn{}
+		safeConfigChanged(bound.listener, event, ret)
+		service.reportListenerFailure(bound.desc, ret.err)
+	}
+
+	service.lock.RLock()
+	callbacks := make([]func(ChangeEvent), len(service.callbacks))
+	copy(callbacks, service.callbacks)
+	service.lock.RUnlock()
+
+	for _, callback := range callbacks {
+		ret := &errorReturn{}
+		safeChangeCallback(callback, event, ret)
+		service.reportListenerFailure(service.desc, ret.err)
+	}
+
+	return nil
+}
+
+func (service *configServiceData) OnChange(callback func(ChangeEvent)) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	service.callbacks = append(service.callbacks, callback)
+}
+
+func (service *configServiceData) DargoDestroy(ioc.Descriptor) error {
+	service.stopPolling()
+
+	return nil
+}
+
+func (service *configServiceData) stopPolling() {
+	service.stopOnce.Do(func() {
+		close(service.stop)
+	})
+}
+
+// reportListenerFailure gives the failure of a listener, if any, to the ErrorService
+func (service *configServiceData) reportListenerFailure(desc ioc.Descriptor, err error) {
+	if err == nil {
+		return
+	}
+
+	ioc.ReportFailure(service.locator, ioc.ListenerFailure, desc, err)
+}
+
+func (service *configServiceData) poll(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-service.stop:
+			return
+		case <-ticker.C:
+		}
+
+		if service.locator.GetState() != ioc.LocatorStateRunning {
+			// The locator is going away even if it has not yet destroyed this service
+			service.stopPolling()
+			return
+		}
+
+		changed, err := service.anyChanged()
+		if err == nil && !changed {
+			continue
+		}
+
+		if err == nil {
+			err = service.Reload()
+		}
+
+		if err != nil {
+			listeners, lerr := service.getListeners()
+			if lerr != nil {
+				continue
+			}
+
+			for _, bound := range listeners {
+				ret := &errorReturn{}
+				safeConfigReloadFailed(bound.listener, err, ret)
+				service.reportListenerFailure(bound.desc, ret.err)
+			}
+		}
+	}
+}
+
+func (service *configServiceData) anyChanged() (bool, error) {
+	for _, source := range service.sources {
+		changed, err := source.Changed()
+		if err != nil {
+			return false, fmt.Errorf("could not check configuration from %s: %v", source.GetName(), err)
+		}
+
+		if changed {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (service *configServiceData) getListeners() ([]boundConfigListener, error) {
+	descs, err := service.locator.GetDescriptors(ioc.NewSingleFilter(ioc.UserServicesNamespace, ConfigListenerName))
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]boundConfigListener, 0, len(descs))
+	for _, desc := range descs {
+		raw, err := service.locator.GetServiceFromDescriptor(desc)
+		if err != nil {
+			return nil, err
+		}
+
+		listener, ok := raw.(ConfigListener)
+		if !ok {
+			return nil, fmt.Errorf("a service %v with config listener key does not implement ConfigListener", raw)
+		}
+
+		retVal = append(retVal, boundConfigListener{desc: desc, listener: listener})
+	}
+
+	return retVal, nil
+}
+
+// recordInjection remembers that the service was injected with the value of the key
+func (service *configServiceData) recordInjection(key string, desc ioc.Descriptor) {
+	service.lock.Lock()
+	defer service.lock.Unlock()
+
+	descs, found := service.injected[key]
+	if !found {
+		descs = make(map[ioc.Descriptor]bool)
+		service.injected[key] = descs
+	}
+
+	descs[desc] = true
+}
+
+// affected returns the services injected with any of the changed keys, or
+// with a parent or child of any of them
+func (service *configServiceData) affected(changed []string) []ioc.Descriptor {
+	service.lock.RLock()
+	defer service.lock.RUnlock()
+
+	found := make(map[ioc.Descriptor]bool)
+	retVal := make([]ioc.Descriptor, 0)
+	for injectedKey, descs := range service.injected {
+		for _, changedKey := range changed {
+			if changedKey != injectedKey && !strings.HasPrefix(changedKey, injectedKey+".") &&
+				!strings.HasPrefix(injectedKey, changedKey+".") {
+				continue
+			}
+
+			for desc := range descs {
+				if !found[desc] {
+					found[desc] = true
+					retVal = append(retVal, desc)
+				}
+			}
+		}
+	}
+
+	return retVal
+}
+
+func changedKeys(oldValues, newValues map[string]interface{}) []string {
+	oldFlat := make(map[string]interface{})
+	flatten("", oldValues, oldFlat)
+	newFlat := make(map[string]interface{})
+	flatten("", newValues, newFlat)
+
+	retVal := make([]string, 0)
+	for key, oldValue := range oldFlat {
+		newValue, found := newFlat[key]
+		if !found || !reflect.DeepEqual(oldValue, newValue) {
+			retVal = append(retVal, key)
+		}
+	}
+
+	for key := range newFlat {
+		if _, found := oldFlat[key]; !found {
+			retVal = append(retVal, key)
+		}
+	}
+
+	sort.Strings(retVal)
+
+	return retVal
+}
+
+type errorReturn struct {
+	err error
+}
+
+func safeConfigChanged(listener ConfigListener, event ChangeEvent, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("config listener %T failed on a change: %v", listener, r)
+		}
+	}()
+
+	listener.ConfigChanged(event)
+}
+
+func safeConfigReloadFailed(listener ConfigListener, err error, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("config listener %T failed on a failed reload: %v", listener, r)
+		}
+	}()
+
+	listener.ConfigReloadFailed(err)
+}
+
+func safeChangeCallback(callback func(ChangeEvent), event ChangeEvent, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("config change callback failed: %v", r)
+		}
+	}()
+
+	callback(event)
+}
@@ -0,0 +1,400 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package config
+
+import (
+	"context"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	configLocator1 = "ConfigLocator1"
+	configLocator2 = "ConfigLocator2"
+	configLocator3 = "ConfigLocator3"
+	configLocator4 = "ConfigLocator4"
+	configLocator5 = "ConfigLocator5"
+)
+
+type limits struct {
+	MaxConnections int `yaml:"max_connections"`
+	Burst          int `yaml:"burst"`
+}
+
+type serverConfig struct {
+	Port    int           `config:"server.port,required"`
+	Host    string        `config:"server.host,default=localhost"`
+	Timeout time.Duration `config:"server.timeout"`
+	Debug   bool          `config:"server.debug"`
+	Tags    []string      `config:"server.tags"`
+	Limits  limits        `config:"server.limits"`
+	Missing string        `config:"server.missing"`
+}
+
+type needsMissing struct {
+	Value string `config:"no.such.key,required"`
+}
+
+type badConversion struct {
+	Port int `config:"server.host"`
+}
+
+const serverYAML = `
+server:
+  port: 8080
+  timeout: 5s
+  tags: [a, b]
+  limits:
+    max_connections: 10
+    burst: 2
+`
+
+func writeFile(t *testing.T, dir string, name string, contents string) string {
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, []byte(contents), 0600)
+	assert.Nil(t, err)
+
+	return path
+}
+
+func TestConfigInjection(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "server.yaml", serverYAML)
+	jsonFile := writeFile(t, dir, "override.json", `{"server": {"limits": {"burst": 5}}}`)
+	t.Setenv("CONFIGTEST_SERVER_DEBUG", "true")
+
+	locator, err := ioc.CreateAndBind(configLocator1, func(binder ioc.Binder) error {
+		binder.Bind("Server", serverConfig{})
+		binder.Bind("NeedsMissing", needsMissing{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	service, err := EnableConfig(locator, 0, NewMapSource("defaults", map[string]interface{}{
+		"server.port":  80,
+		"server.debug": false,
+	}), NewFileSource(yamlFile), NewFileSource(jsonFile), NewEnvSource("CONFIGTEST"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := locator.GetDService("Server")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	server := raw.(*serverConfig)
+	assert.Equal(t, 8080, server.Port)
+	assert.Equal(t, "localhost", server.Host)
+	assert.Equal(t, 5*time.Second, server.Timeout)
+	assert.True(t, server.Debug)
+	assert.Equal(t, []string{"a", "b"}, server.Tags)
+	assert.Equal(t, limits{MaxConnections: 10, Burst: 5}, server.Limits)
+	assert.Equal(t, "", server.Missing)
+
+	_, err = locator.GetDService("NeedsMissing")
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "no.such.key"), "error was %v", err)
+	}
+
+	lookedUp, err := ioc.GetD[ConfigService](locator, ConfigServiceName)
+	if assert.Nil(t, err) {
+		assert.Equal(t, service, lookedUp)
+	}
+
+	var port uint16
+	assert.Nil(t, service.Unmarshal("server.port", &port))
+	assert.Equal(t, uint16(8080), port)
+	assert.NotNil(t, service.Unmarshal("no.such.key", &port))
+
+	keys := service.Keys()
+	assert.Contains(t, keys, "server.limits.burst")
+	assert.Contains(t, keys, "server.debug")
+}
+
+func TestConfigVerify(t *testing.T) {
+	locator, err := ioc.CreateAndBind(configLocator2, func(binder ioc.Binder) error {
+		binder.Bind("Server", serverConfig{})
+		binder.Bind("NeedsMissing", needsMissing{})
+		binder.Bind("BadConversion", badConversion{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = EnableConfig(locator, 0, NewMapSource("values", map[string]interface{}{
+		"server.port": 8080,
+		"server.host": "example.com",
+	}))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = ioc.Verify(locator)
+	multi, ok := err.(ioc.MultiError)
+	if !assert.True(t, ok, "Verify should return a MultiError") {
+		return
+	}
+
+	types := make(map[string]string)
+	for _, verr := range multi.GetErrors() {
+		info, ok := verr.(ioc.VerificationInfo)
+		if assert.True(t, ok) {
+			types[info.GetDescriptor().GetName()] = info.GetVerificationType()
+		}
+	}
+
+	assert.Equal(t, map[string]string{
+		"NeedsMissing":  VerificationMissingConfiguration,
+		"BadConversion": VerificationInvalidConfiguration,
+	}, types)
+}
+
+type recordingConfigListener struct {
+	lock   sync.Mutex
+	events []ChangeEvent
+	errs   []error
+}
+
+func (rcl *recordingConfigListener) ConfigChanged(event ChangeEvent) {
+	rcl.lock.Lock()
+	defer rcl.lock.Unlock()
+
+	rcl.events = append(rcl.events, event)
+}
+
+func (rcl *recordingConfigListener) ConfigReloadFailed(err error) {
+	rcl.lock.Lock()
+	defer rcl.lock.Unlock()
+
+	rcl.errs = append(rcl.errs, err)
+}
+
+func (rcl *recordingConfigListener) getEvents() []ChangeEvent {
+	rcl.lock.Lock()
+	defer rcl.lock.Unlock()
+
+	retVal := make([]ChangeEvent, len(rcl.events))
+	copy(retVal, rcl.events)
+
+	return retVal
+}
+
+type portHolder struct {
+	Port int `config:"server.port"`
+}
+
+func TestConfigReloadByPolling(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "server.yaml", "server:\n  port: 8080\n")
+	listener := &recordingConfigListener{}
+
+	locator, err := ioc.CreateAndBind(configLocator3, func(binder ioc.Binder) error {
+		binder.BindConstant(ConfigListenerName, listener).InNamespace(ioc.UserServicesNamespace)
+		binder.Bind("PortHolder", portHolder{}).InScope(ioc.PerLookup)
+		binder.Bind("Unrelated", serverConfig{}).InScope(ioc.PerLookup)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	service, err := EnableConfig(locator, 10*time.Millisecond, NewFileSource(yamlFile))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := locator.GetDService("PortHolder")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 8080, raw.(*portHolder).Port)
+
+	writeFile(t, dir, "server.yaml", "server:\n  port: 9090\n  host: example.com\n")
+
+	var events []ChangeEvent
+	for lcv := 0; lcv < 200; lcv++ {
+		events = listener.getEvents()
+		if len(events) > 0 {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !assert.Equal(t, 1, len(events)) {
+		return
+	}
+
+	assert.Equal(t, []string{"server.host", "server.port"}, events[0].GetChangedKeys())
+	affected := events[0].GetAffected()
+	if assert.Equal(t, 1, len(affected)) {
+		assert.Equal(t, "PortHolder", affected[0].GetName())
+	}
+
+	raw, err = locator.GetDService("PortHolder")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 9090, raw.(*portHolder).Port, "PerLookup services get the new value")
+	}
+
+	assert.Nil(t, locator.ShutdownContext(context.Background()))
+	select {
+	case <-service.(*configServiceData).stop:
+	default:
+		assert.Fail(t, "polling should stop when the locator is shut down")
+	}
+}
+
+func TestConfigReloadFailure(t *testing.T) {
+	dir := t.TempDir()
+	yamlFile := writeFile(t, dir, "server.yaml", "server:\n  port: 8080\n")
+
+	locator, err := ioc.CreateAndBind(configLocator4, func(binder ioc.Binder) error {
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	service, err := EnableConfig(locator, 0, NewFileSource(yamlFile))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	writeFile(t, dir, "server.yaml", "server: [unclosed\n")
+	assert.NotNil(t, service.Reload())
+
+	port, found := service.Get("server.port")
+	assert.True(t, found, "a failed reload keeps the current configuration")
+	assert.Equal(t, 8080, port)
+
+	_, err = EnableConfig(locator, 0, NewFileSource(filepath.Join(dir, "no-such-file.yaml")))
+	assert.NotNil(t, err)
+}
+
+type panickingConfigListener struct{}
+
+func (pcl *panickingConfigListener) ConfigChanged(event ChangeEvent) {
+	panic("config listener panic")
+}
+
+func (pcl *panickingConfigListener) ConfigReloadFailed(err error) {
+	panic("config listener panic")
+}
+
+type recordingErrorService struct {
+	lock  sync.Mutex
+	infos []ioc.ErrorInformation
+}
+
+func (res *recordingErrorService) OnFailure(info ioc.ErrorInformation) error {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+
+	res.infos = append(res.infos, info)
+
+	return nil
+}
+
+func TestConfigListenerPanicsAndCallbacks(t *testing.T) {
+	errorService := &recordingErrorService{}
+
+	locator, err := ioc.CreateAndBind(configLocator5, func(binder ioc.Binder) error {
+		binder.BindConstant(ioc.ErrorServiceName, errorService).InNamespace(ioc.UserServicesNamespace)
+		binder.Bind(ConfigListenerName, panickingConfigListener{}).InNamespace(ioc.UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	values := map[string]interface{}{"server": map[string]interface{}{"port": 8080}}
+	service, err := EnableConfig(locator, 0, NewMapSource("defaults", values))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var changes []ChangeEvent
+	service.OnChange(func(event ChangeEvent) {
+		changes = append(changes, event)
+	})
+	service.OnChange(func(event ChangeEvent) {
+		panic("callback panic")
+	})
+
+	values["server"].(map[string]interface{})["port"] = 9090
+	if !assert.Nil(t, service.Reload()) {
+		return
+	}
+
+	if assert.Equal(t, 1, len(changes), "callbacks are called after a panicking listener") {
+		assert.Equal(t, []string{"server.port"}, changes[0].GetChangedKeys())
+	}
+
+	errorService.lock.Lock()
+	defer errorService.lock.Unlock()
+
+	if !assert.Equal(t, 2, len(errorService.infos)) {
+		return
+	}
+
+	assert.Equal(t, ioc.ListenerFailure, errorService.infos[0].GetType())
+	assert.Equal(t, ConfigListenerName, errorService.infos[0].GetDescriptor().GetName())
+	assert.True(t, strings.Contains(errorService.infos[0].GetAssociatedError().Error(), "config listener panic"))
+
+	assert.Equal(t, ioc.ListenerFailure, errorService.infos[1].GetType())
+	assert.Equal(t, ConfigServiceName, errorService.infos[1].GetDescriptor().GetName())
+	assert.True(t, strings.Contains(errorService.infos[1].GetAssociatedError().Error(), "callback panic"))
+}
@@ -0,0 +1,238 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package config
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+)
+
+const (
+	requiredOption = "required"
+	defaultOption  = "default="
+)
+
+// tagData is the parsed value of a config tag
+type tagData struct {
+	key        string
+	required   bool
+	hasDefault bool
+	defaultVal string
+}
+
+func parseConfigTag(tag string) (*tagData, error) {
+	parts := strings.Split(tag, ",")
+
+	retVal := &tagData{
+		key: strings.TrimSpace(parts[0]),
+	}
+	if retVal.key == "" {
+		return nil, fmt.Errorf("config tag %q has no key", tag)
+	}
+
+	for index := 1; index < len(parts); index++ {
+		part := strings.TrimSpace(parts[index])
+
+		if strings.HasPrefix(part, defaultOption) {
+			// The default is the rest of the tag, so it may contain commas
+			retVal.hasDefault = true
+			retVal.defaultVal = strings.Join(parts[index:], ",")[len(defaultOption):]
+			break
+		}
+
+		if part == requiredOption {
+			retVal.required = true
+			continue
+		}
+
+		return nil, fmt.Errorf("unknown option %s in config tag %q", part, tag)
+	}
+
+	return retVal, nil
+}
+
+// convert returns the value as the given type.  Strings, such as the values of
+// environment variables and defaults, are parsed as YAML unless the type is a
+// string, and other values are converted by way of YAML
+func convert(raw interface{}, ty reflect.Type) (reflect.Value, error) {
+	if raw == nil {
+		return reflect.Zero(ty), nil
+	}
+
+	rawValue := reflect.ValueOf(raw)
+	if rawValue.Type().AssignableTo(ty) {
+		return rawValue, nil
+	}
+
+	target := reflect.New(ty)
+
+	asString, isString := raw.(string)
+	if isString {
+		if ty.Kind() == reflect.String {
+			return rawValue.Convert(ty), nil
+		}
+
+		err := yaml.Unmarshal([]byte(asString), target.Interface())
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("could not convert %q to %v: %v", asString, ty, err)
+		}
+
+		return target.Elem(), nil
+	}
+
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	err = yaml.Unmarshal(data, target.Interface())
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("could not convert %v to %v: %v", raw, ty, err)
+	}
+
+	return target.Elem(), nil
+}
+
+// configResolver is the InjectionResolver for fields with the config tag
+type configResolver struct {
+	service *configServiceData
+}
+
+func (cr *configResolver) Resolve(locator ioc.ServiceLocator, injectee ioc.Injectee) (*reflect.Value, bool, error) {
+	field := injectee.GetField()
+
+	tag, hasTag := field.Tag.Lookup("config")
+	if !hasTag {
+		return nil, false, nil
+	}
+
+	td, err := parseConfigTag(tag)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cr.service.recordInjection(td.key, injectee.GetDescriptor())
+
+	raw, found := cr.service.Get(td.key)
+
+	if !found {
+		if !td.hasDefault {
+			if td.required {
+				return nil, false, fmt.Errorf("required configuration key %s of field %s has no value",
+					td.key, field.Name)
+			}
+
+			return nil, false, nil
+		}
+
+		raw = td.defaultVal
+	}
+
+	value, err := convert(raw, field.Type)
+	if err != nil {
+		return nil, false, fmt.Errorf("configuration key %s of field %s: %v", td.key, field.Name, err)
+	}
+
+	return &value, true, nil
+}
+
+// configVerifier is the Verifier that checks the config tags of services
+type configVerifier struct {
+	service *configServiceData
+}
+
+func (cv *configVerifier) VerifyService(locator ioc.ServiceLocator, desc ioc.Descriptor) error {
+	typedDesc, ok := desc.(ioc.TypedDescriptor)
+	if !ok {
+		return nil
+	}
+
+	ty := typedDesc.GetImplementationType()
+	for ty != nil && ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	if ty == nil || ty.Kind() != reflect.Struct {
+		return nil
+	}
+
+	errs := ioc.NewMultiError()
+	for lcv := 0; lcv < ty.NumField(); lcv++ {
+		field := ty.Field(lcv)
+
+		tag, hasTag := field.Tag.Lookup("config")
+		if !hasTag {
+			continue
+		}
+
+		td, err := parseConfigTag(tag)
+		if err != nil {
+			errs.AddError(ioc.NewVerificationError(ioc.VerificationInvalidInjectionPoint, desc, field.Name,
+				err.Error()))
+			continue
+		}
+
+		raw, found := cv.service.Get(td.key)
+		if !found {
+			if !td.hasDefault {
+				if td.required {
+					errs.AddError(ioc.NewVerificationError(VerificationMissingConfiguration, desc, field.Name,
+						fmt.Sprintf("required configuration key %s has no value", td.key)))
+				}
+
+				continue
+			}
+
+			raw = td.defaultVal
+		}
+
+		_, err = convert(raw, field.Type)
+		if err != nil {
+			errs.AddError(ioc.NewVerificationError(VerificationInvalidConfiguration, desc, field.Name,
+				fmt.Sprintf("configuration key %s: %v", td.key, err)))
+		}
+	}
+
+	return errs.GetFinalError()
+}
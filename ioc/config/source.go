@@ -0,0 +1,294 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Source is a layer of configuration.  The values of later sources
+// given to EnableConfig override the values of earlier ones
+type Source interface {
+	// GetName returns a name for the source used in error messages
+	GetName() string
+
+	// Load returns the configuration of the source as a tree of
+	// maps keyed by the parts of the dotted configuration keys
+	Load() (map[string]interface{}, error)
+
+	// Changed returns true if the source has changed since it was last loaded
+	Changed() (bool, error)
+}
+
+type fileSource struct {
+	lock    sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// NewFileSource returns a Source that reads the given file.  Files ending
+// in .json are read as JSON and all others are read as YAML.  The file is
+// considered changed when its modification time or size changes
+func NewFileSource(path string) Source {
+	return &fileSource{
+		path: path,
+	}
+}
+
+func (fs *fileSource) GetName() string {
+	return fs.path
+}
+
+func (fs *fileSource) Load() (map[string]interface{}, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(fs.path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := make(map[string]interface{})
+	if strings.EqualFold(filepath.Ext(fs.path), ".json") {
+		err = json.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not parse configuration file %s: %v", fs.path, err)
+	}
+
+	fs.modTime = info.ModTime()
+	fs.size = info.Size()
+
+	return normalize(raw).(map[string]interface{}), nil
+}
+
+func (fs *fileSource) Changed() (bool, error) {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	info, err := os.Stat(fs.path)
+	if err != nil {
+		return false, err
+	}
+
+	return !info.ModTime().Equal(fs.modTime) || info.Size() != fs.size, nil
+}
+
+type envSource struct {
+	prefix string
+}
+
+// NewEnvSource returns a Source made from the environment variables that
+// start with the prefix followed by an underscore.  The rest of the name of
+// the variable is lower cased and each underscore is replaced with a dot, so
+// with prefix APP the variable APP_SERVER_PORT is the key server.port.  The
+// environment is read once, when the configuration is loaded
+func NewEnvSource(prefix string) Source {
+	return &envSource{
+		prefix: prefix + "_",
+	}
+}
+
+func (es *envSource) GetName() string {
+	return "environment " + es.prefix + "*"
+}
+
+func (es *envSource) Load() (map[string]interface{}, error) {
+	retVal := make(map[string]interface{})
+
+	for _, env := range os.Environ() {
+		name, value, found := strings.Cut(env, "=")
+		if !found || !strings.HasPrefix(name, es.prefix) || len(name) == len(es.prefix) {
+			continue
+		}
+
+		key := strings.ToLower(strings.ReplaceAll(name[len(es.prefix):], "_", "."))
+		setPath(retVal, key, value)
+	}
+
+	return retVal, nil
+}
+
+func (es *envSource) Changed() (bool, error) {
+	return false, nil
+}
+
+type mapSource struct {
+	name   string
+	values map[string]interface{}
+}
+
+// NewMapSource returns a Source with the given values, which never changes.
+// The keys of the map may be dotted, and the values may be nested maps.  This
+// is useful for defaults and in tests
+func NewMapSource(name string, values map[string]interface{}) Source {
+	return &mapSource{
+		name:   name,
+		values: values,
+	}
+}
+
+func (ms *mapSource) GetName() string {
+	return ms.name
+}
+
+func (ms *mapSource) Load() (map[string]interface{}, error) {
+	retVal := make(map[string]interface{})
+	for key, value := range ms.values {
+		setPath(retVal, key, normalize(value))
+	}
+
+	return retVal, nil
+}
+
+func (ms *mapSource) Changed() (bool, error) {
+	return false, nil
+}
+
+// normalize makes every map in the tree a map[string]interface{}
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		retVal := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			retVal[key] = normalize(child)
+		}
+		return retVal
+	case map[interface{}]interface{}:
+		retVal := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			retVal[fmt.Sprint(key)] = normalize(child)
+		}
+		return retVal
+	case []interface{}:
+		retVal := make([]interface{}, len(v))
+		for index, child := range v {
+			retVal[index] = normalize(child)
+		}
+		return retVal
+	default:
+		return value
+	}
+}
+
+// setPath puts the value into the tree at the dotted key, merging
+// it with any map already there
+func setPath(tree map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	current := tree
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := current[part].(map[string]interface{})
+		if !ok {
+			child = make(map[string]interface{})
+			current[part] = child
+		}
+
+		current = child
+	}
+
+	last := parts[len(parts)-1]
+	valueMap, isMap := value.(map[string]interface{})
+	existingMap, wasMap := current[last].(map[string]interface{})
+	if isMap && wasMap {
+		merge(existingMap, valueMap)
+		return
+	}
+
+	current[last] = value
+}
+
+// merge puts the values of from into into, with the values of from
+// winning except where both are maps, which are merged
+func merge(into map[string]interface{}, from map[string]interface{}) {
+	for key, value := range from {
+		setPath(into, key, value)
+	}
+}
+
+// getPath returns the value at the dotted key of the tree
+func getPath(tree map[string]interface{}, key string) (interface{}, bool) {
+	var current interface{} = tree
+	for _, part := range strings.Split(key, ".") {
+		currentMap, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		current, ok = currentMap[part]
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+// flatten returns the leaf values of the tree keyed by their dotted keys
+func flatten(prefix string, tree map[string]interface{}, into map[string]interface{}) {
+	for key, value := range tree {
+		fullKey := key
+		if prefix != "" {
+			fullKey = prefix + "." + key
+		}
+
+		if child, ok := value.(map[string]interface{}); ok {
+			flatten(fullKey, child, into)
+			continue
+		}
+
+		into[fullKey] = value
+	}
+}
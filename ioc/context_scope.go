@@ -41,6 +41,7 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
 	"github.com/jwells131313/goethe/cache"
 )
@@ -95,21 +96,7 @@ func (cs *contextScopeData) removeContext(dc *dargoContext) {
 		}
 
 		// Destroy all services in this cache
-		innerCache.Remove(func(key interface{}, value interface{}) bool {
-			idKey, ok := key.(idKey)
-			if !ok {
-				return true
-			}
-
-			destroyer := idKey.desc.GetDestroyFunction()
-			if destroyer == nil {
-				return true
-			}
-
-			destroyer(cs.locator, idKey.desc, value)
-
-			return true
-		})
+		destroyAll(context.Background(), cs.locator, innerCache)
 
 		return true
 	})
@@ -190,25 +177,7 @@ func (cs *contextScopeData) DestroyOne(locator ServiceLocator, desc Descriptor)
 		return err
 	}
 
-	idKey := idKey{
-		desc: desc,
-	}
-
-	cache.Remove(func(key interface{}, value interface{}) bool {
-		if idKey == key {
-			destroyer := desc.GetDestroyFunction()
-
-			if destroyer != nil {
-				destroyer(locator, desc, value)
-			}
-
-			return true
-		}
-
-		return false
-	})
-
-	return nil
+	return destroyOne(locator, cache, desc)
 }
 
 func (cs *contextScopeData) GetSupportsNilCreation(locator ServiceLocator) bool {
@@ -220,30 +189,27 @@ func (cs *contextScopeData) IsActive(locator ServiceLocator) bool {
 }
 
 func (cs *contextScopeData) Shutdown(locator ServiceLocator) {
+	cs.ShutdownContext(context.Background(), locator)
+}
+
+func (cs *contextScopeData) ShutdownContext(ctx context.Context, locator ServiceLocator) error {
+	errs := NewMultiError()
+
 	cs.contextCaches.Remove(func(key interface{}, value interface{}) bool {
 		innerCache, ok := value.(cache.Cache)
 		if !ok {
 			return true
 		}
 
-		innerCache.Remove(func(key interface{}, v2 interface{}) bool {
-			idKey, ok := key.(idKey)
-			if !ok {
-				return true
-			}
-
-			destroyer := idKey.desc.GetDestroyFunction()
-			if destroyer == nil {
-				return true
-			}
-
-			destroyer(locator, idKey.desc, v2)
-
-			return true
-		})
+		err := destroyAll(ctx, locator, innerCache)
+		if err != nil {
+			errs.AddError(err)
+		}
 
 		return true
 	})
+
+	return errs.GetFinalError()
 }
 
 func (cs *contextScopeData) Compute(in interface{}) (interface{}, error) {
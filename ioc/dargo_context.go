@@ -105,6 +105,56 @@ func NewDargoContext(parent context.Context, locator ServiceLocator) (context.Co
 	return retVal, nil
 }
 
+// CurrentDargoContext returns the dargo context of the lookup or creation being
+// done on the current goroutine, such as a lookup done with the Value method of a
+// dargo context.  It is for implementations of ContextualScope that keep services
+// per context.Context.  It returns false if there is no current dargo context
+func CurrentDargoContext() (context.Context, bool) {
+	if threadManager.GetThreadID() < 0 {
+		return nil, false
+	}
+
+	tl, err := threadManager.GetThreadLocal(dargoContextThreadLocal)
+	if err != nil {
+		return nil, false
+	}
+
+	raw, err := tl.Get()
+	if err != nil {
+		return nil, false
+	}
+
+	stack, ok := raw.(stack)
+	if !ok {
+		return nil, false
+	}
+
+	rawContext, found := stack.Peek()
+	if !found {
+		return nil, false
+	}
+
+	retVal, ok := rawContext.(*dargoContext)
+	if !ok {
+		return nil, false
+	}
+
+	return retVal, true
+}
+
+// GetServiceInContext returns the best service with the given key as found with
+// the dargo context of ctx, which must be a context returned from NewDargoContext
+// or a context derived from one.  Unlike the Value method of the context, any
+// error from the lookup is returned
+func GetServiceInContext(ctx context.Context, key ServiceKey) (interface{}, error) {
+	dgo, ok := ctx.Value(dargoContextKey{}).(*dargoContext)
+	if !ok {
+		return nil, fmt.Errorf("the context was not created with NewDargoContext")
+	}
+
+	return dgo.getValue(key)
+}
+
 type doneStruct struct{}
 
 func (dgo *dargoContext) killMe() {
@@ -128,9 +178,15 @@ func (dgo *dargoContext) Err() error {
 	return dgo.parent.Err()
 }
 
+// dargoContextKey is the key with which a dargoContext returns
+// itself from Value, so that it can be found from derived contexts
+type dargoContextKey struct{}
+
 func (dgo *dargoContext) Value(key interface{}) interface{} {
 
 	switch key.(type) {
+	case dargoContextKey:
+		return dgo
 	case ServiceKey:
 		retVal, err := dgo.getValue(key.(ServiceKey))
 		if err != nil {
@@ -158,23 +214,32 @@ type valReply struct {
 }
 
 func (dgo *dargoContext) getValue(key ServiceKey) (interface{}, error) {
+	return dgo.call(func() (interface{}, error) {
+		return dgo.locator.GetService(key)
+	})
+}
+
+// call runs the function with this context on the top of the dargo context
+// stack of a goethe thread, so that ContextScope services found by the
+// function are the ones of this context
+func (dgo *dargoContext) call(f func() (interface{}, error)) (interface{}, error) {
 	tid := threadManager.GetThreadID()
 
 	if tid < 0 {
 		c := make(chan (*valReply))
 
-		threadManager.Go(dgo.getChannelDargoValue, key, c)
+		threadManager.Go(dgo.getChannelDargoValue, f, c)
 
 		rply := <-c
 
 		return rply.val, rply.err
 	}
 
-	return dgo.getGoetheDargoValue(key)
+	return dgo.getGoetheDargoValue(f)
 }
 
-func (dgo *dargoContext) getChannelDargoValue(key ServiceKey, ch chan *valReply) {
-	retVal, err := dgo.getGoetheDargoValue(key)
+func (dgo *dargoContext) getChannelDargoValue(f func() (interface{}, error), ch chan *valReply) {
+	retVal, err := dgo.getGoetheDargoValue(f)
 
 	ret := &valReply{
 		val: retVal,
@@ -184,7 +249,7 @@ func (dgo *dargoContext) getChannelDargoValue(key ServiceKey, ch chan *valReply)
 	ch <- ret
 }
 
-func (dgo *dargoContext) getGoetheDargoValue(key ServiceKey) (interface{}, error) {
+func (dgo *dargoContext) getGoetheDargoValue(f func() (interface{}, error)) (interface{}, error) {
 	tl, err := threadManager.GetThreadLocal(dargoContextThreadLocal)
 	if err != nil {
 		return nil, err
@@ -206,7 +271,7 @@ func (dgo *dargoContext) getGoetheDargoValue(key ServiceKey) (interface{}, error
 	}
 	defer stack.Pop()
 
-	return dgo.locator.GetService(key)
+	return f()
 }
 
 type dargoContextCreationServiceData struct {
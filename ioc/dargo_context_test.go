@@ -54,6 +54,8 @@ const (
 	testDargoContextLocator1 = "TestDargoContextLocator1"
 	testDargoContextLocator2 = "TestDargoContextLocator2"
 	testDargoContextLocator3 = "TestDargoContextLocator3"
+	testDargoContextLocator4 = "TestDargoContextLocator4"
+	testDargoContextLocator5 = "TestDargoContextLocator5"
 
 	testDargoService   = "testDargoService"
 	testToUpperService = "testToUpperService"
@@ -223,6 +225,78 @@ func TestDargoContextCancel(t *testing.T) {
 
 }
 
+func TestDargoContextDargoDestroyer(t *testing.T) {
+	parentContext, canceller := context.WithCancel(context.Background())
+	defer canceller()
+
+	locator, err := CreateAndBind(testDargoContextLocator4, func(binder Binder) error {
+		binder.Bind(testDargoService, &destroyableService{}).InScope(ContextScope)
+
+		return nil
+	})
+	if !assert.Nil(t, err, "could not create locator") {
+		return
+	}
+
+	EnableDargoContextScope(locator)
+
+	dargoContext, err := createDargoContext(parentContext, t, locator)
+	if err != nil {
+		return
+	}
+
+	ds, ok := dargoContext.Value(testDargoService).(*destroyableService)
+	if !assert.True(t, ok, "could not get destroyable service from context") {
+		return
+	}
+
+	assert.False(t, ds.destroyed, "Have not cancelled context yet so should not be destroyed")
+
+	canceller()
+
+	<-dargoContext.Done()
+
+	assert.True(t, ds.destroyed, "DargoDestroy should be called when the context is cancelled")
+}
+
+func TestGetServiceInContext(t *testing.T) {
+	parentContext, canceller := context.WithCancel(context.Background())
+	defer canceller()
+
+	locator, err := CreateAndBind(testDargoContextLocator5, func(binder Binder) error {
+		binder.Bind(testDargoService, &destroyableService{}).InScope(ContextScope)
+
+		return nil
+	})
+	if !assert.Nil(t, err, "could not create locator") {
+		return
+	}
+
+	EnableDargoContextScope(locator)
+
+	dargoContext, err := createDargoContext(parentContext, t, locator)
+	if err != nil {
+		return
+	}
+
+	type derivedKey struct{}
+	derived := context.WithValue(dargoContext, derivedKey{}, "value")
+
+	ds, err := GetInContext[*destroyableService](derived, DSK(testDargoService))
+	if assert.Nil(t, err) {
+		assert.Equal(t, dargoContext.Value(testDargoService), ds, "derived contexts share the services of their dargo context")
+	}
+
+	_, err = GetServiceInContext(derived, DSK("NoSuchService"))
+	assert.True(t, IsServiceNotFound(err), "the lookup error should be returned")
+
+	_, err = GetServiceInContext(parentContext, DSK(testDargoService))
+	assert.NotNil(t, err, "not a dargo context")
+
+	_, found := CurrentDargoContext()
+	assert.False(t, found, "there is no lookup being done with a dargo context")
+}
+
 func hasValue(t *testing.T, expected int32, a, b, c int32) {
 	if a != expected && b != expected && c != expected {
 		t.Errorf("There was no expected return value of %d.  Instead got %d,%d,%d", expected, a, b, c)
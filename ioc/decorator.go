@@ -0,0 +1,182 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Decorator is implemented by services bound with Binder.Decorates.  When a
+// service it decorates is created the Decorator is given the service and what
+// it returns is used in place of the service, and so is what is cached by the
+// scope of the service and what is injected into other services.  A Decorator
+// that returns a value of another type than the service means the service can
+// no longer be found by its implementation type, only by the interfaces the
+// returned value implements.  Looking it up or injecting it by its implementation
+// type fails with an error implementing ServiceTypeInfo
+type Decorator interface {
+	// Decorate returns the decorated service, which may be the service itself.
+	// The descriptor is that of the service being decorated.  If Decorate returns
+	// an error the creation of the service fails
+	Decorate(desc Descriptor, service interface{}) (interface{}, error)
+}
+
+type decoratorFilter struct {
+	decorated Descriptor
+}
+
+// Filter returns true if the descriptor decorates the decorated service
+func (filter *decoratorFilter) Filter(desc Descriptor) bool {
+	if desc == filter.decorated {
+		return false
+	}
+
+	for _, value := range desc.GetMetadata()[DecoratesMetadata] {
+		namespace, name, err := splitContract(value, desc.GetNamespace())
+		if err != nil {
+			continue
+		}
+
+		if hasName(filter.decorated, namespace, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (filter *decoratorFilter) GetNamespace() string {
+	return ""
+}
+
+func (filter *decoratorFilter) GetName() string {
+	return ""
+}
+
+// wrap applies the decorators and then the interceptors of the service to a
+// newly created service, remembering the original service so that it is the
+// one that is destroyed.  PerLookup services are never destroyed and so the
+// original of those is not remembered
+func (locator *serviceLocatorData) wrap(desc Descriptor, service interface{}) (interface{}, error) {
+	switch desc.GetNamespace() {
+	case SystemNamespace, UserServicesNamespace, ContextualScopeNamespace:
+		return service, nil
+	}
+
+	retVal, err := locator.decorate(desc, service)
+	if err != nil {
+		return nil, err
+	}
+
+	retVal, err = locator.intercept(desc, retVal)
+	if err != nil {
+		return nil, err
+	}
+
+	if desc.GetScope() != PerLookup && retVal != nil && reflect.TypeOf(retVal).Comparable() && retVal != service {
+		locator.wrapped.Store(retVal, service)
+	}
+
+	return retVal, nil
+}
+
+// decorate gives the service to each of its decorators, highest rank first.  The
+// decorators are found from the index the locator keeps of them by the services
+// they decorate
+func (locator *serviceLocatorData) decorate(desc Descriptor, service interface{}) (interface{}, error) {
+	decoratorDescs, err := locator.GetDescriptors(&decoratorFilter{decorated: desc})
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := service
+	for _, decoratorDesc := range decoratorDescs {
+		raw, err := locator.GetServiceFromDescriptor(decoratorDesc)
+		if err != nil {
+			return nil, err
+		}
+
+		decorator, ok := raw.(Decorator)
+		if !ok {
+			return nil, fmt.Errorf("service %s decorates %s but does not implement Decorator",
+				decoratorDesc.GetFullName(), desc.GetFullName())
+		}
+
+		locator.recordDependency(desc, decoratorDesc)
+
+		ret := &errorReturn{}
+		retVal = safeDecorate(decorator, desc, retVal, ret)
+		if ret.err != nil {
+			return nil, ret.err
+		}
+	}
+
+	return retVal, nil
+}
+
+// unwrap returns the service that was decorated or intercepted to become the
+// value, forgetting it since the service is being destroyed
+func (locator *serviceLocatorData) unwrap(value interface{}) interface{} {
+	if value == nil || !reflect.TypeOf(value).Comparable() {
+		return value
+	}
+
+	service, found := locator.wrapped.LoadAndDelete(value)
+	if !found {
+		return value
+	}
+
+	return service
+}
+
+func safeDecorate(decorator Decorator, desc Descriptor, service interface{}, ret *errorReturn) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	decorated, err := decorator.Decorate(desc, service)
+	ret.err = err
+
+	return decorated
+}
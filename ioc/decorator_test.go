@@ -0,0 +1,233 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const (
+	decoratorLocator1 = "DecoratorLocator1"
+	decoratorLocator2 = "DecoratorLocator2"
+	decoratorLocator3 = "DecoratorLocator3"
+	decoratorLocator4 = "DecoratorLocator4"
+
+	greetingName = "Greeting"
+)
+
+type greeting interface {
+	Greet(name string) string
+}
+
+type plainGreeting struct {
+	destroyed bool
+}
+
+func (pg *plainGreeting) Greet(name string) string {
+	return "hello " + name
+}
+
+func (pg *plainGreeting) DargoDestroy(Descriptor) error {
+	pg.destroyed = true
+	return nil
+}
+
+type wrappedGreeting struct {
+	inner greeting
+	wrap  func(string) string
+}
+
+func (wg *wrappedGreeting) Greet(name string) string {
+	return wg.wrap(wg.inner.Greet(name))
+}
+
+type exclaimDecorator struct {
+}
+
+func (ed *exclaimDecorator) Decorate(desc Descriptor, service interface{}) (interface{}, error) {
+	return &wrappedGreeting{
+		inner: service.(greeting),
+		wrap: func(in string) string {
+			return in + "!"
+		},
+	}, nil
+}
+
+type upperDecorator struct {
+}
+
+func (ud *upperDecorator) Decorate(desc Descriptor, service interface{}) (interface{}, error) {
+	return &wrappedGreeting{
+		inner: service.(greeting),
+		wrap:  strings.ToUpper,
+	}, nil
+}
+
+type failingDecorator struct {
+}
+
+func (fd *failingDecorator) Decorate(desc Descriptor, service interface{}) (interface{}, error) {
+	return nil, fmt.Errorf(ExpectedPanicMessage)
+}
+
+type greetingUser struct {
+	Greeting greeting `inject:"Greeting"`
+}
+
+type plainGreetingUser struct {
+	Greeting *plainGreeting `inject:""`
+}
+
+func TestDecorators(t *testing.T) {
+	locator, err := CreateAndBind(decoratorLocator1, func(binder Binder) error {
+		binder.Bind(greetingName, &plainGreeting{})
+		binder.Bind("Upper", &upperDecorator{}).Decorates(greetingName).Ranked(1)
+		binder.Bind("Exclaim", &exclaimDecorator{}).Decorates("default#Greeting").Ranked(10)
+		binder.Bind("User", &greetingUser{})
+		binder.Bind("Undecorated", &plainGreeting{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	user, err := GetD[*greetingUser](locator, "User")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, "HELLO BOB!", user.Greeting.Greet("bob"), "the highest ranked decorator is applied first")
+
+	again, err := GetD[greeting](locator, greetingName)
+	assert.Nil(t, err)
+	assert.Equal(t, user.Greeting, again, "the decorated service is what is cached")
+
+	undecorated, err := GetD[greeting](locator, "Undecorated")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hello bob", undecorated.Greet("bob"))
+	}
+
+	original := user.Greeting.(*wrappedGreeting).inner.(*wrappedGreeting).inner.(*plainGreeting)
+
+	assert.Nil(t, locator.ShutdownContext(context.Background()))
+	assert.True(t, original.destroyed, "the original service should be destroyed")
+}
+
+func TestDecoratorFailures(t *testing.T) {
+	locator, err := CreateAndBind(decoratorLocator2, func(binder Binder) error {
+		binder.Bind(greetingName, &plainGreeting{})
+		binder.Bind("Fails", &failingDecorator{}).Decorates(greetingName)
+		binder.Bind("Other", &plainGreeting{})
+		binder.Bind("NotADecorator", &plainGreeting{}).Decorates("Other")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService(greetingName)
+	assert.NotNil(t, err, "a failing decorator fails the creation of the service")
+
+	_, err = locator.GetDService("Other")
+	assert.NotNil(t, err, "a decorator must implement Decorator")
+}
+
+func TestDecoratedServiceByType(t *testing.T) {
+	locator, err := CreateAndBind(decoratorLocator3, func(binder Binder) error {
+		binder.Bind(greetingName, &plainGreeting{})
+		binder.Bind("Exclaim", &exclaimDecorator{}).Decorates(greetingName)
+		binder.Bind("User", &plainGreetingUser{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = GetByType[*plainGreeting](locator)
+	if assert.NotNil(t, err, "the decorator changed the type of the service") {
+		_, ok := err.(ServiceTypeInfo)
+		assert.True(t, ok, "should be a type error, got %v", err)
+	}
+
+	_, err = locator.GetDService("User")
+	assert.NotNil(t, err, "a decorated service of another type cannot be injected by type")
+
+	decorated, err := GetD[greeting](locator, greetingName)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "hello bob!", decorated.Greet("bob"))
+	}
+}
+
+func TestDecoratedPerLookupNotRemembered(t *testing.T) {
+	locator, err := CreateAndBind(decoratorLocator4, func(binder Binder) error {
+		binder.Bind(greetingName, &plainGreeting{}).InScope(PerLookup).AlsoAs("Salutation")
+		binder.Bind("Exclaim", &exclaimDecorator{}).Decorates("Salutation")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	for lcv := 0; lcv < 10; lcv++ {
+		decorated, err := GetD[greeting](locator, greetingName)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		assert.Equal(t, "hello bob!", decorated.Greet("bob"), "decorators of a contract decorate the service")
+	}
+
+	remembered := 0
+	locator.(*serviceLocatorData).wrapped.Range(func(key, value interface{}) bool {
+		remembered++
+		return true
+	})
+	assert.Equal(t, 0, remembered, "PerLookup services are never destroyed so should not be remembered")
+}
@@ -0,0 +1,261 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"github.com/jwells131313/goethe/cache"
+	"sort"
+	"sync"
+)
+
+// dependencyData records the services that each service looked up,
+// either by injection or with a Provider, and the order in which
+// services were created.  It is used to destroy services after all
+// of the services that depend on them have been destroyed
+type dependencyData struct {
+	lock         sync.Mutex
+	dependencies map[Descriptor][]Descriptor
+	created      map[Descriptor]uint64
+	generation   uint64
+}
+
+func newDependencyData() *dependencyData {
+	return &dependencyData{
+		dependencies: make(map[Descriptor][]Descriptor),
+		created:      make(map[Descriptor]uint64),
+	}
+}
+
+func (dd *dependencyData) addDependency(from, to Descriptor) {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	current := dd.dependencies[from]
+	for _, already := range current {
+		if already == to {
+			return
+		}
+	}
+
+	dd.dependencies[from] = append(current, to)
+}
+
+func (dd *dependencyData) getDependencies(from Descriptor) []Descriptor {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	current := dd.dependencies[from]
+
+	retVal := make([]Descriptor, len(current))
+	copy(retVal, current)
+
+	return retVal
+}
+
+func (dd *dependencyData) addCreation(desc Descriptor) {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	dd.generation++
+	dd.created[desc] = dd.generation
+}
+
+func (dd *dependencyData) getCreation(desc Descriptor) uint64 {
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	return dd.created[desc]
+}
+
+// remove forgets the given descriptors, which have been removed from the locator
+func (dd *dependencyData) remove(descs []Descriptor) {
+	if len(descs) == 0 {
+		return
+	}
+
+	removed := make(map[Descriptor]bool)
+	for _, desc := range descs {
+		removed[desc] = true
+	}
+
+	dd.lock.Lock()
+	defer dd.lock.Unlock()
+
+	for from, tos := range dd.dependencies {
+		if removed[from] {
+			delete(dd.dependencies, from)
+			continue
+		}
+
+		kept := tos[:0]
+		for _, to := range tos {
+			if !removed[to] {
+				kept = append(kept, to)
+			}
+		}
+		dd.dependencies[from] = kept
+	}
+
+	for desc := range removed {
+		delete(dd.created, desc)
+	}
+}
+
+// recordDependency records that the forMe service looked up the desc service
+func (locator *serviceLocatorData) recordDependency(forMe, desc Descriptor) {
+	if forMe == nil || desc == nil {
+		return
+	}
+
+	locator.getOwner(forMe).dependencies.addDependency(forMe, desc)
+}
+
+// orderForDestruction returns the given descriptors in the order in which they
+// should be destroyed, which is that every service comes before all of the
+// services it depends on.  Services with no dependency between them are
+// destroyed in the reverse order of their creation
+func orderForDestruction(rawLocator ServiceLocator, descs []Descriptor) []Descriptor {
+	retVal := make([]Descriptor, len(descs))
+	copy(retVal, descs)
+
+	locator, ok := rawLocator.(*serviceLocatorData)
+	if !ok {
+		return retVal
+	}
+
+	members := make(map[Descriptor]bool)
+	for _, desc := range retVal {
+		members[desc] = true
+	}
+
+	sort.SliceStable(retVal, func(i, j int) bool {
+		return locator.getOwner(retVal[i]).dependencies.getCreation(retVal[i]) <
+			locator.getOwner(retVal[j]).dependencies.getCreation(retVal[j])
+	})
+
+	visited := make(map[Descriptor]bool)
+	ordered := make([]Descriptor, 0, len(retVal))
+
+	var visit func(Descriptor)
+	visit = func(desc Descriptor) {
+		if visited[desc] {
+			return
+		}
+		visited[desc] = true
+
+		for _, dependency := range locator.getOwner(desc).dependencies.getDependencies(desc) {
+			visit(dependency)
+		}
+
+		if members[desc] {
+			ordered = append(ordered, desc)
+		}
+	}
+
+	for _, desc := range retVal {
+		visit(desc)
+	}
+
+	for i, j := 0, len(ordered)-1; i < j; i, j = i+1, j-1 {
+		ordered[i], ordered[j] = ordered[j], ordered[i]
+	}
+
+	return ordered
+}
+
+// destroyAll removes every service from the given scope cache and destroys
+// them with DestroyServices
+func destroyAll(ctx context.Context, locator ServiceLocator, c cache.Cache) error {
+	values := make(map[Descriptor]interface{})
+
+	c.Remove(func(key interface{}, value interface{}) bool {
+		idKey, ok := key.(idKey)
+		if ok {
+			values[idKey.desc] = value
+		}
+
+		return true
+	})
+
+	return DestroyServices(ctx, locator, values)
+}
+
+// destroyOne removes the service of the descriptor from the cache and then destroys
+// it, outside of the lock of the cache so that its destroyer may look up other services
+func destroyOne(locator ServiceLocator, c cache.Cache, desc Descriptor) error {
+	lookForMe := idKey{desc: desc}
+	values := make(map[Descriptor]interface{})
+
+	c.Remove(func(key interface{}, value interface{}) bool {
+		if key != lookForMe {
+			return false
+		}
+
+		values[desc] = value
+
+		return true
+	})
+
+	return DestroyServices(context.Background(), locator, values)
+}
+
+// DestroyServices is for implementations of ContextualScope that need to destroy the
+// services they have created.  Each service is destroyed with its destroy function or
+// DargoDestroy method before the services it depends on.  Services with no dependency
+// between them are destroyed in the reverse order of their creation.  Failures are
+// given to the ErrorService and returned in a MultiError
+func DestroyServices(ctx context.Context, locator ServiceLocator, services map[Descriptor]interface{}) error {
+	descs := make([]Descriptor, 0, len(services))
+	for desc := range services {
+		descs = append(descs, desc)
+	}
+
+	errs := NewMultiError()
+	for _, desc := range orderForDestruction(locator, descs) {
+		err := destroyService(ctx, locator, desc, services[desc])
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
+
+	return errs.GetFinalError()
+}
@@ -42,6 +42,8 @@ package ioc
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 )
 
@@ -116,14 +118,99 @@ type WriteableDescriptor interface {
 	SetMetadata(map[string][]string) error
 }
 
+// ContractDescriptor is implemented by descriptors whose service is advertised
+// under additional names, such as the descriptors of this package.  Descriptors
+// that do not implement it are only found by their own namespace and name
+type ContractDescriptor interface {
+	// GetContracts Returns the additional namespace#name pairs this service
+	// is advertised under.  The service can be looked up or injected with
+	// any of these names in addition to its own namespace and name
+	GetContracts() []string
+}
+
+// WriteableContractDescriptor is implemented by writeable descriptors that can
+// advertise their service under additional names, such as the descriptors
+// returned by NewWriteableDescriptor and NewConstantDescriptor
+type WriteableContractDescriptor interface {
+	ContractDescriptor
+
+	// SetContracts sets the additional names this service is advertised under.
+	// Each contract is either of the form namespace#name or simply name, in which
+	// case the contract is in the same namespace as the service itself
+	SetContracts([]string) error
+}
+
+// getContracts returns the contracts of the descriptor, or nil if it does
+// not implement ContractDescriptor
+func getContracts(desc Descriptor) []string {
+	contractDesc, ok := desc.(ContractDescriptor)
+	if !ok {
+		return nil
+	}
+
+	return contractDesc.GetContracts()
+}
+
+// TypedDescriptor is implemented by descriptors that know the type of the
+// service they create, such as the descriptors of this package.  Descriptors
+// that do not implement it can not be found by type
+type TypedDescriptor interface {
+	// GetImplementationType returns the type of the service created by this
+	// descriptor, or nil if that type is not known.  Services can be looked up
+	// by this type or by any interface this type implements
+	GetImplementationType() reflect.Type
+}
+
+// WriteableTypedDescriptor is implemented by writeable descriptors whose
+// implementation type can be set, such as the descriptors returned by
+// NewWriteableDescriptor and NewConstantDescriptor
+type WriteableTypedDescriptor interface {
+	TypedDescriptor
+
+	// SetImplementationType sets the type of the service created by this descriptor
+	SetImplementationType(reflect.Type) error
+}
+
+// getImplementationType returns the implementation type of the descriptor, or
+// nil if it does not implement TypedDescriptor
+func getImplementationType(desc Descriptor) reflect.Type {
+	typedDesc, ok := desc.(TypedDescriptor)
+	if !ok {
+		return nil
+	}
+
+	return typedDesc.GetImplementationType()
+}
+
 type baseDescriptor struct {
 	lock                   sync.Mutex
 	namespace, name, scope string
 	qualifiers             []string
+	contracts              []string
 	visibility             int
 	metadata               map[string][]string
+	implementationType     reflect.Type
 	rank                   int32
 	serviceID, locatorID   int64
+	injectionPoints        []*injectionPoint
+	constant               bool
+	conditions             []Condition
+}
+
+// injectionInformation is implemented by the descriptors of this package
+// and carries what is known about the dependencies of a service without
+// creating it.  The injection points are nil if they are not known
+type injectionInformation interface {
+	getInjectionPoints() []*injectionPoint
+	setInjectionPoints([]*injectionPoint)
+	isConstant() bool
+}
+
+// conditionInformation is implemented by the descriptors of this package
+// and carries the conditions added with Binder.When
+type conditionInformation interface {
+	getConditions() []Condition
+	addCondition(Condition)
 }
 
 type descriptorImpl struct {
@@ -175,6 +262,24 @@ func NewDescriptor(desc Descriptor, serviceID, locatorID int64) (Descriptor, err
 		return nil, fmt.Errorf("descriptor must have visibility LocalVisibility or Normal, it is %d", visibility)
 	}
 
+	fullName := fmt.Sprintf("%s#%s", namespace, name)
+	contracts := make([]string, 0)
+	seen := map[string]bool{fullName: true}
+	for _, contract := range getContracts(desc) {
+		space, contractName, err := splitContract(contract, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		fullContract := fmt.Sprintf("%s#%s", space, contractName)
+		if seen[fullContract] {
+			continue
+		}
+		seen[fullContract] = true
+
+		contracts = append(contracts, fullContract)
+	}
+
 	retVal := &descriptorImpl{
 		creator:   creator,
 		destroyer: desc.GetDestroyFunction(),
@@ -184,12 +289,25 @@ func NewDescriptor(desc Descriptor, serviceID, locatorID int64) (Descriptor, err
 	retVal.name = name
 	retVal.scope = scope
 	retVal.qualifiers = qualifiers
+	retVal.contracts = contracts
 	retVal.visibility = visibility
 	retVal.metadata = copyMetadata(desc.GetMetadata())
+	retVal.implementationType = getImplementationType(desc)
 	retVal.rank = desc.GetRank()
 	retVal.serviceID = serviceID
 	retVal.locatorID = locatorID
 
+	info, ok := desc.(injectionInformation)
+	if ok {
+		retVal.injectionPoints = info.getInjectionPoints()
+		retVal.constant = info.isConstant()
+	}
+
+	conditional, ok := desc.(conditionInformation)
+	if ok {
+		retVal.conditions = conditional.getConditions()
+	}
+
 	return retVal, nil
 }
 
@@ -199,6 +317,7 @@ func NewWriteableDescriptor() WriteableDescriptor {
 
 	retVal.namespace = DefaultNamespace
 	retVal.qualifiers = make([]string, 0)
+	retVal.contracts = make([]string, 0)
 	retVal.metadata = make(map[string][]string)
 	retVal.visibility = NormalVisibility
 	retVal.scope = Singleton
@@ -216,9 +335,12 @@ func NewConstantDescriptor(sk ServiceKey, cnstnt interface{}) WriteableDescripto
 	retVal.namespace = sk.GetNamespace()
 	retVal.name = sk.GetName()
 	retVal.qualifiers = sk.GetQualifiers()
+	retVal.contracts = make([]string, 0)
 	retVal.metadata = make(map[string][]string)
+	retVal.implementationType = reflect.TypeOf(cnstnt)
 	retVal.visibility = NormalVisibility
 	retVal.scope = PerLookup
+	retVal.constant = true
 
 	return retVal
 
@@ -259,6 +381,15 @@ func (di *baseDescriptor) GetFullName() string {
 	return fmt.Sprintf("%s#%s", di.namespace, di.name)
 }
 
+func (di *baseDescriptor) GetContracts() []string {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	retVal := make([]string, len(di.contracts))
+	copy(retVal, di.contracts)
+	return retVal
+}
+
 func (di *baseDescriptor) GetScope() string {
 	di.lock.Lock()
 	defer di.lock.Unlock()
@@ -305,6 +436,57 @@ func (di *baseDescriptor) GetMetadata() map[string][]string {
 	return copyMetadata(di.metadata)
 }
 
+func (di *baseDescriptor) GetImplementationType() reflect.Type {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	return di.implementationType
+}
+
+func (di *baseDescriptor) getInjectionPoints() []*injectionPoint {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	return di.injectionPoints
+}
+
+func (di *baseDescriptor) setInjectionPoints(points []*injectionPoint) {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	di.injectionPoints = points
+}
+
+func (di *baseDescriptor) getConditions() []Condition {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	retVal := make([]Condition, len(di.conditions))
+	copy(retVal, di.conditions)
+	return retVal
+}
+
+func (di *baseDescriptor) addCondition(condition Condition) {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	di.conditions = append(di.conditions, condition)
+}
+
+func (di *baseDescriptor) isConstant() bool {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	return di.constant
+}
+
+func (di *baseDescriptor) setConstant() {
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	di.constant = true
+}
+
 func (di *baseDescriptor) GetRank() int32 {
 	di.lock.Lock()
 	defer di.lock.Unlock()
@@ -387,6 +569,50 @@ func (wdi *writeableDescriptorImpl) SetName(in string) error {
 	return nil
 }
 
+func (wdi *writeableDescriptorImpl) SetContracts(in []string) error {
+	wdi.lock.Lock()
+	defer wdi.lock.Unlock()
+
+	for _, contract := range in {
+		_, _, err := splitContract(contract, DefaultNamespace)
+		if err != nil {
+			return err
+		}
+	}
+
+	copied := make([]string, len(in))
+	copy(copied, in)
+
+	wdi.contracts = copied
+
+	return nil
+}
+
+// splitContract returns the namespace and name of a contract of the
+// form namespace#name or name, using defaultNamespace for the latter
+func splitContract(contract, defaultNamespace string) (string, string, error) {
+	namespace := defaultNamespace
+	name := contract
+
+	namespaceAndName := strings.SplitN(contract, "#", 2)
+	if len(namespaceAndName) == 2 {
+		namespace = namespaceAndName[0]
+		name = namespaceAndName[1]
+	}
+
+	err := checkNamespaceCharacters(namespace)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = checkNameCharacters(name)
+	if err != nil {
+		return "", "", err
+	}
+
+	return namespace, name, nil
+}
+
 func (wdi *writeableDescriptorImpl) SetScope(in string) error {
 	wdi.lock.Lock()
 	defer wdi.lock.Unlock()
@@ -442,6 +668,15 @@ func (wdi *writeableDescriptorImpl) SetMetadata(md map[string][]string) error {
 	return nil
 }
 
+func (wdi *writeableDescriptorImpl) SetImplementationType(ty reflect.Type) error {
+	wdi.lock.Lock()
+	defer wdi.lock.Unlock()
+
+	wdi.implementationType = ty
+
+	return nil
+}
+
 func (wdi *writeableDescriptorImpl) GetCreateFunction() func(sl ServiceLocator, sk Descriptor) (interface{}, error) {
 	wdi.lock.Lock()
 	defer wdi.lock.Unlock()
@@ -40,34 +40,102 @@
 
 package ioc
 
-// nameCache.  Locking is done via caller for ALL api
+import (
+	"reflect"
+	"sync"
+)
+
+// nameCache.  Locking is done via caller for ALL api, other than the
+// memoized interface lookups which are made by concurrent readers
 type nameCache struct {
-	all  []Descriptor
-	data map[string]map[string][]Descriptor
+	all      []Descriptor
+	inactive []Descriptor
+	data     map[string]map[string][]Descriptor
+	types    map[reflect.Type][]Descriptor
+
+	// decorators are the descriptors with DecoratesMetadata keyed by the
+	// namespace and name of the services they decorate
+	decorators map[string]map[string][]Descriptor
+
+	// interfaces memoizes the descriptors implementing each interface looked
+	// up, which is discarded whenever a descriptor is added
+	interfaceLock sync.Mutex
+	interfaces    map[reflect.Type][]Descriptor
 }
 
 func newNameCache() *nameCache {
 	return &nameCache{
-		all:  make([]Descriptor, 0),
-		data: make(map[string]map[string][]Descriptor),
+		all:        make([]Descriptor, 0),
+		inactive:   make([]Descriptor, 0),
+		data:       make(map[string]map[string][]Descriptor),
+		types:      make(map[reflect.Type][]Descriptor),
+		decorators: make(map[string]map[string][]Descriptor),
 	}
 }
 
+// getAll returns the descriptors that are found by lookups
 func (nc *nameCache) getAll() []Descriptor {
 	return nc.all
 }
 
+// getBound returns every bound descriptor, including those not found by
+// lookups because their profile is not active or a condition failed
+func (nc *nameCache) getBound() []Descriptor {
+	retVal := make([]Descriptor, 0, len(nc.all)+len(nc.inactive))
+	retVal = append(retVal, nc.all...)
+
+	return append(retVal, nc.inactive...)
+}
+
+// addInactive adds a descriptor that is bound but is not found by lookups
+func (nc *nameCache) addInactive(desc Descriptor) {
+	nc.inactive = append(nc.inactive, desc)
+}
+
 // add is add or replace
 func (nc *nameCache) add(desc Descriptor) {
 	nc.all = append(nc.all, desc)
 
-	space := desc.GetNamespace()
-	name := desc.GetName()
+	nc.interfaceLock.Lock()
+	nc.interfaces = nil
+	nc.interfaceLock.Unlock()
+
+	addToIndex(nc.data, desc.GetNamespace(), desc.GetName(), desc)
+
+	ty := getImplementationType(desc)
+	if ty != nil {
+		nc.types[ty] = append(nc.types[ty], desc)
+	}
+
+	for _, contract := range getContracts(desc) {
+		space, name, err := splitContract(contract, desc.GetNamespace())
+		if err != nil {
+			continue
+		}
+
+		if space == desc.GetNamespace() && name == desc.GetName() {
+			continue
+		}
+
+		addToIndex(nc.data, space, name, desc)
+	}
+
+	for _, decorated := range desc.GetMetadata()[DecoratesMetadata] {
+		space, name, err := splitContract(decorated, desc.GetNamespace())
+		if err != nil {
+			continue
+		}
 
-	internal, found := nc.data[space]
+		addToIndex(nc.decorators, space, name, desc)
+	}
+}
+
+// addToIndex adds the descriptor to the index under the namespace and name
+func addToIndex(index map[string]map[string][]Descriptor, space, name string, desc Descriptor) {
+	internal, found := index[space]
 	if !found {
 		internal = make(map[string][]Descriptor)
-		nc.data[space] = internal
+		index[space] = internal
 	}
 
 	ar, found := internal[name]
@@ -84,9 +152,32 @@ func (nc *nameCache) clone() *nameCache {
 	cloneAll := make([]Descriptor, len(nc.all))
 	copy(cloneAll, nc.all)
 
+	cloneInactive := make([]Descriptor, len(nc.inactive))
+	copy(cloneInactive, nc.inactive)
+
+	retVal := cloneIndex(nc.data)
+
+	cloneTypes := make(map[reflect.Type][]Descriptor)
+	for ty, descArray := range nc.types {
+		cloneDescs := make([]Descriptor, len(descArray))
+		copy(cloneDescs, descArray)
+
+		cloneTypes[ty] = cloneDescs
+	}
+
+	return &nameCache{
+		all:        cloneAll,
+		inactive:   cloneInactive,
+		data:       retVal,
+		types:      cloneTypes,
+		decorators: cloneIndex(nc.decorators),
+	}
+}
+
+func cloneIndex(index map[string]map[string][]Descriptor) map[string]map[string][]Descriptor {
 	retVal := make(map[string]map[string][]Descriptor)
 
-	for space, internal := range nc.data {
+	for space, internal := range index {
 		cp := make(map[string][]Descriptor)
 
 		for name, descArray := range internal {
@@ -99,10 +190,7 @@ func (nc *nameCache) clone() *nameCache {
 		retVal[space] = cp
 	}
 
-	return &nameCache{
-		all:  cloneAll,
-		data: retVal,
-	}
+	return retVal
 }
 
 func (nc *nameCache) limitedLookup(filter Filter) []Descriptor {
@@ -120,7 +208,13 @@ func (nc *nameCache) internalLookup(filter Filter, runFilter bool) []Descriptor
 
 	candidates := nc.all
 
-	if space != "" && name != "" {
+	tf, isTypeFilter := filter.(typeFilter)
+	df, isDecoratorFilter := filter.(*decoratorFilter)
+	if isTypeFilter {
+		candidates = nc.lookupType(tf.getType())
+	} else if isDecoratorFilter {
+		candidates = nc.lookupDecorators(df.decorated)
+	} else if space != "" && name != "" {
 		internal, found := nc.data[space]
 		if found {
 			dArray, found := internal[name]
@@ -148,15 +242,99 @@ func (nc *nameCache) internalLookup(filter Filter, runFilter bool) []Descriptor
 	return retVal
 }
 
+// lookupType returns all descriptors whose implementation type is
+// the given type or implements the given interface type
+func (nc *nameCache) lookupType(ty reflect.Type) []Descriptor {
+	if ty == nil {
+		return []Descriptor{}
+	}
+
+	if ty.Kind() != reflect.Interface {
+		return nc.types[ty]
+	}
+
+	nc.interfaceLock.Lock()
+	defer nc.interfaceLock.Unlock()
+
+	retVal, found := nc.interfaces[ty]
+	if found {
+		return retVal
+	}
+
+	retVal = make([]Descriptor, 0)
+	for implType, descArray := range nc.types {
+		if implType.Implements(ty) {
+			retVal = append(retVal, descArray...)
+		}
+	}
+
+	if nc.interfaces == nil {
+		nc.interfaces = make(map[reflect.Type][]Descriptor)
+	}
+	nc.interfaces[ty] = retVal
+
+	return retVal
+}
+
+// lookupDecorators returns the descriptors that decorate the service under
+// its own namespace and name or under any of its contracts
+func (nc *nameCache) lookupDecorators(decorated Descriptor) []Descriptor {
+	retVal := make([]Descriptor, 0)
+	found := make(map[Descriptor]bool)
+
+	add := func(space, name string) {
+		for _, desc := range nc.decorators[space][name] {
+			if !found[desc] {
+				found[desc] = true
+				retVal = append(retVal, desc)
+			}
+		}
+	}
+
+	add(decorated.GetNamespace(), decorated.GetName())
+
+	for _, contract := range getContracts(decorated) {
+		space, name, err := splitContract(contract, decorated.GetNamespace())
+		if err != nil {
+			continue
+		}
+
+		add(space, name)
+	}
+
+	return retVal
+}
+
 func checkFilter(filter Filter, desc Descriptor) bool {
 	filterNamespace := filter.GetNamespace()
 	filterName := filter.GetName()
 
 	if filterNamespace != "" && filterName != "" {
-		if desc.GetNamespace() != filterNamespace || desc.GetName() != filterName {
+		if !hasName(desc, filterNamespace, filterName) {
 			return false
 		}
 	}
 
 	return filter.Filter(desc)
 }
+
+// hasName returns true if the descriptor has the given namespace and name
+// or if it is advertised under that namespace and name as a contract
+func hasName(desc Descriptor, namespace, name string) bool {
+	if desc.GetNamespace() == namespace && desc.GetName() == name {
+		return true
+	}
+
+	for _, contract := range getContracts(desc) {
+		space, contractName, err := splitContract(contract, desc.GetNamespace())
+		if err != nil {
+			continue
+		}
+
+		if space == namespace && contractName == name {
+			return true
+		}
+	}
+
+	return false
+}
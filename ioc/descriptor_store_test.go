@@ -42,6 +42,7 @@ package ioc
 
 import (
 	"github.com/stretchr/testify/assert"
+	"reflect"
 	"testing"
 )
 
@@ -252,3 +253,103 @@ func (pf *panicyFilter) GetName() string {
 func (pf *panicyFilter) Filter(Descriptor) bool {
 	panic("should not be called")
 }
+
+func TestLookupByContract(t *testing.T) {
+	cache := newNameCache()
+
+	wd := NewConstantDescriptor(DSK(FOO), 0)
+	wd.(WriteableContractDescriptor).SetContracts([]string{BAR, NS1 + "#" + BAZ})
+
+	d1, err := NewDescriptor(wd, 0, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	d2 := createDescriptor(DefaultNamespace, BAR, 0, 1)
+
+	cache.add(d1)
+	cache.add(d2)
+
+	assert.Equal(t, 2, len(cache.getAll()))
+
+	rv := cache.lookup(NewSingleFilter(DefaultNamespace, FOO))
+	assert.Equal(t, []Descriptor{d1}, rv)
+
+	rv = cache.lookup(NewSingleFilter(DefaultNamespace, BAR))
+	assert.Equal(t, []Descriptor{d1, d2}, rv)
+
+	rv = cache.lookup(NewServiceKeyFilter(DSK(BAR)))
+	assert.Equal(t, []Descriptor{d1, d2}, rv)
+
+	rv = cache.lookup(NewSingleFilter(NS1, BAZ))
+	assert.Equal(t, []Descriptor{d1}, rv)
+
+	rv = cache.lookup(NewSingleFilter(DefaultNamespace, BAZ))
+	assert.Equal(t, 0, len(rv))
+
+	assert.True(t, checkFilter(NewSingleFilter(NS1, BAZ), d1))
+	assert.False(t, checkFilter(NewSingleFilter(NS1, BAZ), d2))
+
+	clone := cache.clone()
+	rv = clone.lookup(NewSingleFilter(NS1, BAZ))
+	assert.Equal(t, []Descriptor{d1}, rv)
+}
+
+type storeIface interface {
+	storeMethod()
+}
+
+type storeImpl struct{}
+
+func (si *storeImpl) storeMethod() {}
+
+func TestLookupByType(t *testing.T) {
+	cache := newNameCache()
+
+	d1 := createDescriptor(NS1, FOO, 0, 0)
+
+	d2, err := NewDescriptor(NewConstantDescriptor(DSK(BAR), &storeImpl{}), 1, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	d3, err := NewDescriptor(NewConstantDescriptor(DSK(BAZ, "red"), &storeImpl{}), 2, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	cache.add(d1)
+	cache.add(d2)
+	cache.add(d3)
+
+	implType := reflect.TypeOf(&storeImpl{})
+	ifaceType := reflect.TypeOf((*storeIface)(nil)).Elem()
+
+	rv := cache.lookup(NewTypeFilter(implType))
+	assert.Equal(t, []Descriptor{d2, d3}, rv)
+
+	rv = cache.lookup(NewTypeFilter(ifaceType))
+	assert.Equal(t, []Descriptor{d2, d3}, rv)
+
+	rv = cache.lookup(NewTypeFilter(ifaceType, "red"))
+	assert.Equal(t, []Descriptor{d3}, rv)
+
+	rv = cache.lookup(NewTypeFilter(reflect.TypeOf(0)))
+	assert.Equal(t, []Descriptor{d1}, rv)
+
+	rv = cache.lookup(NewTypeFilter(reflect.TypeOf("")))
+	assert.Equal(t, 0, len(rv))
+
+	rv = cache.clone().lookup(NewTypeFilter(ifaceType))
+	assert.Equal(t, []Descriptor{d2, d3}, rv)
+
+	// Interface lookups are memoized until another descriptor is added
+	d4, err := NewDescriptor(NewConstantDescriptor(DSK(FOO), &storeImpl{}), 3, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	cache.add(d4)
+
+	rv = cache.lookup(NewTypeFilter(ifaceType))
+	assert.Equal(t, []Descriptor{d2, d3, d4}, rv)
+}
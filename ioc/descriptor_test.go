@@ -158,3 +158,92 @@ func testCreator(locator ServiceLocator, key Descriptor) (interface{}, error) {
 func testDestroyer(locator ServiceLocator, key Descriptor, killMe interface{}) error {
 	return nil
 }
+
+func TestDescriptorContracts(t *testing.T) {
+	wd := createBenchmarkWriteableDescriptor(t)
+
+	err := wd.(WriteableContractDescriptor).SetContracts([]string{"Reader", NS2 + "#Writer", "Reader", bar})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, 4, len(wd.(WriteableContractDescriptor).GetContracts()), "writeable descriptor keeps contracts as given")
+
+	desc, err := NewDescriptor(wd, 100, 1)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	contracts := desc.(ContractDescriptor).GetContracts()
+	if !assert.Equal(t, 2, len(contracts), "duplicates and own name should be removed") {
+		return
+	}
+	assert.Equal(t, foo+"#Reader", contracts[0])
+	assert.Equal(t, NS2+"#Writer", contracts[1])
+
+	err = wd.(WriteableContractDescriptor).SetContracts([]string{"bad name"})
+	assert.NotNil(t, err)
+
+	err = wd.(WriteableContractDescriptor).SetContracts([]string{"bad namespace#Reader"})
+	assert.NotNil(t, err)
+}
+
+// minimalDescriptor implements only Descriptor, as a descriptor written
+// outside of this package might
+type minimalDescriptor struct {
+	name string
+}
+
+func (md *minimalDescriptor) GetCreateFunction() func(ServiceLocator, Descriptor) (interface{}, error) {
+	return func(ServiceLocator, Descriptor) (interface{}, error) {
+		return md.name, nil
+	}
+}
+
+func (md *minimalDescriptor) GetDestroyFunction() func(ServiceLocator, Descriptor, interface{}) error {
+	return nil
+}
+
+func (md *minimalDescriptor) GetNamespace() string             { return DefaultNamespace }
+func (md *minimalDescriptor) GetName() string                  { return md.name }
+func (md *minimalDescriptor) GetFullName() string              { return DefaultNamespace + "#" + md.name }
+func (md *minimalDescriptor) GetScope() string                 { return Singleton }
+func (md *minimalDescriptor) GetQualifiers() []string          { return []string{} }
+func (md *minimalDescriptor) GetVisibility() int               { return NormalVisibility }
+func (md *minimalDescriptor) GetMetadata() map[string][]string { return map[string][]string{} }
+func (md *minimalDescriptor) GetRank() int32                   { return 0 }
+func (md *minimalDescriptor) SetRank(int32) int32              { return 0 }
+func (md *minimalDescriptor) GetServiceID() int64              { return -1 }
+func (md *minimalDescriptor) GetLocatorID() int64              { return -1 }
+
+func TestMinimalDescriptorImplementation(t *testing.T) {
+	locator, err := NewServiceLocator("MinimalDescriptorLocator", FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	dcs, err := getDCS(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	config, err := dcs.CreateDynamicConfiguration()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = config.Bind(&minimalDescriptor{name: "Minimal"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	if !assert.Nil(t, config.Commit()) {
+		return
+	}
+
+	raw, err := locator.GetDService("Minimal")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "Minimal", raw)
+	}
+}
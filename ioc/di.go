@@ -41,8 +41,11 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
+	"github.com/pkg/errors"
 	"reflect"
+	"time"
 )
 
 type diData struct {
@@ -63,6 +66,292 @@ func newCreatorFunc(ty reflect.Type, parent *serviceLocatorData) func(ServiceLoc
 	return retVal
 }
 
+type constructorData struct {
+	fn         reflect.Value
+	parameters []*parseData
+}
+
+// newConstructorFunc returns a creation function that will call the given constructor
+// function with its parameters resolved from the locator along with the type of the
+// service the constructor returns and the injection points of its parameters
+func newConstructorFunc(constructor interface{}, parameters []string) (func(ServiceLocator, Descriptor) (interface{}, error),
+	reflect.Type, []*injectionPoint, error) {
+	fn := reflect.ValueOf(constructor)
+	if fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, nil, nil, fmt.Errorf("BindConstructor must be passed a function")
+	}
+
+	fnType := fn.Type()
+	if fnType.IsVariadic() {
+		return nil, nil, nil, fmt.Errorf("BindConstructor may not be passed a variadic function")
+	}
+
+	errorType := reflect.TypeOf((*error)(nil)).Elem()
+	numOut := fnType.NumOut()
+	if numOut < 1 || numOut > 2 || (numOut == 2 && fnType.Out(1) != errorType) {
+		return nil, nil, nil, fmt.Errorf("BindConstructor function must return the service or the service and an error")
+	}
+
+	if len(parameters) != 0 && len(parameters) != fnType.NumIn() {
+		return nil, nil, nil, fmt.Errorf("BindConstructor was given %d parameters for a function taking %d parameters",
+			len(parameters), fnType.NumIn())
+	}
+
+	cd := &constructorData{
+		fn:         fn,
+		parameters: make([]*parseData, fnType.NumIn()),
+	}
+
+	points := make([]*injectionPoint, fnType.NumIn())
+
+	for lcv := 0; lcv < fnType.NumIn(); lcv++ {
+		parameter := ""
+		if len(parameters) > 0 {
+			parameter = parameters[lcv]
+		}
+
+		pd, err := parseInjectString(parameter)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		paramType := fnType.In(lcv)
+		if pd.all {
+			_, err = collectionElementType(paramType, pd)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+		}
+
+		if pd.proxy && paramType.Kind() != reflect.Interface {
+			return nil, nil, nil, fmt.Errorf("parameter %d of the constructor must be an interface to be proxied", lcv)
+		}
+
+		if isTopic(paramType) || isTypedTopic(paramType) {
+			_, err = newTopicValue(nil, paramType, pd)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("parameter %d of the constructor: %v", lcv, err)
+			}
+		}
+
+		if pd.byType && (isProvider(paramType) || isTypedProvider(paramType)) {
+			return nil, nil, nil, fmt.Errorf("parameter %d of the constructor is a Provider and so must name the service", lcv)
+		}
+
+		cd.parameters[lcv] = pd
+		points[lcv] = &injectionPoint{
+			name:   fmt.Sprintf("parameter %d", lcv),
+			typ:    paramType,
+			parsed: pd,
+		}
+	}
+
+	retVal := func(rawLocator ServiceLocator, desc Descriptor) (interface{}, error) {
+		locator, ok := rawLocator.(*serviceLocatorData)
+		if !ok {
+			return nil, fmt.Errorf("unknown service locator type")
+		}
+
+		return cd.create(locator, desc)
+	}
+
+	return retVal, fnType.Out(0), points, nil
+}
+
+func (cd *constructorData) create(locator *serviceLocatorData, desc Descriptor) (interface{}, error) {
+	fnType := cd.fn.Type()
+
+	args := make([]reflect.Value, fnType.NumIn())
+	depErrors := NewMultiError()
+
+	for index, pd := range cd.parameters {
+		paramType := fnType.In(index)
+
+		arg, err := locator.resolveDependency(desc, paramType, pd)
+		if err != nil {
+			depErrors.AddError(err)
+			continue
+		}
+
+		args[index] = arg
+	}
+
+	if depErrors.HasError() {
+		depErrors.AddError(fmt.Errorf("an error occurred while getting the constructor parameters of %v", desc))
+
+		locator.runErrorHandlers(ServiceCreationFailure, desc, fnType, nil, depErrors)
+
+		return nil, &hasRunHandlers{
+			hasRunHandlers:  true,
+			underlyingError: depErrors,
+		}
+	}
+
+	results := cd.fn.Call(args)
+	if len(results) == 2 && !results[1].IsNil() {
+		return nil, results[1].Interface().(error)
+	}
+
+	iFace := results[0].Interface()
+
+	err := initializeService(locator, desc, fnType, iFace)
+	if err != nil {
+		return nil, err
+	}
+
+	return iFace, nil
+}
+
+// resolveDependency returns the value for a constructor parameter, or a field set by
+// a generated creator, of the given type and with the given parsed inject string
+func (locator *serviceLocatorData) resolveDependency(desc Descriptor, paramType reflect.Type,
+	pd *parseData) (reflect.Value, error) {
+	if isTopic(paramType) || isTypedTopic(paramType) {
+		return newTopicValue(locator, paramType, pd)
+	}
+
+	if pd.all {
+		collection, err := locator.getCollectionFor(paramType, pd, desc)
+		if err != nil {
+			return reflect.Value{}, err
+		}
+
+		return *collection, nil
+	}
+
+	proxy, err := locator.getProxyFor(paramType, pd, desc)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if proxy != nil {
+		return *proxy, nil
+	}
+
+	if isTypedProvider(paramType) {
+		return newTypedProviderValue(paramType, newProvider(locator, pd.serviceKey, desc)), nil
+	}
+
+	var dependency interface{}
+	if pd.byType {
+		dependency, err = locator.getServiceByTypeFor(paramType, pd.qualifiers, desc)
+	} else if isProvider(paramType) {
+		dependency = newProvider(locator, pd.serviceKey, desc)
+	} else {
+		dependency, err = locator.getServiceFor(pd.serviceKey, desc)
+	}
+
+	if err != nil {
+		if pd.isOptional && IsServiceNotFound(err) {
+			return reflect.Zero(paramType), nil
+		}
+
+		return reflect.Value{}, err
+	}
+
+	if dependency == nil {
+		return reflect.Zero(paramType), nil
+	}
+
+	retVal := reflect.ValueOf(dependency)
+	if !retVal.Type().AssignableTo(paramType) {
+		return reflect.Value{}, fmt.Errorf("service of type %v can not be injected into type %v",
+			retVal.Type(), paramType)
+	}
+
+	return retVal, nil
+}
+
+// initializeService calls DargoInitialize on the service if it implements
+// DargoInitializer, running the error handlers if it fails
+func initializeService(locator *serviceLocatorData, desc Descriptor, ty reflect.Type, iFace interface{}) error {
+	initializer, ok := iFace.(DargoInitializer)
+	if !ok {
+		return nil
+	}
+
+	errRet := &errorReturn{}
+	safeDargoInitialize(initializer, desc, errRet)
+	err := errRet.err
+
+	if err == nil {
+		return nil
+	}
+
+	_, isMulti := err.(MultiError)
+	if !isMulti {
+		err = NewMultiError(err)
+	}
+
+	locator.runErrorHandlers(ServiceCreationFailure, desc, ty, nil, err)
+
+	return &hasRunHandlers{
+		hasRunHandlers:  true,
+		underlyingError: err.(MultiError),
+	}
+}
+
+// destroyService calls the destroy function of the descriptor or, if the
+// descriptor has none, DargoDestroy on the service if it implements
+// DargoDestroyer.  The destroyer is not called if the context is already
+// done, and is abandoned if it does not finish within the budget of the
+// context.  Any failure is given to the error handlers and returned
+func destroyService(ctx context.Context, rawLocator ServiceLocator, desc Descriptor, value interface{}) error {
+	if desc == nil {
+		return nil
+	}
+
+	locator, ok := rawLocator.(*serviceLocatorData)
+	if !ok {
+		return destroyInstance(ctx, rawLocator, desc, value)
+	}
+
+	start := locator.fireLifecycleEvent(InstancePreDestroy, desc, value, nil, time.Time{})
+
+	err := destroyInstance(ctx, locator, desc, locator.unwrap(value))
+
+	locator.fireLifecycleEvent(InstancePostDestroy, desc, value, err, start)
+
+	return err
+}
+
+// destroyInstance calls the destroy function or DargoDestroy method of the
+// service itself, rather than of its decorators or interception proxy
+func destroyInstance(ctx context.Context, rawLocator ServiceLocator, desc Descriptor, value interface{}) error {
+	df := desc.GetDestroyFunction()
+	dargoDestroyer, isDargoDestroyer := value.(DargoDestroyer)
+	if df == nil && !isDargoDestroyer {
+		return nil
+	}
+
+	err := ctx.Err()
+	if err != nil {
+		err = errors.Wrapf(err, "service %v was not destroyed", desc)
+	} else {
+		err = callDestroyer(ctx, desc, func() error {
+			errRet := &errorReturn{}
+
+			if df != nil {
+				safeDestroyFunction(df, rawLocator, desc, value, errRet)
+			} else {
+				safeDargoDestroy(dargoDestroyer, desc, errRet)
+			}
+
+			return errRet.err
+		})
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	locator, ok := rawLocator.(*serviceLocatorData)
+	if ok {
+		locator.runErrorHandlers(ServiceDestructionFailure, desc, reflect.TypeOf(value), nil, err)
+	}
+
+	return err
+}
+
 type indexAndValueOfDependency struct {
 	index int
 	value *reflect.Value
@@ -110,6 +399,27 @@ func safeDargoInitialize(dargoI DargoInitializer, desc Descriptor, ret *errorRet
 	ret.err = dargoI.DargoInitialize(desc)
 }
 
+func safeDargoDestroy(dargoD DargoDestroyer, desc Descriptor, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	ret.err = dargoD.DargoDestroy(desc)
+}
+
+func safeDestroyFunction(df func(ServiceLocator, Descriptor, interface{}) error,
+	l ServiceLocator, desc Descriptor, value interface{}, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	ret.err = df(l, desc, value)
+}
+
 type hasRunErrorHandlersError interface {
 	error
 	GetHasRunErrorHandlers() bool
@@ -498,3 +498,314 @@ type ServiceWithOptionalAndRequiredInjections struct {
 	SSb *SimpleService `inject:"SimpleService@B,optional"`
 	SSc *SimpleService `inject:"SimpleService@C"`
 }
+
+const (
+	ConstructorLocator1 = "ConstructorLocator1"
+	ConstructorLocator2 = "ConstructorLocator2"
+	ConstructorLocator3 = "ConstructorLocator3"
+)
+
+type constructedRepo struct {
+	db     *BSimpleService
+	colors Provider
+	color  ColorService
+	label  string
+}
+
+func newConstructedRepo(db *BSimpleService, colors Provider, color ColorService, label string) (*constructedRepo, error) {
+	return &constructedRepo{
+		db:     db,
+		colors: colors,
+		color:  color,
+		label:  label,
+	}, nil
+}
+
+type byTypeConstructed struct {
+	b       *BSimpleService
+	locator ServiceLocator
+	c       *CSimpleService
+}
+
+type initializedRepo struct {
+	initialized bool
+}
+
+func (ir *initializedRepo) DargoInitialize(Descriptor) error {
+	ir.initialized = true
+	return nil
+}
+
+func TestBindConstructor(t *testing.T) {
+	locator, err := CreateAndBind(ConstructorLocator1, func(binder Binder) error {
+		binder.Bind(BServiceName, &BSimpleService{})
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BRed)
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BBlue)
+		binder.BindConstant("Label", "the label")
+		binder.BindConstructor("Repo", newConstructedRepo, "", ColorServiceName, ColorServiceName+"@"+BBlue, "Label")
+		binder.BindConstructor("Initialized", func() *initializedRepo {
+			return &initializedRepo{}
+		})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	repo, err := GetD[*constructedRepo](locator, "Repo")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.NotNil(t, repo.db)
+	assert.Equal(t, BBlue, repo.color.GetColor())
+	assert.Equal(t, "the label", repo.label)
+
+	all, err := repo.colors.GetAll()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, len(all))
+
+	byType, err := GetByType[*constructedRepo](locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, repo == byType, "constructed service should be found by type")
+
+	initialized, err := GetD[*initializedRepo](locator, "Initialized")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, initialized.initialized, "DargoInitialize should be called")
+}
+
+func TestBindConstructorByType(t *testing.T) {
+	locator, err := CreateAndBind(ConstructorLocator2, func(binder Binder) error {
+		binder.Bind(BServiceName, &BSimpleService{})
+		binder.BindConstructor("ByType", func(b *BSimpleService, l ServiceLocator, c *CSimpleService) *byTypeConstructed {
+			return &byTypeConstructed{
+				b:       b,
+				locator: l,
+				c:       c,
+			}
+		}, "", "", ",optional")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	bt, err := GetD[*byTypeConstructed](locator, "ByType")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.NotNil(t, bt.b)
+	assert.NotNil(t, bt.locator)
+	assert.Nil(t, bt.c)
+}
+
+func TestBindConstructorErrors(t *testing.T) {
+	lastErrorInformation = make([]ErrorInformation, 0)
+
+	locator, err := CreateAndBind(ConstructorLocator3, func(binder Binder) error {
+		binder.Bind(ErrorServiceName, errorServiceData{}).InNamespace(UserServicesNamespace)
+		binder.BindConstructor("Failing", func() (*BSimpleService, error) {
+			return nil, fmt.Errorf(ExpectedPanicMessage)
+		})
+		binder.BindConstructor("Unresolved", func(c *CSimpleService) *BSimpleService {
+			return &BSimpleService{}
+		})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Failing")
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, strings.Contains(err.Error(), ExpectedPanicMessage))
+
+	_, err = locator.GetDService("Unresolved")
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, IsServiceNotFound(err))
+
+	if !assert.Equal(t, 2, len(lastErrorInformation)) {
+		return
+	}
+	assert.Equal(t, ServiceCreationFailure, lastErrorInformation[0].GetType())
+	assert.Equal(t, "Failing", lastErrorInformation[0].GetDescriptor().GetName())
+	assert.Equal(t, ServiceCreationFailure, lastErrorInformation[1].GetType())
+	assert.Equal(t, "Unresolved", lastErrorInformation[1].GetDescriptor().GetName())
+
+	assert.Panics(t, func() {
+		BindIntoLocator(locator, func(binder Binder) error {
+			binder.BindConstructor("NotAFunction", &BSimpleService{})
+			return nil
+		})
+	})
+
+	assert.Panics(t, func() {
+		BindIntoLocator(locator, func(binder Binder) error {
+			binder.BindConstructor("WrongReturn", func() (*BSimpleService, string) { return nil, "" })
+			return nil
+		})
+	})
+
+	assert.Panics(t, func() {
+		BindIntoLocator(locator, func(binder Binder) error {
+			binder.BindConstructor("WrongCount", func(*BSimpleService) *BSimpleService { return nil }, "", "")
+			return nil
+		})
+	})
+}
+
+const (
+	DestroyerLocator1 = "DestroyerLocator1"
+	DestroyerLocator2 = "DestroyerLocator2"
+)
+
+type destroyableService struct {
+	destroyed bool
+	failWith  string
+	panicWith string
+}
+
+func (ds *destroyableService) DargoDestroy(Descriptor) error {
+	ds.destroyed = true
+
+	if ds.panicWith != "" {
+		panic(ds.panicWith)
+	}
+
+	if ds.failWith != "" {
+		return fmt.Errorf(ds.failWith)
+	}
+
+	return nil
+}
+
+func TestDargoDestroyer(t *testing.T) {
+	explicitCalled := false
+
+	locator, err := CreateAndBind(DestroyerLocator1, func(binder Binder) error {
+		binder.Bind("Destroyable", &destroyableService{})
+		binder.Bind("Immediate", &destroyableService{}).InScope(ImmediateScope)
+		binder.Bind("Explicit", &destroyableService{}).AndDestroyWith(
+			func(ServiceLocator, Descriptor, interface{}) error {
+				explicitCalled = true
+				return nil
+			})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableImmediateScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	destroyable, err := GetD[*destroyableService](locator, "Destroyable")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	immediate, err := GetD[*destroyableService](locator, "Immediate")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	explicit, err := GetD[*destroyableService](locator, "Explicit")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	immediateScope, err := Get[ContextualScope](locator, CSK(ImmediateScope))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	immediateDesc, err := locator.GetBestDescriptor(NewSingleFilter(DefaultNamespace, "Immediate"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = immediateScope.DestroyOne(locator, immediateDesc)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.True(t, immediate.destroyed, "immediate service should have DargoDestroy called")
+
+	locator.Shutdown()
+
+	assert.True(t, destroyable.destroyed, "singleton should have DargoDestroy called")
+	assert.True(t, explicitCalled, "explicit destroy function should be called")
+	assert.False(t, explicit.destroyed, "DargoDestroy should not be called when there is a destroy function")
+}
+
+func TestDargoDestroyerFailures(t *testing.T) {
+	lastErrorInformation = make([]ErrorInformation, 0)
+
+	locator, err := CreateAndBind(DestroyerLocator2, func(binder Binder) error {
+		binder.Bind(ErrorServiceName, errorServiceData{}).InNamespace(UserServicesNamespace)
+		binder.BindConstant("Failing", &destroyableService{failWith: ExpectedPanicMessage})
+		binder.BindConstant("Panicking", &destroyableService{panicWith: ExpectedPanicMessage})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := GetD[*destroyableService](locator, "Failing")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	desc, err := locator.GetBestDescriptor(NewSingleFilter(DefaultNamespace, "Failing"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	singletonScope := locator.(*serviceLocatorData).singletonContext
+
+	err = singletonScope.DestroyOne(locator, desc)
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, raw.destroyed)
+	assert.True(t, strings.Contains(err.Error(), ExpectedPanicMessage))
+
+	if !assert.Equal(t, 1, len(lastErrorInformation)) {
+		return
+	}
+	assert.Equal(t, ServiceDestructionFailure, lastErrorInformation[0].GetType())
+	assert.Equal(t, "Failing", lastErrorInformation[0].GetDescriptor().GetName())
+
+	_, err = locator.GetDService("Panicking")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	locator.Shutdown()
+
+	found := false
+	for _, ei := range lastErrorInformation {
+		if ei.GetType() == ServiceDestructionFailure && ei.GetDescriptor().GetName() == "Panicking" {
+			found = true
+			assert.True(t, strings.Contains(ei.GetAssociatedError().Error(), ExpectedPanicMessage))
+		}
+	}
+	assert.True(t, found, "panic in DargoDestroy should be given to the ErrorService")
+}
@@ -89,6 +89,7 @@ type dynamicConfigModificationData struct {
 	originalGeneration uint64
 	binds              []Descriptor
 	removeFilters      []Filter
+	profiles           profileSet
 }
 
 func newModifier(parent *serviceLocatorData) DynamicConfiguration {
@@ -153,7 +154,7 @@ func (mod *dynamicConfigModificationData) Commit() error {
 		return err
 	}
 
-	handlersAlreadyRun, err := mod.parent.update(mod.binds, mod.removeFilters, mod.originalGeneration)
+	handlersAlreadyRun, err := mod.parent.update(mod.binds, mod.removeFilters, mod.profiles, mod.originalGeneration)
 	mod.state = 1
 	if err != nil {
 		_, ok := err.(MultiError)
@@ -178,5 +179,7 @@ func (mod *dynamicConfigModificationData) Commit() error {
 		}
 	}
 
+	mod.parent.reevaluateChildren()
+
 	return nil
 }
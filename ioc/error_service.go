@@ -53,6 +53,10 @@ type ErrorInformation interface {
 	// DYNAMIC_CONFIGURATION_FAILURE
 	// SERVICE_CREATION_FAILURE
 	// LOOKUP_VALIDATION_FAILURE
+	// SERVICE_DESTRUCTION_FAILURE
+	// SUBSCRIBER_FAILURE
+	// LISTENER_FAILURE
+	// CONDITION_FAILURE
 	GetType() string
 	// GetDescriptor returns the Descriptor associated with the failure
 	GetDescriptor() Descriptor
@@ -129,3 +133,17 @@ func (eid *errorInformationData) String() string {
 
 	return fmt.Sprintf("ErrorInformation(%s,%v,%s)", eid.typ, eid.desc, errS)
 }
+
+// ReportFailure gives the failure to the ErrorService implementations of the locator.
+// It is for packages that extend dargo, such as ioc/config, which have failures of their
+// own to report.  The descriptor is that of the service that failed
+func ReportFailure(locator ServiceLocator, typ string, desc Descriptor, err error) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+
+	iLocator.runErrorHandlers(typ, desc, nil, nil, err)
+
+	return nil
+}
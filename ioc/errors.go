@@ -40,7 +40,10 @@
 
 package ioc
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+)
 
 // ServiceNotFoundInfo is implemented if an error indicates a service
 // was not found
@@ -51,6 +54,7 @@ type ServiceNotFoundInfo interface {
 
 type serviceNotFoundError struct {
 	key ServiceKey
+	typ reflect.Type
 }
 
 // NewServiceNotFoundError returns an error that also implements ServiceNotFoundInfo
@@ -64,10 +68,206 @@ func NewServiceNotFoundError(key ServiceKey) error {
 	}
 }
 
+// NewServiceTypeNotFoundError returns an error that also implements ServiceNotFoundInfo
+// for a service that was looked up by type.  The GetServiceKey method of the returned
+// error will return nil
+func NewServiceTypeNotFoundError(ty reflect.Type) error {
+	if ty == nil {
+		panic("can not have a service type not found without a type")
+	}
+
+	return &serviceNotFoundError{
+		typ: ty,
+	}
+}
+
 func (snfe *serviceNotFoundError) Error() string {
+	if snfe.key == nil {
+		return fmt.Sprintf("service of type %v was not found", snfe.typ)
+	}
+
 	return fmt.Sprintf("service was not found: %s", snfe.key)
 }
 
 func (snfe *serviceNotFoundError) GetServiceKey() ServiceKey {
 	return snfe.key
 }
+
+// GetServiceType returns the type of the service that was not found, or
+// nil if the service was looked up by key
+func (snfe *serviceNotFoundError) GetServiceType() reflect.Type {
+	return snfe.typ
+}
+
+// AmbiguousServiceInfo is implemented if an error indicates that a lookup
+// by type found more than one service with the same highest rank
+type AmbiguousServiceInfo interface {
+	// GetServiceType returns the type that was looked up
+	GetServiceType() reflect.Type
+
+	// GetCandidates returns the descriptors of the services that matched
+	// the type with the same highest rank
+	GetCandidates() []Descriptor
+}
+
+type ambiguousServiceError struct {
+	typ        reflect.Type
+	candidates []Descriptor
+}
+
+// NewAmbiguousServiceError returns an error that also implements AmbiguousServiceInfo
+func NewAmbiguousServiceError(ty reflect.Type, candidates []Descriptor) error {
+	cpy := make([]Descriptor, len(candidates))
+	copy(cpy, candidates)
+
+	return &ambiguousServiceError{
+		typ:        ty,
+		candidates: cpy,
+	}
+}
+
+func (ase *ambiguousServiceError) Error() string {
+	names := ""
+	for index, candidate := range ase.candidates {
+		if index > 0 {
+			names = names + ", "
+		}
+
+		names = names + candidate.GetFullName()
+	}
+
+	return fmt.Sprintf("more than one service of type %v has the same rank, use a rank or a name to choose one of: %s",
+		ase.typ, names)
+}
+
+func (ase *ambiguousServiceError) GetServiceType() reflect.Type {
+	return ase.typ
+}
+
+func (ase *ambiguousServiceError) GetCandidates() []Descriptor {
+	retVal := make([]Descriptor, len(ase.candidates))
+	copy(retVal, ase.candidates)
+
+	return retVal
+}
+
+// VerificationInfo is implemented by the errors returned from Verify
+type VerificationInfo interface {
+	// GetVerificationType returns the kind of problem found, which is one of
+	// VerificationUnresolvedDependency, VerificationAmbiguousDependency,
+	// VerificationInvalidInjectionPoint, VerificationUnknownScope,
+	// VerificationScopeWidening or VerificationCycle, or a type defined by a Verifier
+	GetVerificationType() string
+
+	// GetDescriptor returns the descriptor of the service with the problem
+	GetDescriptor() Descriptor
+
+	// GetInjectionPoint returns the name of the field or constructor
+	// parameter with the problem, or the empty string if the problem is
+	// not with a single injection point
+	GetInjectionPoint() string
+
+	// GetRelated returns the other services involved in the problem, such as
+	// the services in a cycle or the narrower scoped service that was injected
+	GetRelated() []Descriptor
+}
+
+type verificationError struct {
+	typ            string
+	desc           Descriptor
+	injectionPoint string
+	message        string
+	related        []Descriptor
+}
+
+// NewVerificationError returns an error implementing VerificationInfo, for use
+// by implementations of Verifier
+func NewVerificationError(typ string, desc Descriptor, injectionPoint string, message string,
+	related ...Descriptor) error {
+	cpy := make([]Descriptor, len(related))
+	copy(cpy, related)
+
+	return &verificationError{
+		typ:            typ,
+		desc:           desc,
+		injectionPoint: injectionPoint,
+		message:        message,
+		related:        cpy,
+	}
+}
+
+func (ve *verificationError) Error() string {
+	if ve.injectionPoint == "" {
+		return fmt.Sprintf("%s: %s: %s", ve.typ, ve.desc.GetFullName(), ve.message)
+	}
+
+	return fmt.Sprintf("%s: %s (%s): %s", ve.typ, ve.desc.GetFullName(), ve.injectionPoint, ve.message)
+}
+
+func (ve *verificationError) GetVerificationType() string {
+	return ve.typ
+}
+
+func (ve *verificationError) GetDescriptor() Descriptor {
+	return ve.desc
+}
+
+func (ve *verificationError) GetInjectionPoint() string {
+	return ve.injectionPoint
+}
+
+func (ve *verificationError) GetRelated() []Descriptor {
+	retVal := make([]Descriptor, len(ve.related))
+	copy(retVal, ve.related)
+
+	return retVal
+}
+
+// ServiceTypeInfo is implemented if an error indicates a service was
+// found but it was not of the type expected by the caller
+type ServiceTypeInfo interface {
+	// GetServiceKey returns the key of the service that was looked up
+	GetServiceKey() ServiceKey
+
+	// GetExpectedType returns the type the service was expected to have
+	GetExpectedType() reflect.Type
+
+	// GetActualType returns the type of the service that was found
+	GetActualType() reflect.Type
+}
+
+type serviceTypeError struct {
+	key      ServiceKey
+	expected reflect.Type
+	actual   reflect.Type
+}
+
+// NewServiceTypeError returns an error that also implements ServiceTypeInfo
+func NewServiceTypeError(key ServiceKey, expected reflect.Type, actual interface{}) error {
+	if key == nil {
+		panic("can not have a service type error without a key")
+	}
+
+	return &serviceTypeError{
+		key:      key,
+		expected: expected,
+		actual:   reflect.TypeOf(actual),
+	}
+}
+
+func (ste *serviceTypeError) Error() string {
+	return fmt.Sprintf("service %s has type %v which is not the expected type %v",
+		ste.key, ste.actual, ste.expected)
+}
+
+func (ste *serviceTypeError) GetServiceKey() ServiceKey {
+	return ste.key
+}
+
+func (ste *serviceTypeError) GetExpectedType() reflect.Type {
+	return ste.expected
+}
+
+func (ste *serviceTypeError) GetActualType() reflect.Type {
+	return ste.actual
+}
@@ -40,7 +40,10 @@
 
 package ioc
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+)
 
 // Filter is used to filter descriptors for matching services
 type Filter interface {
@@ -116,11 +119,7 @@ func (filter *serviceKeyFilter) GetName() string {
 }
 
 func filterOne(key ServiceKey, desc Descriptor) bool {
-	if desc.GetNamespace() != key.GetNamespace() {
-		return false
-	}
-
-	if desc.GetName() != key.GetName() {
+	if !hasName(desc, key.GetNamespace(), key.GetName()) {
 		return false
 	}
 
@@ -153,6 +152,82 @@ func filterOne(key ServiceKey, desc Descriptor) bool {
 	return true
 }
 
+type typeFilter interface {
+	getType() reflect.Type
+}
+
+type typeFilterData struct {
+	ty         reflect.Type
+	qualifiers []string
+}
+
+// NewTypeFilter returns a filter for services whose implementation type
+// is the given type, or implements the given type if it is an interface.
+// The services must also have all of the given qualifiers
+func NewTypeFilter(ty reflect.Type, qualifiers ...string) Filter {
+	qCopy := make([]string, len(qualifiers))
+	copy(qCopy, qualifiers)
+
+	return &typeFilterData{
+		ty:         ty,
+		qualifiers: qCopy,
+	}
+}
+
+func (tfd *typeFilterData) Filter(desc Descriptor) bool {
+	if !isOfType(desc, tfd.ty) {
+		return false
+	}
+
+	descQualifiers := desc.GetQualifiers()
+	for _, qualifier := range tfd.qualifiers {
+		found := false
+		for _, dQualifier := range descQualifiers {
+			if qualifier == dQualifier {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (tfd *typeFilterData) GetNamespace() string {
+	return ""
+}
+
+func (tfd *typeFilterData) GetName() string {
+	return ""
+}
+
+func (tfd *typeFilterData) getType() reflect.Type {
+	return tfd.ty
+}
+
+func (tfd *typeFilterData) String() string {
+	return fmt.Sprintf("typeFilterData(%v,%v)", tfd.ty, tfd.qualifiers)
+}
+
+// isOfType returns true if the implementation type of the descriptor
+// is the given type or implements the given interface type
+func isOfType(desc Descriptor, ty reflect.Type) bool {
+	implType := getImplementationType(desc)
+	if implType == nil || ty == nil {
+		return false
+	}
+
+	if implType == ty {
+		return true
+	}
+
+	return ty.Kind() == reflect.Interface && implType.Implements(ty)
+}
+
 type allFilterData struct {
 }
 
@@ -0,0 +1,321 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// GeneratedCreator creates a service of type *T without reflection.  Creators
+// are written by the dargo-gen command, which generates one for each structure
+// of a package with inject tags
+type GeneratedCreator[T any] func(locator ServiceLocator, desc Descriptor) (*T, error)
+
+// generatedCreator is a registered creator and the fingerprint of the
+// structure it was generated from
+type generatedCreator struct {
+	creator     func(ServiceLocator, Descriptor) (interface{}, error)
+	fingerprint string
+}
+
+var (
+	generatedLock     sync.Mutex
+	generatedCreators = make(map[reflect.Type]*generatedCreator)
+)
+
+// RegisterGeneratedCreator makes Binder.Bind use the creator for services of type
+// T, rather than creating them with reflection.  The fingerprint is that of the
+// fields of T with inject tags when the creator was generated, as returned by
+// InjectFingerprint.  If the fields of T no longer have that fingerprint the creator
+// is out of date and Binder.Bind creates the services with reflection.  The creator
+// is only used when no InjectionResolver other than the system one is bound into
+// the locator, since generated creators only inject fields with the inject tag.
+// The files written by dargo-gen call this from an init function
+func RegisterGeneratedCreator[T any](creator GeneratedCreator[T], fingerprint string) error {
+	if creator == nil {
+		return fmt.Errorf("the generated creator may not be nil")
+	}
+
+	ty := reflect.TypeOf((*T)(nil)).Elem()
+	if ty.Kind() != reflect.Struct {
+		return fmt.Errorf("generated creators may only be registered for structures, not %v", ty)
+	}
+
+	generatedLock.Lock()
+	defer generatedLock.Unlock()
+
+	generatedCreators[ty] = &generatedCreator{
+		creator:     creator.Creator(),
+		fingerprint: fingerprint,
+	}
+
+	return nil
+}
+
+// InjectFingerprint returns the fingerprint of the fields of a structure that have
+// inject tags, given as the name of each field followed by the value of its inject
+// tag, in the order the fields are declared.  The name of an embedded field is the
+// name of its type
+func InjectFingerprint(namesAndTags ...string) string {
+	hash := sha256.New()
+	for _, value := range namesAndTags {
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// structFingerprint returns the InjectFingerprint of the structure type
+func structFingerprint(ty reflect.Type) string {
+	namesAndTags := make([]string, 0)
+	for lcv := 0; lcv < ty.NumField(); lcv++ {
+		field := ty.Field(lcv)
+
+		injectString, hasTag := field.Tag.Lookup("inject")
+		if !hasTag {
+			continue
+		}
+
+		namesAndTags = append(namesAndTags, field.Name, injectString)
+	}
+
+	return InjectFingerprint(namesAndTags...)
+}
+
+// Creator returns the generated creator as a creation function that can be given
+// to Binder.BindWithCreator
+func (creator GeneratedCreator[T]) Creator() func(ServiceLocator, Descriptor) (interface{}, error) {
+	return func(locator ServiceLocator, desc Descriptor) (interface{}, error) {
+		service, err := creator(locator, desc)
+		if err != nil {
+			return nil, err
+		}
+
+		return service, nil
+	}
+}
+
+// getGeneratedCreator returns the creator registered for the structure type, or
+// nil if there is none or it was generated from different inject tags
+func getGeneratedCreator(ty reflect.Type) func(ServiceLocator, Descriptor) (interface{}, error) {
+	generatedLock.Lock()
+	generated, found := generatedCreators[ty]
+	generatedLock.Unlock()
+
+	if !found || generated.fingerprint != structFingerprint(ty) {
+		return nil
+	}
+
+	return generated.creator
+}
+
+// preferGenerated returns a creation function that uses the generated creator when
+// the locator only has the system injection resolver and reflection otherwise
+func preferGenerated(generated func(ServiceLocator, Descriptor) (interface{}, error),
+	reflective func(ServiceLocator, Descriptor) (interface{}, error)) func(ServiceLocator, Descriptor) (interface{}, error) {
+	return func(locator ServiceLocator, desc Descriptor) (interface{}, error) {
+		iLocator, ok := locator.(*serviceLocatorData)
+		if !ok || !iLocator.onlySystemInjection() {
+			return reflective(locator, desc)
+		}
+
+		return generated(locator, desc)
+	}
+}
+
+func (locator *serviceLocatorData) onlySystemInjection() bool {
+	for _, resolver := range locator.injectionResolvers {
+		if _, isSystem := resolver.(*systemInjectionResolver); !isSystem {
+			return false
+		}
+	}
+
+	return true
+}
+
+// InjectTag is the parsed value of an inject tag.  dargo-gen parses the inject tags
+// of a structure when it generates its creator and writes them into the creator, so
+// that they are not parsed again each time a service is created
+type InjectTag struct {
+	Namespace  string
+	Name       string
+	Qualifiers []string
+	Optional   bool
+	ByType     bool
+	All        bool
+	Strict     bool
+	MapKey     string
+	Proxy      bool
+}
+
+// ParseInjectTag parses the value of an inject tag in the same way as the
+// tags of structures created with reflection are parsed
+func ParseInjectTag(tag string) (InjectTag, error) {
+	pd, err := parseInjectString(tag)
+	if err != nil {
+		return InjectTag{}, err
+	}
+
+	retVal := InjectTag{
+		Qualifiers: pd.qualifiers,
+		Optional:   pd.isOptional,
+		ByType:     pd.byType,
+		All:        pd.all,
+		Strict:     pd.strict,
+		MapKey:     pd.mapKey,
+		Proxy:      pd.proxy,
+	}
+	if pd.serviceKey != nil {
+		retVal.Namespace = pd.serviceKey.GetNamespace()
+		retVal.Name = pd.serviceKey.GetName()
+	}
+
+	return retVal, nil
+}
+
+func (tag InjectTag) toParseData() (*parseData, error) {
+	qualifiers := tag.Qualifiers
+	if qualifiers == nil {
+		qualifiers = []string{}
+	}
+
+	retVal := &parseData{
+		isOptional: tag.Optional,
+		byType:     tag.ByType,
+		qualifiers: qualifiers,
+		all:        tag.All,
+		strict:     tag.Strict,
+		mapKey:     tag.MapKey,
+		proxy:      tag.Proxy,
+	}
+	if tag.ByType {
+		return retVal, nil
+	}
+
+	sk, err := NewServiceKey(tag.Namespace, tag.Name, qualifiers...)
+	if err != nil {
+		return nil, err
+	}
+	retVal.serviceKey = sk
+
+	return retVal, nil
+}
+
+// InjectInto sets the field to the value of the parsed inject tag for the service
+// being created, in the same way as a field with the tag would be injected.  Any
+// error is added to errs and the field is left alone.  It is called by generated
+// creators
+func InjectInto[T any](locator ServiceLocator, desc Descriptor, field *T, tag InjectTag, errs MultiError) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		errs.AddError(fmt.Errorf("unknown service locator type"))
+		return
+	}
+
+	pd, err := tag.toParseData()
+	if err != nil {
+		errs.AddError(err)
+		return
+	}
+
+	ty := reflect.TypeOf((*T)(nil)).Elem()
+	if pd.byType && (isProvider(ty) || isTypedProvider(ty)) {
+		errs.AddError(fmt.Errorf("a Provider must name the service to inject"))
+		return
+	}
+
+	value, err := iLocator.resolveDependency(desc, ty, pd)
+	if err != nil {
+		errs.AddError(err)
+		return
+	}
+
+	if !value.IsValid() {
+		return
+	}
+
+	raw := value.Interface()
+	if raw == nil {
+		return
+	}
+
+	typed, ok := raw.(T)
+	if !ok {
+		errs.AddError(fmt.Errorf("the value %v for field of type %v has type %T", raw, ty, raw))
+		return
+	}
+
+	*field = typed
+}
+
+// FinishGenerated completes the creation of a service by a generated creator.  If
+// errs has any errors the error services are told and an error is returned, otherwise
+// DargoInitialize is called if the service implements DargoInitializer
+func FinishGenerated[T any](locator ServiceLocator, desc Descriptor, service *T, errs MultiError) (*T, error) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("unknown service locator type")
+	}
+
+	ty := reflect.TypeOf(service).Elem()
+	if errs.HasError() {
+		errs.AddError(fmt.Errorf("an error occurred while getting the dependencies of %v", desc))
+
+		iLocator.runErrorHandlers(ServiceCreationFailure, desc, ty, nil, errs)
+
+		return nil, &hasRunHandlers{
+			hasRunHandlers:  true,
+			underlyingError: errs,
+		}
+	}
+
+	err := initializeService(iLocator, desc, ty, service)
+	if err != nil {
+		return nil, err
+	}
+
+	return service, nil
+}
@@ -0,0 +1,266 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	generatedLocator1 = "GeneratedLocator1"
+	generatedLocator2 = "GeneratedLocator2"
+	generatedLocator3 = "GeneratedLocator3"
+	generatedLocator4 = "GeneratedLocator4"
+	generatedLocator5 = "GeneratedLocator5"
+)
+
+type generatedDependency struct {
+}
+
+type generatedService struct {
+	Dependency  *generatedDependency `inject:"Dependency"`
+	Provider    Provider             `inject:"Dependency"`
+	Alternate   *generatedDependency `alternate:"Dependency"`
+	Optional    *generatedDependency `inject:"NoSuchDependency,optional"`
+	initialized bool
+}
+
+func (gs *generatedService) DargoInitialize(Descriptor) error {
+	gs.initialized = true
+	return nil
+}
+
+type failingGeneratedService struct {
+	Missing *generatedDependency `inject:"NoSuchDependency"`
+}
+
+type staleGeneratedService struct {
+	Dependency *generatedDependency `inject:"Dependency"`
+}
+
+var generatedCreations int32
+
+// createGeneratedService is what dargo-gen would generate for generatedService
+func createGeneratedService(locator ServiceLocator, desc Descriptor) (*generatedService, error) {
+	atomic.AddInt32(&generatedCreations, 1)
+
+	service := &generatedService{}
+	errs := NewMultiError()
+
+	InjectInto(locator, desc, &service.Dependency, InjectTag{Namespace: DefaultNamespace, Name: "Dependency"}, errs)
+	InjectInto(locator, desc, &service.Provider, InjectTag{Namespace: DefaultNamespace, Name: "Dependency"}, errs)
+	InjectInto(locator, desc, &service.Optional,
+		InjectTag{Namespace: DefaultNamespace, Name: "NoSuchDependency", Optional: true}, errs)
+
+	return FinishGenerated(locator, desc, service, errs)
+}
+
+func createFailingGeneratedService(locator ServiceLocator, desc Descriptor) (*failingGeneratedService, error) {
+	service := &failingGeneratedService{}
+	errs := NewMultiError()
+
+	InjectInto(locator, desc, &service.Missing, InjectTag{Namespace: DefaultNamespace, Name: "NoSuchDependency"}, errs)
+
+	return FinishGenerated(locator, desc, service, errs)
+}
+
+var staleCreations int32
+
+// createStaleGeneratedService was generated when the field had a different tag
+func createStaleGeneratedService(locator ServiceLocator, desc Descriptor) (*staleGeneratedService, error) {
+	atomic.AddInt32(&staleCreations, 1)
+
+	return FinishGenerated(locator, desc, &staleGeneratedService{}, NewMultiError())
+}
+
+func init() {
+	RegisterGeneratedCreator[generatedService](createGeneratedService, InjectFingerprint(
+		"Dependency", "Dependency", "Provider", "Dependency", "Optional", "NoSuchDependency,optional"))
+	RegisterGeneratedCreator[failingGeneratedService](createFailingGeneratedService, InjectFingerprint(
+		"Missing", "NoSuchDependency"))
+	RegisterGeneratedCreator[staleGeneratedService](createStaleGeneratedService, InjectFingerprint(
+		"Dependency", "OldDependency"))
+}
+
+func TestGeneratedCreatorIsPreferred(t *testing.T) {
+	locator, err := CreateAndBind(generatedLocator1, func(binder Binder) error {
+		binder.Bind("Generated", generatedService{})
+		binder.Bind("Dependency", generatedDependency{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	before := atomic.LoadInt32(&generatedCreations)
+
+	service, err := GetD[*generatedService](locator, "Generated")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, before+1, atomic.LoadInt32(&generatedCreations))
+	assert.NotNil(t, service.Dependency)
+	assert.Nil(t, service.Optional)
+	assert.True(t, service.initialized)
+
+	provided, err := service.Provider.Get()
+	if assert.Nil(t, err) {
+		assert.Equal(t, service.Dependency, provided)
+	}
+
+	assert.Nil(t, Verify(locator))
+}
+
+func TestGeneratedCreatorNotUsedWithResolvers(t *testing.T) {
+	locator, err := CreateAndBind(generatedLocator2, func(binder Binder) error {
+		binder.Bind(InjectionResolverName, AlternateInjectionResolver{}).InNamespace(UserServicesNamespace)
+		binder.Bind("Generated", generatedService{})
+		binder.Bind("Dependency", generatedDependency{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	before := atomic.LoadInt32(&generatedCreations)
+
+	service, err := GetD[*generatedService](locator, "Generated")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, before, atomic.LoadInt32(&generatedCreations), "reflection is used with user resolvers")
+	assert.NotNil(t, service.Alternate)
+	assert.Equal(t, service.Dependency, service.Alternate)
+}
+
+func TestGeneratedCreatorFailure(t *testing.T) {
+	errorService := &recordingErrorService{}
+
+	locator, err := CreateAndBind(generatedLocator3, func(binder Binder) error {
+		binder.BindConstant(ErrorServiceName, errorService).InNamespace(UserServicesNamespace)
+		binder.Bind("Failing", failingGeneratedService{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("Failing")
+	assert.NotNil(t, err)
+
+	failures := errorService.getErrors()
+	if assert.Equal(t, 1, len(failures)) {
+		assert.Equal(t, ServiceCreationFailure, failures[0].GetType())
+		assert.Equal(t, "Failing", failures[0].GetDescriptor().GetName())
+	}
+
+	assert.NotNil(t, RegisterGeneratedCreator[generatedService](nil, ""))
+	assert.NotNil(t, RegisterGeneratedCreator[int](func(ServiceLocator, Descriptor) (*int, error) {
+		return nil, nil
+	}, ""))
+}
+
+func TestGeneratedCreatorWithBindWithCreator(t *testing.T) {
+	locator, err := CreateAndBind(generatedLocator4, func(binder Binder) error {
+		binder.BindWithCreator("Generated", GeneratedCreator[generatedService](createGeneratedService).Creator())
+		binder.Bind("Dependency", generatedDependency{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	service, err := GetD[*generatedService](locator, "Generated")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.NotNil(t, service.Dependency)
+	assert.True(t, service.initialized)
+}
+
+func TestStaleGeneratedCreatorNotUsed(t *testing.T) {
+	locator, err := CreateAndBind(generatedLocator5, func(binder Binder) error {
+		binder.Bind("Stale", staleGeneratedService{})
+		binder.Bind("Dependency", generatedDependency{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	service, err := GetD[*staleGeneratedService](locator, "Stale")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&staleCreations), "reflection is used when the tags have changed")
+	assert.NotNil(t, service.Dependency)
+}
+
+func TestParseInjectTag(t *testing.T) {
+	tag, err := ParseInjectTag("ns#Name@Q1@Q2,optional")
+	if assert.Nil(t, err) {
+		assert.Equal(t, InjectTag{
+			Namespace:  "ns",
+			Name:       "Name",
+			Qualifiers: []string{"Q1", "Q2"},
+			Optional:   true,
+		}, tag)
+	}
+
+	tag, err = ParseInjectTag("type@Q")
+	if assert.Nil(t, err) {
+		assert.Equal(t, InjectTag{Qualifiers: []string{"Q"}, ByType: true}, tag)
+	}
+
+	_, err = ParseInjectTag("Name,nonsense")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,250 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Graph is the dependency graph of the services of a ServiceLocator as known
+// from the inject tags and constructor parameters of the services, without
+// creating any of them.  A Graph can be rendered as Graphviz DOT or as JSON
+type Graph struct {
+	// Locator is the name of the locator the graph was made from
+	Locator string `json:"locator"`
+	// Nodes are the services of the locator and its parents
+	Nodes []*GraphNode `json:"nodes"`
+	// Edges are the dependencies between the services
+	Edges []*GraphEdge `json:"edges"`
+}
+
+// GraphNode is a service in a Graph
+type GraphNode struct {
+	// ID is unique within the graph and has the form locatorID.serviceID
+	ID         string              `json:"id"`
+	Namespace  string              `json:"namespace"`
+	Name       string              `json:"name"`
+	Qualifiers []string            `json:"qualifiers,omitempty"`
+	Scope      string              `json:"scope"`
+	Rank       int32               `json:"rank"`
+	Metadata   map[string][]string `json:"metadata,omitempty"`
+	// Instantiated is true if the service has already been created and is
+	// being kept by its scope.  Services in the PerLookup scope are never kept
+	Instantiated bool `json:"instantiated"`
+}
+
+// GraphEdge is a dependency of one service on another in a Graph
+type GraphEdge struct {
+	// From is the ID of the service that has the dependency
+	From string `json:"from"`
+	// To is the ID of the service that would be injected
+	To string `json:"to"`
+	// InjectionPoint is the name of the field or constructor parameter
+	InjectionPoint string `json:"injectionPoint"`
+	// Provider is true if the dependency is injected with a Provider, in
+	// which case it is not created along with the service that has it
+	Provider bool `json:"provider,omitempty"`
+	// Optional is true if the dependency is optional
+	Optional bool `json:"optional,omitempty"`
+}
+
+// GraphOf returns the dependency graph of the services of the locator and its
+// parents.  Only services bound with Binder.Bind or Binder.BindConstructor have
+// edges since the dependencies of other services are not known until they are
+// created.  Dependencies that can not be resolved are left out of the graph, use
+// Verify to find them
+func GraphOf(locator ServiceLocator) (*Graph, error) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("unknown service locator type")
+	}
+
+	err := iLocator.checkState()
+	if err != nil {
+		return nil, err
+	}
+
+	all, err := iLocator.GetDescriptors(AllFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].GetLocatorID() != all[j].GetLocatorID() {
+			return all[i].GetLocatorID() < all[j].GetLocatorID()
+		}
+
+		return all[i].GetServiceID() < all[j].GetServiceID()
+	})
+
+	retVal := &Graph{
+		Locator: locator.GetName(),
+		Nodes:   make([]*GraphNode, 0, len(all)),
+		Edges:   make([]*GraphEdge, 0),
+	}
+
+	for _, desc := range all {
+		node := &GraphNode{
+			ID:           descriptorToIDString(desc),
+			Namespace:    desc.GetNamespace(),
+			Name:         desc.GetName(),
+			Scope:        desc.GetScope(),
+			Rank:         desc.GetRank(),
+			Instantiated: iLocator.getOwner(desc).isInstantiated(desc),
+		}
+
+		if qualifiers := desc.GetQualifiers(); len(qualifiers) > 0 {
+			node.Qualifiers = qualifiers
+		}
+
+		if metadata := desc.GetMetadata(); len(metadata) > 0 {
+			node.Metadata = metadata
+		}
+
+		retVal.Nodes = append(retVal.Nodes, node)
+
+		info, ok := desc.(injectionInformation)
+		if !ok {
+			continue
+		}
+
+		for _, point := range info.getInjectionPoints() {
+			dependencies, err := iLocator.resolveInjectionPoint(desc, point)
+			if err != nil {
+				continue
+			}
+
+			for _, dependency := range dependencies {
+				retVal.Edges = append(retVal.Edges, &GraphEdge{
+					From:           descriptorToIDString(desc),
+					To:             descriptorToIDString(dependency),
+					InjectionPoint: point.name,
+					Provider:       point.isProvider(),
+					Optional:       point.parsed.isOptional,
+				})
+			}
+		}
+	}
+
+	return retVal, nil
+}
+
+// isInstantiated returns true if the scope of the service is keeping an instance
+// of it.  Scopes that have not yet been created are not created by this method
+func (locator *serviceLocatorData) isInstantiated(desc Descriptor) bool {
+	scope := desc.GetScope()
+
+	switch scope {
+	case PerLookup:
+		return false
+	case Singleton:
+		return locator.singletonContext.ContainsKey(locator, desc)
+	}
+
+	scopeDesc, err := locator.GetBestDescriptor(NewSingleFilter(ContextualScopeNamespace, scope))
+	if err != nil || scopeDesc == nil {
+		return false
+	}
+
+	scopeOwner := locator.getOwner(scopeDesc)
+	if !scopeOwner.singletonContext.ContainsKey(scopeOwner, scopeDesc) {
+		return false
+	}
+
+	raw, err := scopeOwner.singletonContext.FindOrCreate(scopeOwner, scopeDesc)
+	if err != nil {
+		return false
+	}
+
+	cs, ok := raw.(ContextualScope)
+	if !ok {
+		return false
+	}
+
+	return cs.ContainsKey(locator, desc)
+}
+
+// DOT renders the graph in the Graphviz DOT language.  Services that have been
+// instantiated are filled and dependencies injected with a Provider are dashed
+func (g *Graph) DOT() string {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "digraph %s {\n", strconv.Quote(g.Locator))
+
+	for _, node := range g.Nodes {
+		label := node.Namespace + "#" + node.Name
+		if len(node.Qualifiers) > 0 {
+			label = label + "@" + strings.Join(node.Qualifiers, "@")
+		}
+		label = fmt.Sprintf("%s\n%s rank %d", label, node.Scope, node.Rank)
+
+		style := ""
+		if node.Instantiated {
+			style = " style=filled"
+		}
+
+		fmt.Fprintf(&buf, "  %s [label=%s%s];\n", strconv.Quote(node.ID), strconv.Quote(label), style)
+	}
+
+	for _, edge := range g.Edges {
+		style := ""
+		if edge.Provider {
+			style = " style=dashed"
+		}
+
+		fmt.Fprintf(&buf, "  %s -> %s [label=%s%s];\n", strconv.Quote(edge.From), strconv.Quote(edge.To),
+			strconv.Quote(edge.InjectionPoint), style)
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+// JSON renders the graph as indented JSON
+func (g *Graph) JSON() ([]byte, error) {
+	return json.MarshalIndent(g, "", "  ")
+}
@@ -0,0 +1,132 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const (
+	graphLocator = "GraphLocator"
+)
+
+func findGraphNode(g *Graph, name string) *GraphNode {
+	for _, node := range g.Nodes {
+		if node.Namespace == DefaultNamespace && node.Name == name {
+			return node
+		}
+	}
+
+	return nil
+}
+
+func TestGraphOf(t *testing.T) {
+	locator, err := CreateAndBind(graphLocator, func(binder Binder) error {
+		binder.Bind("Database", &verifyDatabase{}).QualifiedBy("Primary").Ranked(3)
+		binder.Bind("Request", &verifyDatabase{}).InScope(PerLookup)
+		binder.Bind("Repository", &verifyRepository{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Repository")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	g, err := GraphOf(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	database := findGraphNode(g, "Database")
+	request := findGraphNode(g, "Request")
+	repository := findGraphNode(g, "Repository")
+	if !assert.NotNil(t, database) || !assert.NotNil(t, request) || !assert.NotNil(t, repository) {
+		return
+	}
+
+	assert.Equal(t, []string{"Primary"}, database.Qualifiers)
+	assert.Equal(t, int32(3), database.Rank)
+	assert.Equal(t, Singleton, database.Scope)
+	assert.True(t, database.Instantiated)
+	assert.True(t, repository.Instantiated)
+	assert.False(t, request.Instantiated)
+
+	edges := map[string]*GraphEdge{}
+	for _, edge := range g.Edges {
+		if edge.From == repository.ID {
+			edges[edge.InjectionPoint] = edge
+		}
+	}
+
+	// Locator, Database and Request, Audit is optional and not bound
+	if !assert.Equal(t, 3, len(edges)) {
+		return
+	}
+	assert.Equal(t, database.ID, edges["Database"].To)
+	assert.False(t, edges["Database"].Provider)
+	assert.Equal(t, request.ID, edges["Request"].To)
+	assert.True(t, edges["Request"].Provider)
+
+	dot := g.DOT()
+	assert.True(t, strings.HasPrefix(dot, "digraph \"GraphLocator\" {"))
+	assert.True(t, strings.Contains(dot, "\""+repository.ID+"\" -> \""+database.ID+"\" [label=\"Database\"];"), dot)
+	assert.True(t, strings.Contains(dot, "\""+repository.ID+"\" -> \""+request.ID+"\" [label=\"Request\" style=dashed];"), dot)
+
+	raw, err := g.JSON()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var back Graph
+	err = json.Unmarshal(raw, &back)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, g, &back)
+}
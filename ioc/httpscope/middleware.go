@@ -0,0 +1,146 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package httpscope
+
+import (
+	"context"
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/jwells131313/goethe/cache"
+	"net/http"
+)
+
+type requestHandler struct {
+	locator ioc.ServiceLocator
+	next    http.Handler
+}
+
+// Handler returns an http.Handler that creates a dargo context for every
+// request before calling next.  The context of the request given to next is
+// the dargo context, so RequestScope and ContextScope services can be found
+// with its Value method or with the Get functions of this package.  All of
+// the RequestScope services created for the request are destroyed when next
+// returns.  EnableRequestScope must have been called on the locator
+func Handler(locator ioc.ServiceLocator, next http.Handler) http.Handler {
+	return &requestHandler{
+		locator: locator,
+		next:    next,
+	}
+}
+
+// Middleware returns a function that wraps an http.Handler with Handler
+func Middleware(locator ioc.ServiceLocator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return Handler(locator, next)
+	}
+}
+
+func (rh *requestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scope, err := getRequestScope(rh.locator)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	state := &requestState{
+		locator: rh.locator,
+		writer:  w,
+	}
+
+	state.services, err = cache.NewCache(state, func(cycler interface{}) error {
+		return fmt.Errorf("a cycle was detected in %s services involving %v", RequestScope, cycler)
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	parent, cancel := context.WithCancel(context.WithValue(r.Context(), requestStateKey{}, state))
+	defer cancel()
+
+	dargoContext, err := ioc.NewDargoContext(parent, rh.locator)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	state.request = r.WithContext(dargoContext)
+
+	scope.addRequest(state)
+	defer func() {
+		if scope.removeRequest(state) {
+			state.destroy(context.Background())
+		}
+	}()
+
+	rh.next.ServeHTTP(w, state.request)
+}
+
+func getRequestScope(locator ioc.ServiceLocator) (*requestScopeData, error) {
+	raw, err := locator.GetService(ioc.CSK(RequestScope))
+	if err != nil {
+		if ioc.IsServiceNotFound(err) {
+			return nil, fmt.Errorf("there is no %s.  You need to call EnableRequestScope in the httpscope package", RequestScope)
+		}
+
+		return nil, err
+	}
+
+	scope, ok := raw.(*requestScopeData)
+	if !ok {
+		return nil, fmt.Errorf("the %s implementation was not the expected type", RequestScope)
+	}
+
+	return scope, nil
+}
+
+// Get returns the service with the given key as a T, as found with the dargo
+// context of the request.  The request must be one given to a handler wrapped
+// with Handler or Middleware
+func Get[T any](r *http.Request, key ioc.ServiceKey) (T, error) {
+	return ioc.GetInContext[T](r.Context(), key)
+}
+
+// GetD returns the service with the given name in the default namespace as a T,
+// as found with the dargo context of the request
+func GetD[T any](r *http.Request, name string, qualifiers ...string) (T, error) {
+	return Get[T](r, ioc.DSK(name, qualifiers...))
+}
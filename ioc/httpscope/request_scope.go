@@ -0,0 +1,260 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package httpscope
+
+import (
+	"context"
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/jwells131313/goethe/cache"
+	"net/http"
+	"sync"
+)
+
+const (
+	// RequestScope is the scope of services that are created once per HTTP
+	// request and destroyed when the handler of the request returns
+	RequestScope = "RequestScope"
+
+	// RequestServiceName is the name of the RequestScope service in the default
+	// namespace that is the *http.Request being handled
+	RequestServiceName = "HTTPRequest"
+
+	// ResponseWriterServiceName is the name of the RequestScope service in the
+	// default namespace that is the http.ResponseWriter of the request being handled
+	ResponseWriterServiceName = "HTTPResponseWriter"
+)
+
+type requestStateKey struct{}
+
+// requestState holds the services of one request
+type requestState struct {
+	locator  ioc.ServiceLocator
+	services cache.Cache
+	request  *http.Request
+	writer   http.ResponseWriter
+}
+
+func (rs *requestState) Compute(in interface{}) (interface{}, error) {
+	desc, ok := in.(ioc.Descriptor)
+	if !ok {
+		return nil, fmt.Errorf("incoming key not the expected type %v", in)
+	}
+
+	return rs.locator.CreateServiceFromDescriptor(desc)
+}
+
+// destroy destroys all of the services created for the request
+func (rs *requestState) destroy(ctx context.Context) error {
+	values := make(map[ioc.Descriptor]interface{})
+
+	rs.services.Remove(func(key interface{}, value interface{}) bool {
+		desc, ok := key.(ioc.Descriptor)
+		if ok {
+			values[desc] = value
+		}
+
+		return true
+	})
+
+	return ioc.DestroyServices(ctx, rs.locator, values)
+}
+
+// requestScopeData is the ContextualScope of the RequestScope.  The services of
+// a request are kept in the requestState found in the current dargo context
+type requestScopeData struct {
+	lock   sync.Mutex
+	active map[*requestState]bool
+}
+
+func newRequestScope() *requestScopeData {
+	return &requestScopeData{
+		active: make(map[*requestState]bool),
+	}
+}
+
+func (rsd *requestScopeData) addRequest(state *requestState) {
+	rsd.lock.Lock()
+	defer rsd.lock.Unlock()
+
+	rsd.active[state] = true
+}
+
+func (rsd *requestScopeData) removeRequest(state *requestState) bool {
+	rsd.lock.Lock()
+	defer rsd.lock.Unlock()
+
+	if !rsd.active[state] {
+		return false
+	}
+
+	delete(rsd.active, state)
+
+	return true
+}
+
+func currentRequestState() (*requestState, error) {
+	dargoContext, found := ioc.CurrentDargoContext()
+	if !found {
+		return nil, fmt.Errorf("%s services must be looked up with the context of an HTTP request", RequestScope)
+	}
+
+	state, ok := dargoContext.Value(requestStateKey{}).(*requestState)
+	if !ok {
+		return nil, fmt.Errorf("the current dargo context was not created by the %s middleware", RequestScope)
+	}
+
+	return state, nil
+}
+
+func (rsd *requestScopeData) GetScope() string {
+	return RequestScope
+}
+
+func (rsd *requestScopeData) FindOrCreate(locator ioc.ServiceLocator, desc ioc.Descriptor) (interface{}, error) {
+	state, err := currentRequestState()
+	if err != nil {
+		return nil, err
+	}
+
+	return state.services.Compute(desc)
+}
+
+func (rsd *requestScopeData) ContainsKey(locator ioc.ServiceLocator, desc ioc.Descriptor) bool {
+	state, err := currentRequestState()
+	if err != nil {
+		return false
+	}
+
+	return state.services.HasKey(desc)
+}
+
+func (rsd *requestScopeData) DestroyOne(locator ioc.ServiceLocator, desc ioc.Descriptor) error {
+	state, err := currentRequestState()
+	if err != nil {
+		return err
+	}
+
+	values := make(map[ioc.Descriptor]interface{})
+	state.services.Remove(func(key interface{}, value interface{}) bool {
+		if key != desc {
+			return false
+		}
+
+		values[desc] = value
+
+		return true
+	})
+
+	return ioc.DestroyServices(context.Background(), locator, values)
+}
+
+func (rsd *requestScopeData) GetSupportsNilCreation(locator ioc.ServiceLocator) bool {
+	return false
+}
+
+func (rsd *requestScopeData) IsActive(locator ioc.ServiceLocator) bool {
+	return true
+}
+
+func (rsd *requestScopeData) Shutdown(locator ioc.ServiceLocator) {
+	rsd.ShutdownContext(context.Background(), locator)
+}
+
+// ShutdownContext destroys the services of the requests that are still being handled
+func (rsd *requestScopeData) ShutdownContext(ctx context.Context, locator ioc.ServiceLocator) error {
+	rsd.lock.Lock()
+	states := make([]*requestState, 0, len(rsd.active))
+	for state := range rsd.active {
+		states = append(states, state)
+	}
+	rsd.active = make(map[*requestState]bool)
+	rsd.lock.Unlock()
+
+	errs := ioc.NewMultiError()
+	for _, state := range states {
+		err := state.destroy(ctx)
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
+
+	return errs.GetFinalError()
+}
+
+// EnableRequestScope adds the RequestScope to the locator, along with the
+// RequestServiceName and ResponseWriterServiceName services.  It also enables
+// the DargoContext scope, which the RequestScope is built on.  The RequestScope
+// is proxiable, so services in it may be injected into Singleton services when a
+// proxy factory is registered for the type of the injection point
+func EnableRequestScope(locator ioc.ServiceLocator) error {
+	err := ioc.EnableDargoContextScope(locator)
+	if err != nil {
+		return err
+	}
+
+	return ioc.BindIntoLocator(locator, func(binder ioc.Binder) error {
+		binder.BindConstant(RequestScope, newRequestScope()).InNamespace(ioc.ContextualScopeNamespace).
+			QualifiedBy(RequestScope).WithMetadata(ioc.ProxiableMetadata, "true")
+		binder.BindWithCreator(RequestServiceName, createRequest).InScope(RequestScope)
+		binder.BindWithCreator(ResponseWriterServiceName, createResponseWriter).InScope(RequestScope)
+
+		return nil
+	})
+}
+
+func createRequest(locator ioc.ServiceLocator, desc ioc.Descriptor) (interface{}, error) {
+	state, err := currentRequestState()
+	if err != nil {
+		return nil, err
+	}
+
+	return state.request, nil
+}
+
+func createResponseWriter(locator ioc.ServiceLocator, desc ioc.Descriptor) (interface{}, error) {
+	state, err := currentRequestState()
+	if err != nil {
+		return nil, err
+	}
+
+	return state.writer, nil
+}
@@ -0,0 +1,229 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package httpscope
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const (
+	httpScopeLocator1 = "HTTPScopeLocator1"
+	httpScopeLocator2 = "HTTPScopeLocator2"
+)
+
+var (
+	counterGeneration int32
+	destroyedLock     sync.Mutex
+	destroyed         []string
+)
+
+func addDestroyed(name string) {
+	destroyedLock.Lock()
+	defer destroyedLock.Unlock()
+
+	destroyed = append(destroyed, name)
+}
+
+func getDestroyed() []string {
+	destroyedLock.Lock()
+	defer destroyedLock.Unlock()
+
+	retVal := make([]string, len(destroyed))
+	copy(retVal, destroyed)
+
+	return retVal
+}
+
+type requestCounter struct {
+	id int32
+}
+
+func (rc *requestCounter) DargoInitialize(ioc.Descriptor) error {
+	rc.id = atomic.AddInt32(&counterGeneration, 1)
+	return nil
+}
+
+func (rc *requestCounter) DargoDestroy(ioc.Descriptor) error {
+	addDestroyed(fmt.Sprintf("counter%d", rc.id))
+	return nil
+}
+
+type greeter struct {
+	Request *http.Request       `inject:"HTTPRequest"`
+	Writer  http.ResponseWriter `inject:"HTTPResponseWriter"`
+	Counter *requestCounter     `inject:"Counter"`
+}
+
+func (g *greeter) DargoDestroy(ioc.Descriptor) error {
+	addDestroyed(fmt.Sprintf("greeter%d", g.Counter.id))
+	return nil
+}
+
+func (g *greeter) greet() {
+	fmt.Fprintf(g.Writer, "hello %s %d", g.Request.URL.Query().Get("name"), g.Counter.id)
+}
+
+func waitForDestroyed(count int) []string {
+	for lcv := 0; lcv < 200; lcv++ {
+		current := getDestroyed()
+		if len(current) >= count {
+			return current
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return getDestroyed()
+}
+
+func get(t *testing.T, url string) (int, string) {
+	response, err := http.Get(url)
+	if !assert.Nil(t, err) {
+		return 0, ""
+	}
+	defer response.Body.Close()
+
+	body, err := io.ReadAll(response.Body)
+	assert.Nil(t, err)
+
+	return response.StatusCode, string(body)
+}
+
+func TestRequestScope(t *testing.T) {
+	counterGeneration = 0
+	destroyed = nil
+
+	locator, err := ioc.CreateAndBind(httpScopeLocator1, func(binder ioc.Binder) error {
+		binder.Bind("Counter", &requestCounter{}).InScope(RequestScope)
+		binder.Bind("Greeter", &greeter{}).InScope(RequestScope)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = EnableRequestScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	server := httptest.NewServer(Handler(locator, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g, err := GetD[*greeter](r, "Greeter")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		again, err := GetD[*greeter](r, "Greeter")
+		if err != nil || again != g {
+			http.Error(w, "the same request should get the same service", http.StatusInternalServerError)
+			return
+		}
+
+		g.greet()
+	})))
+	defer server.Close()
+
+	status, body := get(t, server.URL+"?name=alice")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "hello alice 1", body)
+
+	assert.Equal(t, []string{"greeter1", "counter1"}, waitForDestroyed(2),
+		"request services should be destroyed, dependents first, when the handler returns")
+
+	status, body = get(t, server.URL+"?name=bob")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "hello bob 2", body)
+
+	assert.Equal(t, []string{"greeter1", "counter1", "greeter2", "counter2"}, waitForDestroyed(4))
+}
+
+func TestRequestScopeErrors(t *testing.T) {
+	locator, err := ioc.CreateAndBind(httpScopeLocator2, func(binder ioc.Binder) error {
+		binder.Bind("Counter", &requestCounter{}).InScope(RequestScope)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	notEnabled := httptest.NewServer(Middleware(locator)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	})))
+	defer notEnabled.Close()
+
+	status, _ := get(t, notEnabled.URL)
+	assert.Equal(t, http.StatusInternalServerError, status, "the RequestScope was not enabled")
+
+	err = EnableRequestScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	unwrapped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := GetD[*requestCounter](r, "Counter")
+		if err == nil {
+			http.Error(w, "should not find a request service outside of the middleware", http.StatusInternalServerError)
+			return
+		}
+
+		_, err = locator.GetDService("Counter")
+		if err == nil {
+			http.Error(w, "should not find a request service without a request", http.StatusInternalServerError)
+		}
+	}))
+	defer unwrapped.Close()
+
+	status, _ = get(t, unwrapped.URL)
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -41,6 +41,7 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
 	"github.com/jwells131313/goethe"
 	"github.com/jwells131313/goethe/cache"
@@ -73,19 +74,7 @@ func (isd *ImmediateScopeData) ContainsKey(locator ServiceLocator, desc Descript
 
 // DestroyOne implements the ContextualScope interface
 func (isd *ImmediateScopeData) DestroyOne(locator ServiceLocator, desc Descriptor) error {
-	lookForMe := idKey{desc: desc}
-
-	isd.cache.Remove(func(key interface{}, value interface{}) bool {
-		if key == lookForMe {
-			isd.actualDestruction(desc, value)
-
-			return true
-		}
-
-		return false
-	})
-
-	return nil
+	return destroyOne(isd.Locator, isd.cache, desc)
 }
 
 // GetSupportsNilCreation implements the ContextualScope interface
@@ -100,18 +89,21 @@ func (isd *ImmediateScopeData) IsActive(locator ServiceLocator) bool {
 
 // Shutdown implements the ContextualScope interface
 func (isd *ImmediateScopeData) Shutdown(locator ServiceLocator) {
+	isd.ShutdownContext(context.Background(), locator)
+}
+
+// ShutdownContext implements the ContextAwareScope interface
+func (isd *ImmediateScopeData) ShutdownContext(ctx context.Context, locator ServiceLocator) error {
 	tid := threadManager.GetThreadID()
 	if tid < 0 {
-		c := make(chan bool)
-
-		threadManager.Go(isd.channelShutdown, c)
+		c := make(chan error)
 
-		<-c
+		threadManager.Go(isd.channelShutdown, ctx, c)
 
-		return
+		return <-c
 	}
 
-	isd.internalShutdown()
+	return isd.internalShutdown(ctx)
 }
 
 // DargoInitialize initializes the scope
@@ -139,34 +131,12 @@ func (isd *ImmediateScopeData) Compute(in interface{}) (interface{}, error) {
 	return isd.Locator.CreateServiceFromDescriptor(key.desc)
 }
 
-func (isd *ImmediateScopeData) channelShutdown(replyChan chan bool) {
-	isd.internalShutdown()
-
-	replyChan <- true
+func (isd *ImmediateScopeData) channelShutdown(ctx context.Context, replyChan chan error) {
+	replyChan <- isd.internalShutdown(ctx)
 }
 
-func (isd *ImmediateScopeData) internalShutdown() {
-	isd.cache.Remove(func(key interface{}, value interface{}) bool {
-		idKey := key.(idKey)
-
-		isd.actualDestruction(idKey.desc, value)
-
-		return true
-	})
-
-}
-
-func (isd *ImmediateScopeData) actualDestruction(desc Descriptor, value interface{}) {
-	if desc == nil {
-		return
-	}
-
-	df := desc.GetDestroyFunction()
-	if df == nil {
-		return
-	}
-
-	df(isd.Locator, desc, value)
+func (isd *ImmediateScopeData) internalShutdown(ctx context.Context) error {
+	return destroyAll(ctx, isd.Locator, isd.cache)
 }
 
 // ImmediateConfigurationListerData structure for the ImmediateService configuration listener service
@@ -55,6 +55,7 @@ const (
 	ImmediateTestLocator4 = "ImmediateTestLocator4"
 	ImmediateTestLocator5 = "ImmediateTestLocator5"
 	ImmediateTestLocator6 = "ImmediateTestLocator6"
+	ImmediateTestLocator7 = "ImmediateTestLocator7"
 
 	ImmediateServiceName = "ImmediateService"
 
@@ -346,6 +347,54 @@ func TestImmediateWithPanic(t *testing.T) {
 	assert.Equal(t, ExpectedPanicMessage, info.GetAssociatedError().Error())
 }
 
+func TestImmediateDestroyerLooksUpScopedService(t *testing.T) {
+	locator, err := CreateAndBind(ImmediateTestLocator7, func(binder Binder) error {
+		return nil
+	})
+	if !assert.Nil(t, err, "could not create locator") {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = EnableImmediateScope(locator)
+	if !assert.Nil(t, err, "could not enable immediate scope %v", err) {
+		return
+	}
+
+	destroyed := make(chan error, 1)
+
+	err = BindIntoLocator(locator, func(binder Binder) error {
+		binder.Bind("Partner", &CountingImmediateService{}).InScope(ImmediateScope)
+		binder.Bind(ImmediateServiceName, &ImmediateService{}).InScope(ImmediateScope).
+			AndDestroyWith(func(l ServiceLocator, d Descriptor, o interface{}) error {
+				_, err := l.GetDService("Partner")
+				destroyed <- err
+				return err
+			})
+		return nil
+	})
+	if !assert.Nil(t, err, "could not bind immediate services") {
+		return
+	}
+
+	_, err = locator.GetDService(ImmediateServiceName)
+	if !assert.Nil(t, err, "didn't find immediate service?") {
+		return
+	}
+
+	err = UnbindDServices(locator, ImmediateServiceName)
+	if !assert.Nil(t, err, "could not unbind immediate service") {
+		return
+	}
+
+	select {
+	case err = <-destroyed:
+		assert.Nil(t, err, "destroyer could not look up a service of the same scope")
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "destroyer was blocked by the lock of the immediate scope")
+	}
+}
+
 type ImmediateService struct {
 	stopped bool
 }
@@ -72,6 +72,51 @@ const (
 	// ImmediateScope scope services are started immediately
 	ImmediateScope = "ImmediateScope"
 
+	// RunLevelScope scope services are started and stopped a level at a time
+	// by the RunLevelController
+	RunLevelScope = "RunLevelScope"
+
+	// RunLevelMetadata is the metadata key of RunLevelScope services that holds
+	// the run level of the service, as set by Binder.AtRunLevel
+	RunLevelMetadata = "runlevel"
+
+	// RunLevelNone is the level of the RunLevelController before any level
+	// has been reached.  Run levels of services must be higher than this level
+	RunLevelNone = -1
+
+	// DecoratesMetadata is the metadata key of a Decorator service that holds the
+	// namespace#name of the services it decorates, as set by Binder.Decorates
+	DecoratesMetadata = "decorates"
+
+	// SubscribesToMetadata is the metadata key of a Subscriber service that holds the
+	// topics it subscribes to, as set by Binder.SubscribesTo
+	SubscribesToMetadata = "subscribesTo"
+
+	// FactoryMetadata is the metadata key that holds the name of the Factory
+	// of a service bound with Binder.BindFactory or from a manifest
+	FactoryMetadata = "factory"
+
+	// ModuleMetadata is the metadata key that holds the name of the Module that
+	// bound a service with Install, which is used by Uninstall to unbind its services
+	ModuleMetadata = "module"
+
+	// ModuleVersionMetadata is the metadata key that holds the version of the
+	// VersionedModule that bound a service with Install
+	ModuleVersionMetadata = "moduleVersion"
+
+	// ProfileMetadata is the metadata key that holds the profiles of a service
+	// bound with Binder.InProfile
+	ProfileMetadata = "profile"
+
+	// ProfilesEnvironmentVariable is the environment variable holding the comma
+	// separated active profiles of locators created without WithProfiles
+	ProfilesEnvironmentVariable = "DARGO_PROFILES"
+
+	// ProxiableMetadata is the metadata key of a ContextualScope descriptor that, when
+	// it has the value "true", causes services of that scope injected into Singleton or
+	// Immediate services to be injected as proxies if a proxy factory is registered
+	ProxiableMetadata = "proxiable"
+
 	// SystemNamespace The namespace for system services
 	SystemNamespace = "system"
 
@@ -97,6 +142,21 @@ const (
 	// InjectionResolver the name an an implementation of InjectionResolver must have
 	InjectionResolverName = "InjectionResolver"
 
+	// InterceptionServiceName the name an implementation of InterceptionService must have
+	InterceptionServiceName = "InterceptionService"
+
+	// InstanceLifecycleListenerName the name an implementation of InstanceLifecycleListener must have
+	InstanceLifecycleListenerName = "InstanceLifecycleListener"
+
+	// JustInTimeResolverName the name an implementation of JustInTimeResolver must have
+	JustInTimeResolverName = "JustInTimeResolver"
+
+	// VerifierName the name an implementation of Verifier must have
+	VerifierName = "Verifier"
+
+	// ContextResolverName the name an implementation of ContextResolver must have
+	ContextResolverName = "ContextResolver"
+
 	// SystemInjectionResolverQualifierName A qualifier that is put on the system injection
 	// resolver for the "inject" field annotation
 	SystemInjectionResolverQualifierName = "SystemInjectResolverQualifier"
@@ -116,12 +176,22 @@ const (
 	// DynamicConfigurationServiceName The name of the DynamicConfigurationService (in the system namespace)
 	DynamicConfigurationServiceName = "DynamicConfigurationService"
 
+	// RunLevelControllerName The name of the RunLevelController (in the system namespace)
+	RunLevelControllerName = "RunLevelController"
+
+	// RunLevelListenerName the name implementations of RunLevelListener must have
+	RunLevelListenerName = "RunLevelListener"
+
 	// DargoContextCreationServiceName The name of the DargoCreationContextService
 	DargoContextCreationServiceName = "DargoContextCreationService"
 
 	// LocatorStateRunning This is the state when a locator is currently open and running
 	LocatorStateRunning = "Running"
 
+	// LocatorStateShuttingDown This is the state when a locator is being shut down.  Services
+	// may still be looked up while in this state so that destroyers can use other services
+	LocatorStateShuttingDown = "ShuttingDown"
+
 	// LocatorStateShutdown This is the state when a locator has been shut down
 	LocatorStateShutdown = "Shutdown"
 
@@ -131,9 +201,60 @@ const (
 	// ServiceCreationFailure is a type of error returned by ErrorInformation.GetType
 	ServiceCreationFailure = "SERVICE_CREATION_FAILURE"
 
+	// InstancePreCreate is a type of event returned by InstanceLifecycleEvent.GetEventType
+	InstancePreCreate = "PRE_CREATE"
+
+	// InstancePostCreate is a type of event returned by InstanceLifecycleEvent.GetEventType
+	InstancePostCreate = "POST_CREATE"
+
+	// InstanceCreateFailed is a type of event returned by InstanceLifecycleEvent.GetEventType
+	InstanceCreateFailed = "CREATE_FAILED"
+
+	// InstancePreDestroy is a type of event returned by InstanceLifecycleEvent.GetEventType
+	InstancePreDestroy = "PRE_DESTROY"
+
+	// InstancePostDestroy is a type of event returned by InstanceLifecycleEvent.GetEventType
+	InstancePostDestroy = "POST_DESTROY"
+
 	// LookupValidationFailure is a type of error returned by ErrorInformation.GetType
 	LookupValidationFailure = "LOOKUP_VALIDATION_FAILURE"
 
+	// ServiceDestructionFailure is a type of error returned by ErrorInformation.GetType
+	ServiceDestructionFailure = "SERVICE_DESTRUCTION_FAILURE"
+
+	// SubscriberFailure is a type of error returned by ErrorInformation.GetType
+	SubscriberFailure = "SUBSCRIBER_FAILURE"
+
+	// ListenerFailure is a type of error returned by ErrorInformation.GetType
+	ListenerFailure = "LISTENER_FAILURE"
+
+	// ConditionFailure is a type of error returned by ErrorInformation.GetType
+	ConditionFailure = "CONDITION_FAILURE"
+
+	// VerificationUnresolvedDependency is returned by VerificationInfo.GetVerificationType
+	// when a required dependency has no service bound for it
+	VerificationUnresolvedDependency = "UNRESOLVED_DEPENDENCY"
+
+	// VerificationAmbiguousDependency is returned by VerificationInfo.GetVerificationType
+	// when a dependency found by type matches more than one service with the same rank
+	VerificationAmbiguousDependency = "AMBIGUOUS_DEPENDENCY"
+
+	// VerificationInvalidInjectionPoint is returned by VerificationInfo.GetVerificationType
+	// when an inject tag or constructor parameter can not be parsed
+	VerificationInvalidInjectionPoint = "INVALID_INJECTION_POINT"
+
+	// VerificationUnknownScope is returned by VerificationInfo.GetVerificationType
+	// when a service is in a scope for which no ContextualScope is bound
+	VerificationUnknownScope = "UNKNOWN_SCOPE"
+
+	// VerificationScopeWidening is returned by VerificationInfo.GetVerificationType
+	// when a service of a narrower scope is injected into a Singleton or Immediate service
+	VerificationScopeWidening = "SCOPE_WIDENING"
+
+	// VerificationCycle is returned by VerificationInfo.GetVerificationType
+	// when services are injected into each other
+	VerificationCycle = "CYCLE"
+
 	// BindOperation is the Bind operation passed in the ValidationInformation
 	BindOperation = "BIND"
 
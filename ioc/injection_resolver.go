@@ -41,6 +41,7 @@
 package ioc
 
 import (
+	"fmt"
 	"reflect"
 )
 
@@ -105,9 +106,9 @@ func (sir *systemInjectionResolver) Resolve(locator ServiceLocator, injectee Inj
 	fieldVal := injectee.GetField()
 	desc := injectee.GetDescriptor()
 
-	injectString := fieldVal.Tag.Get("inject")
+	injectString, hasTag := fieldVal.Tag.Lookup("inject")
 
-	if injectString != "" {
+	if hasTag {
 		pd, err := parseInjectString(injectString)
 		if err != nil {
 			return nil, false, err
@@ -116,6 +117,58 @@ func (sir *systemInjectionResolver) Resolve(locator ServiceLocator, injectee Inj
 
 		fieldType := fieldVal.Type
 
+		if isTopic(fieldType) || isTypedTopic(fieldType) {
+			topicValue, err := newTopicValue(iLocator, fieldType, pd)
+			if err != nil {
+				return nil, false, fmt.Errorf("field %s: %v", fieldVal.Name, err)
+			}
+
+			return &topicValue, true, nil
+		}
+
+		proxy, err := iLocator.getProxyFor(fieldType, pd, desc)
+		if err != nil {
+			return nil, false, err
+		}
+		if proxy != nil {
+			return proxy, true, nil
+		}
+
+		if pd.all {
+			dependencyAsValue, err := iLocator.getCollectionFor(fieldType, pd, desc)
+			if err != nil {
+				return nil, false, err
+			}
+
+			return dependencyAsValue, true, nil
+		}
+
+		if pd.byType {
+			if isProvider(fieldType) || isTypedProvider(fieldType) {
+				return nil, false, fmt.Errorf("the field %s is a Provider and so must name the service to inject",
+					fieldVal.Name)
+			}
+
+			dependency, err := iLocator.getServiceByTypeFor(fieldType, pd.qualifiers, desc)
+			if err != nil {
+				if pd.isOptional && IsServiceNotFound(err) {
+					return nil, false, nil
+				}
+
+				return nil, false, err
+			}
+
+			dependencyAsValue := reflect.ValueOf(dependency)
+
+			return &dependencyAsValue, true, nil
+		}
+
+		if isTypedProvider(fieldType) {
+			dependencyAsValue := newTypedProviderValue(fieldType, newProvider(iLocator, serviceKey, desc))
+
+			return &dependencyAsValue, true, nil
+		}
+
 		var dependency interface{}
 		if !isProvider(fieldType) {
 			dependency, err = iLocator.getServiceFor(serviceKey, desc)
@@ -0,0 +1,383 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// InterceptionService is a service that must be in namespace ioc.UserServicesNamespace
+// and have name ioc.InterceptionServiceName.  It supplies the interceptors that are run
+// around the method calls of the services it selects.  A selected service is given to
+// other services wrapped in the interception proxy registered with the locator for the
+// interface it implements with RegisterInterceptionFactory or RegisterInterceptionProxy,
+// and so it must be looked up or injected as that interface
+type InterceptionService interface {
+	// GetFilter returns the filter used to select the services to intercept.
+	// Services in the system, user services and scope namespaces are never intercepted
+	GetFilter() Filter
+
+	// GetMethodInterceptors returns the interceptors of the given method of the
+	// interface a service is proxied as.  The interceptors are run in the order
+	// returned.  Returning no interceptors leaves the method alone
+	GetMethodInterceptors(desc Descriptor, method reflect.Method) []MethodInterceptor
+}
+
+// MethodInterceptor runs around the method calls of an intercepted service
+type MethodInterceptor interface {
+	// Invoke is called instead of the method.  It should call Proceed on the
+	// invocation to run the next interceptor, or the method itself if it is
+	// the last interceptor, and return the results, possibly changed.  An
+	// interceptor that does not call Proceed must return results of the types
+	// the method returns
+	Invoke(invocation MethodInvocation) []interface{}
+}
+
+// MethodInvocation is a call of a method of an intercepted service
+type MethodInvocation interface {
+	// GetDescriptor returns the descriptor of the intercepted service
+	GetDescriptor() Descriptor
+
+	// GetService returns the intercepted service itself
+	GetService() interface{}
+
+	// GetMethod returns the method of the interface being called
+	GetMethod() reflect.Method
+
+	// GetArguments returns the arguments of the call.  Changing an element
+	// changes the argument given to the rest of the chain
+	GetArguments() []interface{}
+
+	// Proceed calls the next interceptor, or the method if there are no
+	// more interceptors, and returns its results
+	Proceed() []interface{}
+}
+
+// InterceptionHandler is given to an interception proxy factory.  Each method
+// of the proxy calls Invoke with its name and arguments and returns the results
+type InterceptionHandler interface {
+	// GetDescriptor returns the descriptor of the intercepted service
+	GetDescriptor() Descriptor
+
+	// GetService returns the intercepted service itself
+	GetService() interface{}
+
+	// Invoke calls the named method of the service through its interceptors and
+	// returns the results.  The last argument of a variadic method is given as a slice
+	Invoke(method string, args ...interface{}) []interface{}
+}
+
+// InterceptionFactory creates a proxy that implements an interface by calling
+// Invoke on the handler in each of its methods
+type InterceptionFactory func(handler InterceptionHandler) (interface{}, error)
+
+// RegisterInterceptionFactory registers the factory that creates the interception
+// proxies of services that implement the given interface type in the given locator
+// and its children.  Registering a factory for a type that already has one in the
+// locator replaces the previous factory
+func RegisterInterceptionFactory(locator ServiceLocator, ty reflect.Type, factory InterceptionFactory) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+	if ty == nil || ty.Kind() != reflect.Interface {
+		return fmt.Errorf("interception proxies may only be registered for interface types, not %v", ty)
+	}
+	if factory == nil {
+		return fmt.Errorf("the interception factory for %v may not be nil", ty)
+	}
+
+	iLocator.interceptFactories.Store(ty, factory)
+
+	return nil
+}
+
+// RegisterInterceptionProxy is the type-safe version of RegisterInterceptionFactory,
+// where the factory returns a T that calls Invoke on the handler in each of its methods
+func RegisterInterceptionProxy[T any](locator ServiceLocator, factory func(handler InterceptionHandler) T) error {
+	if factory == nil {
+		return fmt.Errorf("the interception factory may not be nil")
+	}
+
+	return RegisterInterceptionFactory(locator, reflect.TypeOf((*T)(nil)).Elem(), func(handler InterceptionHandler) (interface{}, error) {
+		return factory(handler), nil
+	})
+}
+
+// getInterceptionFactory returns the factory for the one interface registered with
+// this locator or its parents that the type implements.  A factory registered with
+// a locator takes the place of one registered for the same interface with its parents
+func (locator *serviceLocatorData) getInterceptionFactory(ty reflect.Type) (reflect.Type, InterceptionFactory, error) {
+	factories := make(map[reflect.Type]InterceptionFactory)
+	for current := locator; current != nil; current = current.parent {
+		current.interceptFactories.Range(func(key, value interface{}) bool {
+			iFace := key.(reflect.Type)
+			if _, found := factories[iFace]; !found && ty.Implements(iFace) {
+				factories[iFace] = value.(InterceptionFactory)
+			}
+
+			return true
+		})
+	}
+
+	var found reflect.Type
+	for iFace := range factories {
+		if found != nil {
+			return nil, nil, fmt.Errorf("type %v implements both %v and %v, which both have interception factories",
+				ty, found, iFace)
+		}
+
+		found = iFace
+	}
+
+	if found == nil {
+		return nil, nil, nil
+	}
+
+	return found, factories[found], nil
+}
+
+type interceptionHandlerData struct {
+	desc         Descriptor
+	service      interface{}
+	methods      map[string]reflect.Method
+	interceptors map[string][]MethodInterceptor
+}
+
+func (handler *interceptionHandlerData) GetDescriptor() Descriptor {
+	return handler.desc
+}
+
+func (handler *interceptionHandlerData) GetService() interface{} {
+	return handler.service
+}
+
+func (handler *interceptionHandlerData) Invoke(method string, args ...interface{}) []interface{} {
+	iMethod, found := handler.methods[method]
+	if !found {
+		panic(fmt.Sprintf("%s is not a method of the interception proxy of %v", method, handler.desc))
+	}
+
+	invocation := &methodInvocationData{
+		handler:   handler,
+		method:    iMethod,
+		arguments: args,
+		chain:     handler.interceptors[method],
+	}
+
+	return invocation.Proceed()
+}
+
+type methodInvocationData struct {
+	handler   *interceptionHandlerData
+	method    reflect.Method
+	arguments []interface{}
+	chain     []MethodInterceptor
+}
+
+func (mi *methodInvocationData) GetDescriptor() Descriptor {
+	return mi.handler.desc
+}
+
+func (mi *methodInvocationData) GetService() interface{} {
+	return mi.handler.service
+}
+
+func (mi *methodInvocationData) GetMethod() reflect.Method {
+	return mi.method
+}
+
+func (mi *methodInvocationData) GetArguments() []interface{} {
+	return mi.arguments
+}
+
+func (mi *methodInvocationData) Proceed() []interface{} {
+	if len(mi.chain) > 0 {
+		return mi.chain[0].Invoke(&methodInvocationData{
+			handler:   mi.handler,
+			method:    mi.method,
+			arguments: mi.arguments,
+			chain:     mi.chain[1:],
+		})
+	}
+
+	fn := reflect.ValueOf(mi.handler.service).MethodByName(mi.method.Name)
+	fnType := fn.Type()
+
+	if len(mi.arguments) != fnType.NumIn() {
+		panic(fmt.Sprintf("method %s of %v takes %d arguments but was given %d", mi.method.Name,
+			mi.handler.desc, fnType.NumIn(), len(mi.arguments)))
+	}
+
+	args := make([]reflect.Value, len(mi.arguments))
+	for index, arg := range mi.arguments {
+		if arg == nil {
+			args[index] = reflect.Zero(fnType.In(index))
+		} else {
+			args[index] = reflect.ValueOf(arg)
+		}
+	}
+
+	var results []reflect.Value
+	if fnType.IsVariadic() {
+		results = fn.CallSlice(args)
+	} else {
+		results = fn.Call(args)
+	}
+
+	retVal := make([]interface{}, len(results))
+	for index, result := range results {
+		retVal[index] = result.Interface()
+	}
+
+	return retVal
+}
+
+// intercept returns the service wrapped in its interception proxy if any of the
+// interception services of the locator have interceptors for it
+func (locator *serviceLocatorData) intercept(desc Descriptor, service interface{}) (interface{}, error) {
+	if len(locator.interceptors) == 0 || service == nil {
+		return service, nil
+	}
+
+	matching := make([]InterceptionService, 0)
+	for _, interceptor := range locator.interceptors {
+		ret := &errorReturn{}
+		filter := safeGetInterceptionFilter(interceptor, ret)
+		if ret.err != nil {
+			return nil, ret.err
+		}
+
+		if filter != nil && checkFilter(filter, desc) {
+			matching = append(matching, interceptor)
+		}
+	}
+
+	if len(matching) == 0 {
+		return service, nil
+	}
+
+	iFace, factory, err := locator.getInterceptionFactory(reflect.TypeOf(service))
+	if err != nil {
+		return nil, err
+	}
+
+	if factory == nil {
+		return nil, fmt.Errorf("service %v is selected by an InterceptionService but no interception factory "+
+			"is registered for an interface of type %T", desc, service)
+	}
+
+	handler := &interceptionHandlerData{
+		desc:         desc,
+		service:      service,
+		methods:      make(map[string]reflect.Method),
+		interceptors: make(map[string][]MethodInterceptor),
+	}
+
+	intercepted := false
+	for lcv := 0; lcv < iFace.NumMethod(); lcv++ {
+		method := iFace.Method(lcv)
+		handler.methods[method.Name] = method
+
+		for _, interceptor := range matching {
+			ret := &errorReturn{}
+			methodInterceptors := safeGetMethodInterceptors(interceptor, desc, method, ret)
+			if ret.err != nil {
+				return nil, ret.err
+			}
+
+			handler.interceptors[method.Name] = append(handler.interceptors[method.Name], methodInterceptors...)
+		}
+
+		intercepted = intercepted || len(handler.interceptors[method.Name]) > 0
+	}
+
+	if !intercepted {
+		return service, nil
+	}
+
+	ret := &errorReturn{}
+	proxy := safeInterceptionFactory(factory, handler, ret)
+	if ret.err != nil {
+		return nil, ret.err
+	}
+
+	if proxy == nil || !reflect.TypeOf(proxy).Implements(iFace) {
+		return nil, fmt.Errorf("the interception factory for %v returned %T which does not implement it", iFace, proxy)
+	}
+
+	return proxy, nil
+}
+
+func safeGetInterceptionFilter(interceptor InterceptionService, ret *errorReturn) Filter {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return interceptor.GetFilter()
+}
+
+func safeGetMethodInterceptors(interceptor InterceptionService, desc Descriptor, method reflect.Method,
+	ret *errorReturn) []MethodInterceptor {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return interceptor.GetMethodInterceptors(desc, method)
+}
+
+func safeInterceptionFactory(factory InterceptionFactory, handler InterceptionHandler, ret *errorReturn) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	proxy, err := factory(handler)
+	ret.err = err
+
+	return proxy
+}
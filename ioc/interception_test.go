@@ -0,0 +1,346 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+const (
+	interceptionLocator1 = "InterceptionLocator1"
+	interceptionLocator2 = "InterceptionLocator2"
+	interceptionLocator3 = "InterceptionLocator3"
+	interceptionLocator4 = "InterceptionLocator4"
+
+	calculatorName = "Calculator"
+)
+
+type calculator interface {
+	Add(a, b int) int
+	Divide(a, b int) (int, error)
+	Sum(values ...int) int
+}
+
+type calculatorData struct {
+	destroyed bool
+}
+
+func (cd *calculatorData) Add(a, b int) int {
+	return a + b
+}
+
+func (cd *calculatorData) Divide(a, b int) (int, error) {
+	return a / b, nil
+}
+
+func (cd *calculatorData) Sum(values ...int) int {
+	retVal := 0
+	for _, value := range values {
+		retVal += value
+	}
+
+	return retVal
+}
+
+func (cd *calculatorData) DargoDestroy(Descriptor) error {
+	cd.destroyed = true
+	return nil
+}
+
+type calculatorProxy struct {
+	handler InterceptionHandler
+}
+
+func (cp *calculatorProxy) Add(a, b int) int {
+	results := cp.handler.Invoke("Add", a, b)
+	return results[0].(int)
+}
+
+func (cp *calculatorProxy) Divide(a, b int) (int, error) {
+	results := cp.handler.Invoke("Divide", a, b)
+	err, _ := results[1].(error)
+	return results[0].(int), err
+}
+
+func (cp *calculatorProxy) Sum(values ...int) int {
+	results := cp.handler.Invoke("Sum", values)
+	return results[0].(int)
+}
+
+type loggingInterceptor struct {
+	log *[]string
+}
+
+func (li *loggingInterceptor) Invoke(invocation MethodInvocation) []interface{} {
+	name := invocation.GetMethod().Name
+	*li.log = append(*li.log, fmt.Sprintf("before %s %v", name, invocation.GetArguments()))
+
+	results := invocation.Proceed()
+
+	*li.log = append(*li.log, fmt.Sprintf("after %s %v", name, results))
+
+	return results
+}
+
+type doublingInterceptor struct {
+}
+
+func (di *doublingInterceptor) Invoke(invocation MethodInvocation) []interface{} {
+	args := invocation.GetArguments()
+	args[0] = args[0].(int) * 2
+
+	return invocation.Proceed()
+}
+
+type divideByZeroInterceptor struct {
+}
+
+func (dz *divideByZeroInterceptor) Invoke(invocation MethodInvocation) []interface{} {
+	if invocation.GetArguments()[1].(int) == 0 {
+		return []interface{}{0, fmt.Errorf("divide by zero")}
+	}
+
+	return invocation.Proceed()
+}
+
+type calculatorInterceptionService struct {
+	log []string
+}
+
+func (cis *calculatorInterceptionService) GetFilter() Filter {
+	return NewSingleFilter(DefaultNamespace, calculatorName)
+}
+
+func (cis *calculatorInterceptionService) GetMethodInterceptors(desc Descriptor, method reflect.Method) []MethodInterceptor {
+	switch method.Name {
+	case "Add":
+		return []MethodInterceptor{&loggingInterceptor{log: &cis.log}, &doublingInterceptor{}}
+	case "Divide":
+		return []MethodInterceptor{&divideByZeroInterceptor{}}
+	default:
+		return nil
+	}
+}
+
+type panickingInterceptionService struct {
+	inFilter bool
+}
+
+func (pis *panickingInterceptionService) GetFilter() Filter {
+	if pis.inFilter {
+		panic("filter failure")
+	}
+
+	return NewSingleFilter(DefaultNamespace, calculatorName)
+}
+
+func (pis *panickingInterceptionService) GetMethodInterceptors(desc Descriptor, method reflect.Method) []MethodInterceptor {
+	panic("method interceptors failure")
+}
+
+type uninterceptable struct {
+}
+
+func TestInterception(t *testing.T) {
+	interceptionService := &calculatorInterceptionService{}
+
+	locator, err := CreateAndBind(interceptionLocator1, func(binder Binder) error {
+		binder.BindConstant(InterceptionServiceName, interceptionService).InNamespace(UserServicesNamespace)
+		binder.Bind(calculatorName, &calculatorData{})
+		binder.Bind("OtherCalculator", &calculatorData{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = RegisterInterceptionProxy[calculator](locator, func(handler InterceptionHandler) calculator {
+		return &calculatorProxy{
+			handler: handler,
+		}
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	calc, err := GetD[calculator](locator, calculatorName)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	proxy, isProxy := calc.(*calculatorProxy)
+	if !assert.True(t, isProxy, "the calculator should be intercepted") {
+		return
+	}
+
+	assert.Equal(t, 4, calc.Add(1, 2), "the first argument should have been doubled")
+	assert.Equal(t, []string{"before Add [1 2]", "after Add [4]"}, interceptionService.log)
+
+	result, err := calc.Divide(8, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, result)
+
+	_, err = calc.Divide(8, 0)
+	assert.NotNil(t, err, "the interceptor should not have let the divide happen")
+
+	assert.Equal(t, 6, calc.Sum(1, 2, 3), "variadic methods go through the handler as a slice")
+
+	again, err := GetD[calculator](locator, calculatorName)
+	assert.Nil(t, err)
+	assert.Equal(t, calc, again, "the singleton is intercepted only once")
+
+	other, err := GetD[calculator](locator, "OtherCalculator")
+	if assert.Nil(t, err) {
+		_, isProxy = other.(*calculatorProxy)
+		assert.False(t, isProxy, "services not selected by the filter are not intercepted")
+	}
+
+	underlying := proxy.handler.GetService().(*calculatorData)
+	assert.Nil(t, locator.ShutdownContext(context.Background()))
+	assert.True(t, underlying.destroyed, "the intercepted service itself should be destroyed")
+}
+
+func TestInterceptionWithoutFactory(t *testing.T) {
+	locator, err := CreateAndBind(interceptionLocator2, func(binder Binder) error {
+		binder.BindConstant(InterceptionServiceName, &calculatorInterceptionService{}).InNamespace(UserServicesNamespace)
+		binder.Bind(calculatorName, &uninterceptable{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService(calculatorName)
+	assert.NotNil(t, err, "there is no interception factory for the service")
+
+	err = RegisterInterceptionFactory(locator, reflect.TypeOf(&uninterceptable{}), func(InterceptionHandler) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NotNil(t, err, "only interfaces may be intercepted")
+}
+
+func TestInterceptionFactoriesPerLocator(t *testing.T) {
+	parent, err := CreateAndBind(interceptionLocator3, func(binder Binder) error {
+		binder.BindConstant(InterceptionServiceName, &calculatorInterceptionService{}).InNamespace(UserServicesNamespace)
+		binder.Bind(calculatorName, &calculatorData{}).InScope(PerLookup)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	_, err = parent.GetDService(calculatorName)
+	assert.NotNil(t, err, "factories registered with other locators are not used")
+
+	err = RegisterInterceptionProxy[calculator](parent, func(handler InterceptionHandler) calculator {
+		return &calculatorProxy{
+			handler: handler,
+		}
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	child, err := NewChildServiceLocator(interceptionLocator3+"Child", FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	calc, err := GetD[calculator](child, calculatorName)
+	if assert.Nil(t, err) {
+		_, isProxy := calc.(*calculatorProxy)
+		assert.True(t, isProxy, "the factory of the parent is used")
+	}
+}
+
+func TestPanickingInterceptionService(t *testing.T) {
+	errorService := &recordingErrorService{}
+	interceptionService := &panickingInterceptionService{inFilter: true}
+
+	locator, err := CreateAndBind(interceptionLocator4, func(binder Binder) error {
+		binder.BindConstant(ErrorServiceName, errorService).InNamespace(UserServicesNamespace)
+		binder.BindConstant(InterceptionServiceName, interceptionService).InNamespace(UserServicesNamespace)
+		binder.Bind(calculatorName, &calculatorData{}).InScope(PerLookup)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = RegisterInterceptionProxy[calculator](locator, func(handler InterceptionHandler) calculator {
+		return &calculatorProxy{
+			handler: handler,
+		}
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService(calculatorName)
+	if assert.NotNil(t, err, "a panic in GetFilter should fail the creation") {
+		_, isMulti := err.(MultiError)
+		assert.True(t, isMulti)
+		assert.Contains(t, err.Error(), "filter failure")
+	}
+
+	interceptionService.inFilter = false
+
+	_, err = locator.GetDService(calculatorName)
+	if assert.NotNil(t, err, "a panic in GetMethodInterceptors should fail the creation") {
+		assert.Contains(t, err.Error(), "method interceptors failure")
+	}
+
+	failures := errorService.getErrors()
+	if assert.Equal(t, 2, len(failures)) {
+		assert.Equal(t, ServiceCreationFailure, failures[0].GetType())
+		assert.Equal(t, calculatorName, failures[1].GetDescriptor().GetName())
+	}
+}
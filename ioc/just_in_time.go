@@ -0,0 +1,133 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// JustInTimeResolver is a service that must be in namespace ioc.UserServicesNamespace
+// and have name ioc.JustInTimeResolverName.  When a service is looked up or injected
+// and no service matches, each JustInTimeResolver is given a chance to bind services
+// that do, such as by binding a structure type on demand or by loading descriptors
+// from a registry.  If any resolver returns true the lookup is tried one more time
+type JustInTimeResolver interface {
+	// JustInTimeResolution may bind services into the locator, for example with
+	// BindIntoLocator, that would satisfy the request.  It returns true if it
+	// bound any services.  If it returns an error the lookup fails with that error
+	JustInTimeResolution(locator ServiceLocator, request JustInTimeRequest) (bool, error)
+}
+
+// JustInTimeRequest describes a lookup for which no service was found
+type JustInTimeRequest interface {
+	// GetServiceKey returns the key that was looked up, which is nil
+	// if the service was looked up by type
+	GetServiceKey() ServiceKey
+
+	// GetType returns the type that was looked up, which is nil if the
+	// service was looked up with a key
+	GetType() reflect.Type
+
+	// GetInjectee returns the descriptor of the service that the service
+	// was being looked up for, which is nil if it was a direct lookup
+	GetInjectee() Descriptor
+}
+
+type justInTimeRequestData struct {
+	key      ServiceKey
+	typ      reflect.Type
+	injectee Descriptor
+}
+
+func (request *justInTimeRequestData) GetServiceKey() ServiceKey {
+	return request.key
+}
+
+func (request *justInTimeRequestData) GetType() reflect.Type {
+	return request.typ
+}
+
+func (request *justInTimeRequestData) GetInjectee() Descriptor {
+	return request.injectee
+}
+
+// resolveJustInTime gives the request to the just in time resolvers of the
+// locator and returns true if any of them bound services
+func (locator *serviceLocatorData) resolveJustInTime(key ServiceKey, ty reflect.Type, forMe Descriptor) (bool, error) {
+	resolvers := locator.jitResolvers
+	if len(resolvers) == 0 {
+		return false, nil
+	}
+
+	request := &justInTimeRequestData{
+		key:      key,
+		typ:      ty,
+		injectee: forMe,
+	}
+
+	retVal := false
+	for _, resolver := range resolvers {
+		ret := &errorReturn{}
+		resolved := safeJustInTimeResolution(resolver, locator, request, ret)
+		if ret.err != nil {
+			return false, ret.err
+		}
+
+		retVal = retVal || resolved
+	}
+
+	return retVal, nil
+}
+
+func safeJustInTimeResolution(resolver JustInTimeResolver, locator ServiceLocator, request JustInTimeRequest,
+	ret *errorReturn) bool {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	resolved, err := resolver.JustInTimeResolution(locator, request)
+	ret.err = err
+
+	return resolved
+}
@@ -0,0 +1,189 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	jitLocator1 = "JustInTimeLocator1"
+	jitLocator2 = "JustInTimeLocator2"
+	jitLocator3 = "JustInTimeLocator3"
+)
+
+type autoService struct {
+	name string
+}
+
+type onDemandService struct {
+}
+
+type autoClient struct {
+	Auto    *autoService `inject:"AutoInjected"`
+	ByType  *autoService `inject:""`
+	Unknown *autoService `inject:"AutoNoSuch"`
+}
+
+// autoBinder binds any key starting with Auto, and any pointer to struct looked up by type
+type autoBinder struct {
+	calls    int32
+	lastFor  Descriptor
+	failWith error
+}
+
+func (ab *autoBinder) JustInTimeResolution(locator ServiceLocator, request JustInTimeRequest) (bool, error) {
+	atomic.AddInt32(&ab.calls, 1)
+	ab.lastFor = request.GetInjectee()
+
+	if ab.failWith != nil {
+		return false, ab.failWith
+	}
+
+	if key := request.GetServiceKey(); key != nil {
+		if key.GetNamespace() != DefaultNamespace || !strings.HasPrefix(key.GetName(), "Auto") ||
+			key.GetName() == "AutoNoSuch" {
+			return false, nil
+		}
+
+		name := key.GetName()
+		return true, BindIntoLocator(locator, func(binder Binder) error {
+			binder.BindConstant(name, &autoService{name: name})
+			return nil
+		})
+	}
+
+	ty := request.GetType()
+	if ty.Kind() != reflect.Ptr || ty.Elem().Kind() != reflect.Struct {
+		return false, nil
+	}
+
+	return true, BindIntoLocator(locator, func(binder Binder) error {
+		binder.Bind(ty.Elem().Name(), reflect.New(ty.Elem()).Interface())
+		return nil
+	})
+}
+
+func TestJustInTimeResolver(t *testing.T) {
+	resolver := &autoBinder{}
+
+	locator, err := CreateAndBind(jitLocator1, func(binder Binder) error {
+		binder.BindConstant(JustInTimeResolverName, resolver).InNamespace(UserServicesNamespace)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	auto, err := GetD[*autoService](locator, "AutoOne")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "AutoOne", auto.name)
+	assert.Nil(t, resolver.lastFor)
+
+	// The second lookup finds the bound service without asking the resolver
+	calls := atomic.LoadInt32(&resolver.calls)
+	again, err := GetD[*autoService](locator, "AutoOne")
+	assert.Nil(t, err)
+	assert.Equal(t, auto, again)
+	assert.Equal(t, calls, atomic.LoadInt32(&resolver.calls))
+
+	_, err = locator.GetDService("Manual")
+	assert.True(t, IsServiceNotFound(err))
+
+	calls = atomic.LoadInt32(&resolver.calls)
+	byType, err := GetByType[*onDemandService](locator)
+	if assert.Nil(t, err) {
+		assert.NotNil(t, byType)
+	}
+	assert.Equal(t, calls+1, atomic.LoadInt32(&resolver.calls))
+}
+
+func TestJustInTimeInjection(t *testing.T) {
+	resolver := &autoBinder{}
+
+	locator, err := CreateAndBind(jitLocator2, func(binder Binder) error {
+		binder.BindConstant(JustInTimeResolverName, resolver).InNamespace(UserServicesNamespace)
+		binder.Bind("Client", autoClient{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("Client")
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, strings.Contains(err.Error(), "AutoNoSuch"), "error was %v", err)
+	if assert.NotNil(t, resolver.lastFor) {
+		assert.Equal(t, "Client", resolver.lastFor.GetName())
+	}
+
+	_, err = locator.GetDService("AutoInjected")
+	assert.Nil(t, err, "the service bound while injecting the client remains bound")
+}
+
+func TestJustInTimeResolverError(t *testing.T) {
+	resolver := &autoBinder{failWith: fmt.Errorf("registry unavailable")}
+
+	locator, err := CreateAndBind(jitLocator3, func(binder Binder) error {
+		binder.BindConstant(JustInTimeResolverName, resolver).InNamespace(UserServicesNamespace)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("AutoOne")
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "registry unavailable", err.Error())
+	}
+}
@@ -0,0 +1,169 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"time"
+)
+
+// InstanceLifecycleListener is a service that must be in namespace ioc.UserServicesNamespace
+// and have name ioc.InstanceLifecycleListenerName.  It is told every time a service it
+// selects is created or destroyed, in any scope.  A panic from a listener is given to the
+// ErrorService as a ListenerFailure along with the descriptor of the service of the event
+type InstanceLifecycleListener interface {
+	// GetFilter returns the filter used to select the services to be told about.
+	// A nil filter selects all services
+	GetFilter() Filter
+
+	// LifecycleEvent is called for each event of a selected service.  It is called
+	// on the goroutine creating or destroying the service and so should return quickly
+	LifecycleEvent(event InstanceLifecycleEvent)
+}
+
+// InstanceLifecycleEvent describes the creation or destruction of a service
+type InstanceLifecycleEvent interface {
+	// GetEventType returns the type of the event, which is one of InstancePreCreate,
+	// InstancePostCreate, InstanceCreateFailed, InstancePreDestroy or InstancePostDestroy
+	GetEventType() string
+
+	// GetDescriptor returns the descriptor of the service
+	GetDescriptor() Descriptor
+
+	// GetInstance returns the service, which is nil for InstancePreCreate and
+	// InstanceCreateFailed events.  It is the value that was given to other
+	// services, so it is the same for the creation and destruction of a service
+	GetInstance() interface{}
+
+	// GetError returns the error of an InstanceCreateFailed event, or of an
+	// InstancePostDestroy event if the service could not be destroyed
+	GetError() error
+
+	// GetTime returns the time of the event
+	GetTime() time.Time
+
+	// GetDuration returns how long the creation or destruction took for the
+	// InstancePostCreate, InstanceCreateFailed and InstancePostDestroy events
+	GetDuration() time.Duration
+}
+
+type instanceLifecycleEventData struct {
+	eventType string
+	desc      Descriptor
+	instance  interface{}
+	err       error
+	time      time.Time
+	duration  time.Duration
+}
+
+func (event *instanceLifecycleEventData) GetEventType() string {
+	return event.eventType
+}
+
+func (event *instanceLifecycleEventData) GetDescriptor() Descriptor {
+	return event.desc
+}
+
+func (event *instanceLifecycleEventData) GetInstance() interface{} {
+	return event.instance
+}
+
+func (event *instanceLifecycleEventData) GetError() error {
+	return event.err
+}
+
+func (event *instanceLifecycleEventData) GetTime() time.Time {
+	return event.time
+}
+
+func (event *instanceLifecycleEventData) GetDuration() time.Duration {
+	return event.duration
+}
+
+// fireLifecycleEvent tells the lifecycle listeners of the locator about the event
+// and returns the time of the event.  The duration of the event is measured from
+// start unless start is zero
+func (locator *serviceLocatorData) fireLifecycleEvent(eventType string, desc Descriptor, instance interface{},
+	err error, start time.Time) time.Time {
+	now := time.Now()
+
+	listeners := locator.lifecycleListeners
+	if len(listeners) == 0 {
+		return now
+	}
+
+	event := &instanceLifecycleEventData{
+		eventType: eventType,
+		desc:      desc,
+		instance:  instance,
+		err:       err,
+		time:      now,
+	}
+	if !start.IsZero() {
+		event.duration = now.Sub(start)
+	}
+
+	for _, listener := range listeners {
+		ret := &errorReturn{}
+		safeLifecycleEvent(listener, event, ret)
+		if ret.err != nil {
+			locator.runErrorHandlers(ListenerFailure, desc, nil, nil,
+				fmt.Errorf("instance lifecycle listener %T failed on the %s event: %v", listener, eventType, ret.err))
+		}
+	}
+
+	return now
+}
+
+func safeLifecycleEvent(listener InstanceLifecycleListener, event InstanceLifecycleEvent, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	filter := listener.GetFilter()
+	if filter != nil && !checkFilter(filter, event.GetDescriptor()) {
+		return
+	}
+
+	listener.LifecycleEvent(event)
+}
@@ -0,0 +1,199 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const (
+	lifecycleLocator1 = "LifecycleLocator1"
+	lifecycleLocator2 = "LifecycleLocator2"
+)
+
+type watchedFilter struct {
+}
+
+func (wf *watchedFilter) Filter(desc Descriptor) bool {
+	return desc.GetName() == "Watched" || desc.GetName() == "Failing"
+}
+
+func (wf *watchedFilter) GetNamespace() string {
+	return ""
+}
+
+func (wf *watchedFilter) GetName() string {
+	return ""
+}
+
+type recordingLifecycleListener struct {
+	lock   sync.Mutex
+	events []InstanceLifecycleEvent
+}
+
+func (rll *recordingLifecycleListener) GetFilter() Filter {
+	return &watchedFilter{}
+}
+
+func (rll *recordingLifecycleListener) LifecycleEvent(event InstanceLifecycleEvent) {
+	rll.lock.Lock()
+	defer rll.lock.Unlock()
+
+	rll.events = append(rll.events, event)
+}
+
+func (rll *recordingLifecycleListener) getEvents() []InstanceLifecycleEvent {
+	rll.lock.Lock()
+	defer rll.lock.Unlock()
+
+	retVal := make([]InstanceLifecycleEvent, len(rll.events))
+	copy(retVal, rll.events)
+
+	return retVal
+}
+
+type panickingLifecycleListener struct {
+}
+
+func (pll *panickingLifecycleListener) GetFilter() Filter {
+	return &watchedFilter{}
+}
+
+func (pll *panickingLifecycleListener) LifecycleEvent(event InstanceLifecycleEvent) {
+	panic(ExpectedPanicMessage)
+}
+
+func eventTypes(events []InstanceLifecycleEvent) []string {
+	retVal := make([]string, 0, len(events))
+	for _, event := range events {
+		retVal = append(retVal, event.GetEventType())
+	}
+
+	return retVal
+}
+
+func TestInstanceLifecycleListener(t *testing.T) {
+	listener := &recordingLifecycleListener{}
+
+	locator, err := CreateAndBind(lifecycleLocator1, func(binder Binder) error {
+		binder.BindConstant(InstanceLifecycleListenerName, listener).InNamespace(UserServicesNamespace)
+		binder.Bind("Watched", &destroyableService{})
+		binder.Bind("Unwatched", &destroyableService{})
+		binder.Bind("Failing", &failingPlugin{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	watched, err := locator.GetDService("Watched")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Unwatched")
+	assert.Nil(t, err)
+
+	events := listener.getEvents()
+	if !assert.Equal(t, []string{InstancePreCreate, InstancePostCreate}, eventTypes(events)) {
+		return
+	}
+	assert.Nil(t, events[0].GetInstance())
+	assert.Equal(t, watched, events[1].GetInstance())
+	assert.Equal(t, "Watched", events[1].GetDescriptor().GetName())
+	assert.False(t, events[1].GetTime().Before(events[0].GetTime()))
+	assert.True(t, events[1].GetDuration() >= 0)
+
+	_, err = locator.GetDService("Failing")
+	assert.NotNil(t, err)
+
+	events = listener.getEvents()
+	if !assert.Equal(t, 4, len(events)) {
+		return
+	}
+	assert.Equal(t, InstanceCreateFailed, events[3].GetEventType())
+	assert.NotNil(t, events[3].GetError())
+	assert.Nil(t, events[3].GetInstance())
+
+	assert.Nil(t, locator.ShutdownContext(context.Background()))
+
+	events = listener.getEvents()
+	assert.Equal(t, []string{InstancePreCreate, InstancePostCreate, InstancePreCreate, InstanceCreateFailed,
+		InstancePreDestroy, InstancePostDestroy}, eventTypes(events))
+	if len(events) == 6 {
+		assert.Equal(t, watched, events[5].GetInstance(), "the destroyed instance is the created instance")
+		assert.Nil(t, events[5].GetError())
+		assert.True(t, watched.(*destroyableService).destroyed)
+	}
+}
+
+func TestInstanceLifecycleListenerPanics(t *testing.T) {
+	lastErrorInformation = make([]ErrorInformation, 0)
+
+	locator, err := CreateAndBind(lifecycleLocator2, func(binder Binder) error {
+		binder.Bind(ErrorServiceName, errorServiceData{}).InNamespace(UserServicesNamespace)
+		binder.BindConstant(InstanceLifecycleListenerName, &panickingLifecycleListener{}).InNamespace(UserServicesNamespace)
+		binder.Bind("Watched", &destroyableService{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("Watched")
+	if !assert.Nil(t, err, "a panicking listener does not fail the creation") {
+		return
+	}
+
+	failures := 0
+	for _, ei := range lastErrorInformation {
+		if ei.GetType() == ListenerFailure && ei.GetDescriptor().GetName() == "Watched" {
+			failures++
+		}
+	}
+	assert.Equal(t, 2, failures, "the pre and post create panics should be given to the ErrorService")
+}
@@ -0,0 +1,302 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"gopkg.in/yaml.v3"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	// ManifestJSON is the format of ExportManifest that writes JSON
+	ManifestJSON = "json"
+
+	// ManifestYAML is the format of ExportManifest that writes YAML
+	ManifestYAML = "yaml"
+
+	manifestNormalVisibility = "normal"
+	manifestLocalVisibility  = "local"
+)
+
+// Manifest is a declarative list of services, as read by BindFromManifest
+// and written by ExportManifest
+type Manifest struct {
+	Services []*ManifestService `json:"services" yaml:"services"`
+}
+
+// ManifestService describes one service of a Manifest.  Only the name and the
+// factory are required.  Visibility is either normal, the default, or local.
+// A service with profiles is bound as if by Binder.InProfile
+type ManifestService struct {
+	Namespace  string              `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+	Name       string              `json:"name" yaml:"name"`
+	Factory    string              `json:"factory" yaml:"factory"`
+	AlsoAs     []string            `json:"alsoAs,omitempty" yaml:"alsoAs,omitempty"`
+	Qualifiers []string            `json:"qualifiers,omitempty" yaml:"qualifiers,omitempty"`
+	Scope      string              `json:"scope,omitempty" yaml:"scope,omitempty"`
+	Rank       int32               `json:"rank,omitempty" yaml:"rank,omitempty"`
+	Visibility string              `json:"visibility,omitempty" yaml:"visibility,omitempty"`
+	Profiles   []string            `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	Metadata   map[string][]string `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// BindFromManifest reads a Manifest in YAML or JSON and binds its services into
+// the locator in one DynamicConfiguration, so that either all of them are bound or,
+// if an error is returned, none of them are.  Every factory named by the manifest
+// must have been registered with ioc.RegisterFactory
+func BindFromManifest(locator ioc.ServiceLocator, reader io.Reader) error {
+	manifest, err := readManifest(reader)
+	if err != nil {
+		return err
+	}
+
+	visibilities := make([]int, len(manifest.Services))
+	for index, service := range manifest.Services {
+		if service == nil || service.Name == "" {
+			return fmt.Errorf("service %d of the manifest has no name", index)
+		}
+
+		namespace := service.Namespace
+		if namespace == "" {
+			namespace = ioc.DefaultNamespace
+		}
+
+		_, err = ioc.NewServiceKey(namespace, service.Name, service.Qualifiers...)
+		if err != nil {
+			return fmt.Errorf("service %s of the manifest has an invalid namespace, name or qualifier: %v",
+				service.Name, err)
+		}
+
+		for _, alsoAs := range service.AlsoAs {
+			err = checkAlsoAs(alsoAs, namespace)
+			if err != nil {
+				return fmt.Errorf("service %s of the manifest has an invalid alsoAs %q: %v", service.Name, alsoAs, err)
+			}
+		}
+
+		if ioc.GetFactory(service.Factory) == nil {
+			return fmt.Errorf("service %s of the manifest has unknown factory %q", service.Name, service.Factory)
+		}
+
+		switch strings.ToLower(service.Visibility) {
+		case "", manifestNormalVisibility:
+			visibilities[index] = ioc.NormalVisibility
+		case manifestLocalVisibility:
+			visibilities[index] = ioc.LocalVisibility
+		default:
+			return fmt.Errorf("service %s of the manifest has unknown visibility %s", service.Name,
+				service.Visibility)
+		}
+
+		if _, found := service.Metadata[ioc.ProfileMetadata]; found {
+			return fmt.Errorf("service %s of the manifest must give its profiles with profiles, not metadata",
+				service.Name)
+		}
+	}
+
+	return ioc.BindIntoLocator(locator, func(binder ioc.Binder) error {
+		for index, service := range manifest.Services {
+			binder.BindFactory(service.Name, service.Factory)
+
+			if service.Namespace != "" {
+				binder.InNamespace(service.Namespace)
+			}
+			if service.Scope != "" {
+				binder.InScope(service.Scope)
+			}
+			if len(service.AlsoAs) > 0 {
+				binder.AlsoAs(service.AlsoAs...)
+			}
+			for _, qualifier := range service.Qualifiers {
+				binder.QualifiedBy(qualifier)
+			}
+			if len(service.Profiles) > 0 {
+				binder.InProfile(service.Profiles...)
+			}
+			for key, values := range service.Metadata {
+				binder.WithMetadata(key, values...)
+			}
+
+			binder.Ranked(service.Rank).WithVisibility(visibilities[index])
+		}
+
+		return nil
+	})
+}
+
+// checkAlsoAs returns an error if the name given to alsoAs, which may include a
+// namespace, could not be given to Binder.AlsoAs
+func checkAlsoAs(alsoAs, namespace string) error {
+	name := alsoAs
+
+	namespaceAndName := strings.SplitN(alsoAs, "#", 2)
+	if len(namespaceAndName) == 2 {
+		namespace = namespaceAndName[0]
+		name = namespaceAndName[1]
+	}
+
+	_, err := ioc.NewServiceKey(namespace, name)
+
+	return err
+}
+
+func readManifest(reader io.Reader) (*Manifest, error) {
+	// JSON is YAML, so one decoder reads both
+	decoder := yaml.NewDecoder(reader)
+	decoder.KnownFields(true)
+
+	manifest := &Manifest{}
+	err := decoder.Decode(manifest)
+	if err == io.EOF {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read manifest: %v", err)
+	}
+
+	return manifest, nil
+}
+
+// ExportManifest writes the services bound into the locator with a factory, either
+// by BindFromManifest or with Binder.BindFactory, as a Manifest in the given format,
+// which is ManifestJSON or ManifestYAML.  Services that are bound but not active,
+// because none of their profiles is active, are written along with their profiles.
+// Services bound in other ways are not written since a manifest could not bind them
+// again, and an error is returned if a service with a factory was bound with
+// Binder.When, since a manifest can not hold its conditions.  Services of parent
+// locators are not written.  The services are written in the order of their full names
+func ExportManifest(locator ioc.ServiceLocator, writer io.Writer, format string) error {
+	if format != ManifestJSON && format != ManifestYAML {
+		return fmt.Errorf("unknown manifest format %s", format)
+	}
+
+	descs, err := ioc.GetBoundDescriptors(locator)
+	if err != nil {
+		return err
+	}
+
+	manifest := &Manifest{
+		Services: make([]*ManifestService, 0),
+	}
+	for _, desc := range descs {
+		service := descriptorToManifest(desc)
+		if service == nil {
+			continue
+		}
+
+		if ioc.HasConditions(desc) {
+			return fmt.Errorf("service %s was bound with a condition, which can not be written to a manifest",
+				desc.GetFullName())
+		}
+
+		manifest.Services = append(manifest.Services, service)
+	}
+
+	sort.SliceStable(manifest.Services, func(i, j int) bool {
+		left := manifest.Services[i].Namespace + "#" + manifest.Services[i].Name
+		right := manifest.Services[j].Namespace + "#" + manifest.Services[j].Name
+		return left < right
+	})
+
+	if format == ManifestJSON {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(manifest)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	err = encoder.Encode(manifest)
+	if err != nil {
+		return err
+	}
+
+	_, err = writer.Write(buf.Bytes())
+	return err
+}
+
+// descriptorToManifest returns the manifest entry of the descriptor, or nil
+// if it was not bound with a factory
+func descriptorToManifest(desc ioc.Descriptor) *ManifestService {
+	metadata := desc.GetMetadata()
+
+	factoryNames := metadata[ioc.FactoryMetadata]
+	if len(factoryNames) != 1 {
+		return nil
+	}
+
+	retVal := &ManifestService{
+		Namespace:  desc.GetNamespace(),
+		Name:       desc.GetName(),
+		Factory:    factoryNames[0],
+		Qualifiers: desc.GetQualifiers(),
+		Scope:      desc.GetScope(),
+		Rank:       desc.GetRank(),
+		Visibility: manifestNormalVisibility,
+		Profiles:   metadata[ioc.ProfileMetadata],
+	}
+	if contractDesc, ok := desc.(ioc.ContractDescriptor); ok {
+		retVal.AlsoAs = contractDesc.GetContracts()
+	}
+	if desc.GetVisibility() == ioc.LocalVisibility {
+		retVal.Visibility = manifestLocalVisibility
+	}
+
+	for key, values := range metadata {
+		if key == ioc.FactoryMetadata || key == ioc.ProfileMetadata {
+			continue
+		}
+
+		if retVal.Metadata == nil {
+			retVal.Metadata = make(map[string][]string)
+		}
+		retVal.Metadata[key] = values
+	}
+
+	return retVal
+}
@@ -0,0 +1,335 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package manifest
+
+import (
+	"bytes"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const (
+	manifestLocator1 = "ManifestLocator1"
+	manifestLocator2 = "ManifestLocator2"
+	manifestLocator3 = "ManifestLocator3"
+	manifestLocator4 = "ManifestLocator4"
+	manifestLocator5 = "ManifestLocator5"
+)
+
+type manifestStore interface {
+	Kind() string
+}
+
+type manifestStoreData struct {
+	kind string
+}
+
+func (msd *manifestStoreData) Kind() string {
+	return msd.kind
+}
+
+func init() {
+	ioc.RegisterFactory("manifestPostgres", func(locator ioc.ServiceLocator, desc ioc.Descriptor) (interface{}, error) {
+		return &manifestStoreData{kind: "postgres"}, nil
+	})
+	ioc.RegisterFactory("manifestMemory", func(locator ioc.ServiceLocator, desc ioc.Descriptor) (interface{}, error) {
+		return &manifestStoreData{kind: "memory"}, nil
+	})
+}
+
+const storeManifest = `
+services:
+  - name: Store
+    factory: manifestPostgres
+    qualifiers: [Primary]
+    rank: 10
+    alsoAs: [Database]
+    metadata:
+      region: [us-east]
+  - namespace: cache
+    name: Store
+    factory: manifestMemory
+    scope: PerLookup
+    visibility: local
+`
+
+func TestBindFromManifest(t *testing.T) {
+	locator, err := ioc.CreateAndBind(manifestLocator1, func(binder ioc.Binder) error {
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = BindFromManifest(locator, strings.NewReader(storeManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	store, err := ioc.GetD[manifestStore](locator, "Store", "Primary")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "postgres", store.Kind())
+	}
+
+	database, err := ioc.GetD[manifestStore](locator, "Database")
+	if assert.Nil(t, err) {
+		assert.Equal(t, store, database)
+	}
+
+	cached, err := ioc.Get[manifestStore](locator, mustKey("cache", "Store"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, "memory", cached.Kind())
+	}
+
+	desc, err := locator.GetBestDescriptor(ioc.NewSingleFilter("cache", "Store"))
+	if assert.Nil(t, err) && assert.NotNil(t, desc) {
+		assert.Equal(t, ioc.PerLookup, desc.GetScope())
+		assert.Equal(t, ioc.LocalVisibility, desc.GetVisibility())
+		assert.Equal(t, []string{"manifestMemory"}, desc.GetMetadata()[ioc.FactoryMetadata])
+	}
+
+	desc, err = locator.GetBestDescriptor(ioc.NewSingleFilter(ioc.DefaultNamespace, "Store"))
+	if assert.Nil(t, err) && assert.NotNil(t, desc) {
+		assert.Equal(t, int32(10), desc.GetRank())
+		assert.Equal(t, []string{"us-east"}, desc.GetMetadata()["region"])
+	}
+}
+
+func TestBindFromJSONManifest(t *testing.T) {
+	locator, err := ioc.CreateAndBind(manifestLocator2, func(binder ioc.Binder) error {
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = BindFromManifest(locator, strings.NewReader(
+		`{"services": [{"name": "Store", "factory": "manifestMemory"}]}`))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	store, err := ioc.GetD[manifestStore](locator, "Store")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "memory", store.Kind())
+	}
+}
+
+func TestBindFromManifestFailures(t *testing.T) {
+	locator, err := ioc.CreateAndBind(manifestLocator3, func(binder ioc.Binder) error {
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	badManifests := map[string]string{
+		"unknown factory": `
+services:
+  - name: Good
+    factory: manifestMemory
+  - name: Bad
+    factory: noSuchFactory
+`,
+		"no name":            "services:\n  - factory: manifestMemory\n",
+		"unknown field":      "services:\n  - name: Store\n    factory: manifestMemory\n    colour: blue\n",
+		"unknown visibility": "services:\n  - name: Store\n    factory: manifestMemory\n    visibility: public\n",
+		"not a manifest":     "[1, 2, 3]",
+	}
+
+	for reason, manifest := range badManifests {
+		err = BindFromManifest(locator, strings.NewReader(manifest))
+		assert.NotNil(t, err, reason)
+	}
+
+	badEntries := map[string]string{
+		"bad name":      "services:\n  - name: Bad Store\n    factory: manifestMemory\n",
+		"bad namespace": "services:\n  - name: Store\n    factory: manifestMemory\n    namespace: \"bad ns!\"\n",
+		"bad qualifier": "services:\n  - name: Store\n    factory: manifestMemory\n    qualifiers: [\"no-dash\"]\n",
+		"bad alsoAs":    "services:\n  - name: Store\n    factory: manifestMemory\n    alsoAs: [\"Cache\", \"bad ns!#Store\"]\n",
+	}
+
+	for reason, manifest := range badEntries {
+		err = BindFromManifest(locator, strings.NewReader(manifest))
+		if assert.NotNil(t, err, reason) {
+			assert.Contains(t, err.Error(), "Store", "the error should name the entry for %s", reason)
+		}
+	}
+
+	_, err = locator.GetDService("Store")
+	assert.True(t, ioc.IsServiceNotFound(err), "invalid entries are not bound")
+
+	_, err = locator.GetDService("Good")
+	assert.True(t, ioc.IsServiceNotFound(err), "nothing is bound when the manifest fails")
+
+	assert.NotNil(t, ioc.RegisterFactory("", func(ioc.ServiceLocator, ioc.Descriptor) (interface{}, error) {
+		return nil, nil
+	}))
+	assert.NotNil(t, ioc.RegisterFactory("manifestNil", nil))
+}
+
+func TestExportManifest(t *testing.T) {
+	locator, err := ioc.CreateAndBind(manifestLocator4, func(binder ioc.Binder) error {
+		binder.BindFactory("Coded", "manifestMemory").QualifiedBy("Fast")
+		binder.BindConstant("NotExported", &manifestStoreData{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = BindFromManifest(locator, strings.NewReader(storeManifest))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var yamlOut bytes.Buffer
+	if !assert.Nil(t, ExportManifest(locator, &yamlOut, ManifestYAML)) {
+		return
+	}
+
+	exported, err := readManifest(bytes.NewReader(yamlOut.Bytes()))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	names := make([]string, 0)
+	for _, service := range exported.Services {
+		names = append(names, service.Namespace+"#"+service.Name)
+	}
+	assert.Equal(t, []string{"cache#Store", "default#Coded", "default#Store"}, names)
+
+	// Reading the export into a new locator and exporting that gives the same manifest
+	child, err := ioc.NewServiceLocator(manifestLocator4+"Copy", ioc.FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer child.Shutdown()
+
+	if !assert.Nil(t, BindFromManifest(child, bytes.NewReader(yamlOut.Bytes()))) {
+		return
+	}
+
+	var jsonOut, copyOut bytes.Buffer
+	assert.Nil(t, ExportManifest(locator, &jsonOut, ManifestJSON))
+	assert.Nil(t, ExportManifest(child, &copyOut, ManifestJSON))
+	assert.Equal(t, jsonOut.String(), copyOut.String())
+	assert.True(t, strings.Contains(jsonOut.String(), `"factory": "manifestPostgres"`), jsonOut.String())
+
+	err = ExportManifest(locator, &jsonOut, "xml")
+	assert.NotNil(t, err)
+
+	assert.Panics(t, func() {
+		ioc.BindIntoLocator(locator, func(binder ioc.Binder) error {
+			binder.BindFactory("Unknown", "noSuchFactory")
+			return nil
+		})
+	}, "an unknown factory panics like other binder errors")
+}
+
+func TestExportManifestProfilesAndConditions(t *testing.T) {
+	locator, err := ioc.NewServiceLocator(manifestLocator5, ioc.FailIfPresent, ioc.WithProfiles("dev"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = BindFromManifest(locator, strings.NewReader(`
+services:
+  - name: DevStore
+    factory: manifestMemory
+    profiles: [dev]
+  - name: ProdStore
+    factory: manifestPostgres
+    profiles: [prod]
+`))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("ProdStore")
+	assert.True(t, ioc.IsServiceNotFound(err), "the prod profile is not active")
+
+	var out bytes.Buffer
+	if !assert.Nil(t, ExportManifest(locator, &out, ManifestYAML)) {
+		return
+	}
+
+	exported, err := readManifest(bytes.NewReader(out.Bytes()))
+	if !assert.Nil(t, err) || !assert.Equal(t, 2, len(exported.Services)) {
+		return
+	}
+
+	assert.Equal(t, "DevStore", exported.Services[0].Name)
+	assert.Equal(t, []string{"dev"}, exported.Services[0].Profiles)
+	assert.Equal(t, "ProdStore", exported.Services[1].Name, "inactive services are exported")
+	assert.Equal(t, []string{"prod"}, exported.Services[1].Profiles)
+	assert.Nil(t, exported.Services[1].Metadata, "profiles are not written as metadata")
+
+	err = BindFromManifest(locator, strings.NewReader(
+		"services:\n  - name: Bad\n    factory: manifestMemory\n    metadata:\n      profile: [dev]\n"))
+	assert.NotNil(t, err, "profiles may not be given as metadata")
+
+	err = ioc.BindIntoLocator(locator, func(binder ioc.Binder) error {
+		binder.BindFactory("Conditional", "manifestMemory").When(ioc.IfNoOtherBound())
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.NotNil(t, ExportManifest(locator, &out, ManifestJSON), "conditions can not be exported")
+}
+
+func mustKey(namespace, name string) ioc.ServiceKey {
+	key, err := ioc.NewServiceKey(namespace, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return key
+}
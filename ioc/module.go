@@ -0,0 +1,345 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Module is a named group of services that may require other modules.  Modules
+// are installed into a locator with Install, which configures the modules a
+// module requires before the module itself
+type Module interface {
+	// Name returns the name of the module, which must be unique among the
+	// modules installed into a locator
+	Name() string
+
+	// Requires returns the names of the modules that must be installed
+	// before this module
+	Requires() []string
+
+	// Configure binds the services of the module
+	Configure(binder Binder) error
+}
+
+// VersionedModule is a Module that has a version, such as the module of a Go
+// plugin loaded with the ioc/pluginloader package.  Install adds the version to
+// the metadata of the services of the module under ModuleVersionMetadata
+type VersionedModule interface {
+	Module
+
+	// Version returns the version of the module
+	Version() string
+}
+
+// Install installs the modules into the locator.  The modules are ordered so that
+// every module is configured after the modules it requires, which must either be
+// among the given modules or already be installed into the locator.  An error is
+// returned without installing any module if a required module is missing, if the
+// requirements of the modules have a cycle or if a module has the same name as
+// another module.  The services of all of the modules are committed in one
+// DynamicConfiguration, so that if any module fails to configure, by returning an
+// error or by panicking, or the commit fails none of the modules are installed.  The services have the name of their
+// module in their metadata under ModuleMetadata, and the version of a
+// VersionedModule under ModuleVersionMetadata.  Install and Uninstall of the same
+// locator run one at a time, so the Configure method of a module must not call
+// either of them
+func Install(locator ServiceLocator, modules ...Module) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+
+	dcs, err := getDCS(locator)
+	if err != nil {
+		return err
+	}
+
+	iLocator.moduleLock.Lock()
+	defer iLocator.moduleLock.Unlock()
+
+	ordered, err := orderModules(iLocator, modules)
+	if err != nil {
+		return err
+	}
+
+	descs := make([]Descriptor, 0)
+	for _, module := range ordered {
+		configured, err := configureModule(iLocator, module)
+		if err != nil {
+			return err
+		}
+
+		descs = append(descs, configured...)
+	}
+
+	config, err := dcs.CreateDynamicConfiguration()
+	if err != nil {
+		return err
+	}
+
+	for _, desc := range descs {
+		_, err = config.Bind(desc)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = config.Commit()
+	if err != nil {
+		return err
+	}
+
+	for _, module := range ordered {
+		iLocator.modules.Store(module.Name(), module)
+	}
+
+	return nil
+}
+
+// configureModule returns the descriptors of the services of the module
+func configureModule(locator *serviceLocatorData, module Module) ([]Descriptor, error) {
+	name := module.Name()
+
+	b := newBinder(locator)
+
+	ret := &errorReturn{}
+	safeConfigure(module, b, ret)
+	if ret.err != nil {
+		return nil, fmt.Errorf("could not install module %s: %v", name, ret.err)
+	}
+
+	b.stamp(ModuleMetadata, name)
+	if versioned, ok := module.(VersionedModule); ok {
+		b.stamp(ModuleVersionMetadata, versioned.Version())
+	}
+
+	return b.finish(), nil
+}
+
+// safeConfigure calls the Configure method of the module, which may panic
+// when the module misuses the Binder
+func safeConfigure(module Module, binder Binder, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	ret.err = module.Configure(binder)
+}
+
+// alreadyInstalled returns the error for a module with the same name as an installed module
+func alreadyInstalled(locator *serviceLocatorData, module Module, existing Module) error {
+	versioned, isVersioned := module.(VersionedModule)
+	existingVersioned, isExistingVersioned := existing.(VersionedModule)
+	if isVersioned && isExistingVersioned {
+		return fmt.Errorf("module %s version %s conflicts with version %s already installed into locator %s",
+			module.Name(), versioned.Version(), existingVersioned.Version(), locator.GetName())
+	}
+
+	return fmt.Errorf("module %s is already installed into locator %s", module.Name(), locator.GetName())
+}
+
+// orderModules returns the modules ordered so that each module comes after the
+// modules it requires.  Modules that do not depend on each other keep the order
+// they were given in
+func orderModules(locator *serviceLocatorData, modules []Module) ([]Module, error) {
+	byName := make(map[string]Module)
+	for _, module := range modules {
+		if module == nil {
+			return nil, fmt.Errorf("a module may not be nil")
+		}
+
+		name := module.Name()
+		if name == "" {
+			return nil, fmt.Errorf("a module must have a name")
+		}
+		if _, found := byName[name]; found {
+			return nil, fmt.Errorf("module %s is given more than once", name)
+		}
+		if existing, found := locator.modules.Load(name); found {
+			return nil, alreadyInstalled(locator, module, existing.(Module))
+		}
+
+		byName[name] = module
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[string]int)
+	retVal := make([]Module, 0, len(modules))
+
+	var visit func(module Module, path []string) error
+	visit = func(module Module, path []string) error {
+		name := module.Name()
+		path = append(path, name)
+
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("modules have a cycle of requirements: %s", strings.Join(path, " -> "))
+		}
+
+		state[name] = visiting
+
+		for _, required := range module.Requires() {
+			requiredModule, found := byName[required]
+			if !found {
+				if _, installed := locator.modules.Load(required); installed {
+					continue
+				}
+
+				return fmt.Errorf("module %s requires module %s which is not installed", name, required)
+			}
+
+			err := visit(requiredModule, path)
+			if err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		retVal = append(retVal, module)
+
+		return nil
+	}
+
+	for _, module := range modules {
+		err := visit(module, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return retVal, nil
+}
+
+// Uninstall unbinds every service bound into the locator by the module with the
+// given name.  An error is returned if another installed module requires it
+func Uninstall(locator ServiceLocator, name string) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+
+	iLocator.moduleLock.Lock()
+	defer iLocator.moduleLock.Unlock()
+
+	if _, found := iLocator.modules.Load(name); !found {
+		return fmt.Errorf("no module named %s is installed into locator %s", name, locator.GetName())
+	}
+
+	var dependent string
+	iLocator.modules.Range(func(key, value interface{}) bool {
+		for _, required := range value.(Module).Requires() {
+			if required == name {
+				dependent = key.(string)
+				return false
+			}
+		}
+
+		return true
+	})
+	if dependent != "" {
+		return fmt.Errorf("module %s can not be uninstalled since module %s requires it", name, dependent)
+	}
+
+	err := unbindByMetadata(locator, ModuleMetadata, name)
+	if err != nil {
+		return err
+	}
+
+	iLocator.modules.Delete(name)
+
+	return nil
+}
+
+type metadataFilter struct {
+	key, value string
+}
+
+func (filter *metadataFilter) Filter(desc Descriptor) bool {
+	for _, value := range desc.GetMetadata()[filter.key] {
+		if value == filter.value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (filter *metadataFilter) GetNamespace() string {
+	return ""
+}
+
+func (filter *metadataFilter) GetName() string {
+	return ""
+}
+
+// unbindByMetadata unbinds the services with the value in their metadata under the key
+func unbindByMetadata(locator ServiceLocator, key, value string) error {
+	dcs, err := getDCS(locator)
+	if err != nil {
+		return err
+	}
+
+	config, err := dcs.CreateDynamicConfiguration()
+	if err != nil {
+		return err
+	}
+
+	err = config.AddRemoveFilter(&metadataFilter{
+		key:   key,
+		value: value,
+	})
+	if err != nil {
+		return err
+	}
+
+	return config.Commit()
+}
@@ -0,0 +1,256 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	moduleLocator1 = "ModuleLocator1"
+	moduleLocator2 = "ModuleLocator2"
+	moduleLocator3 = "ModuleLocator3"
+	moduleLocator4 = "ModuleLocator4"
+	moduleLocator5 = "ModuleLocator5"
+)
+
+type testModule struct {
+	name       string
+	requires   []string
+	configured *[]string
+	fail       bool
+	misuse     bool
+}
+
+func (tm *testModule) Name() string {
+	return tm.name
+}
+
+func (tm *testModule) Requires() []string {
+	return tm.requires
+}
+
+func (tm *testModule) Configure(binder Binder) error {
+	if tm.configured != nil {
+		*tm.configured = append(*tm.configured, tm.name)
+	}
+
+	binder.BindConstant(tm.name+"Service", tm.name)
+	binder.BindConstant(tm.name+"Other", tm.name).QualifiedBy("Other")
+
+	if tm.misuse {
+		binder.BindConstant(tm.name+"Misused", tm.name).AlsoAs("not a name")
+	}
+
+	if tm.fail {
+		return fmt.Errorf("module %s failed", tm.name)
+	}
+
+	return nil
+}
+
+func TestInstallOrdersModules(t *testing.T) {
+	locator, err := NewServiceLocator(moduleLocator1, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	configured := make([]string, 0)
+
+	err = Install(locator,
+		&testModule{name: "web", requires: []string{"database", "cache"}, configured: &configured},
+		&testModule{name: "cache", requires: []string{"database"}, configured: &configured},
+		&testModule{name: "database", configured: &configured},
+		&testModule{name: "metrics", configured: &configured})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []string{"database", "cache", "web", "metrics"}, configured)
+
+	desc, err := locator.GetBestDescriptor(NewServiceKeyFilter(DSK("cacheOther", "Other")))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"cache"}, desc.GetMetadata()[ModuleMetadata])
+	}
+
+	// Requirements may already be installed
+	err = Install(locator, &testModule{name: "admin", requires: []string{"web"}, configured: &configured})
+	assert.Nil(t, err)
+}
+
+func TestInstallFailures(t *testing.T) {
+	locator, err := NewServiceLocator(moduleLocator2, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = Install(locator,
+		&testModule{name: "web", requires: []string{"database"}},
+		&testModule{name: "cache"})
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "database"))
+	}
+
+	err = Install(locator,
+		&testModule{name: "a", requires: []string{"b"}},
+		&testModule{name: "b", requires: []string{"c"}},
+		&testModule{name: "c", requires: []string{"a"}})
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "a -> b -> c -> a"))
+	}
+
+	assert.NotNil(t, Install(locator, &testModule{name: "a"}, &testModule{name: "a"}))
+	assert.NotNil(t, Install(locator, &testModule{}))
+	assert.NotNil(t, Install(locator, nil))
+
+	// Nothing was installed by the failures
+	_, err = locator.GetDService("cacheService")
+	assert.True(t, IsServiceNotFound(err))
+
+	assert.Nil(t, Install(locator, &testModule{name: "cache"}))
+	assert.NotNil(t, Install(locator, &testModule{name: "cache"}))
+}
+
+func TestInstallConfigureFailure(t *testing.T) {
+	locator, err := NewServiceLocator(moduleLocator3, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = Install(locator,
+		&testModule{name: "database"},
+		&testModule{name: "broken", requires: []string{"database"}, fail: true})
+	assert.NotNil(t, err)
+
+	_, err = locator.GetDService("databaseService")
+	assert.True(t, IsServiceNotFound(err), "no module is installed if one fails")
+
+	_, err = locator.GetDService("brokenService")
+	assert.True(t, IsServiceNotFound(err))
+
+	err = Install(locator,
+		&testModule{name: "database"},
+		&testModule{name: "misused", requires: []string{"database"}, misuse: true})
+	if assert.NotNil(t, err, "a module that panics in Configure should fail to install") {
+		assert.Contains(t, err.Error(), "could not install module misused")
+	}
+
+	_, err = locator.GetDService("databaseService")
+	assert.True(t, IsServiceNotFound(err), "no module is installed if one panics")
+
+	assert.Nil(t, Install(locator, &testModule{name: "database"}, &testModule{name: "broken"}))
+}
+
+func TestUninstall(t *testing.T) {
+	locator, err := NewServiceLocator(moduleLocator4, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = Install(locator,
+		&testModule{name: "database"},
+		&testModule{name: "cache", requires: []string{"database"}})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.NotNil(t, Uninstall(locator, "database"), "cache requires database")
+	assert.NotNil(t, Uninstall(locator, "nothing"))
+
+	err = Uninstall(locator, "cache")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("cacheService")
+	assert.True(t, IsServiceNotFound(err))
+
+	_, err = locator.GetDService("cacheOther", "Other")
+	assert.True(t, IsServiceNotFound(err))
+
+	_, err = locator.GetDService("databaseService")
+	assert.Nil(t, err)
+
+	assert.Nil(t, Uninstall(locator, "database"))
+
+	_, err = locator.GetDService("databaseService")
+	assert.True(t, IsServiceNotFound(err))
+}
+
+func TestConcurrentInstallAndUninstall(t *testing.T) {
+	locator, err := NewServiceLocator(moduleLocator5, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = Install(locator, &testModule{name: "base"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for lcv := 0; lcv < 10; lcv++ {
+		name := fmt.Sprintf("dependent%d", lcv)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			assert.Nil(t, Install(locator, &testModule{name: name, requires: []string{"base"}}))
+			assert.NotNil(t, Uninstall(locator, "base"), "%s requires base", name)
+		}()
+	}
+	wg.Wait()
+
+	installed, err := locator.GetDescriptors(&metadataFilter{key: ModuleMetadata, value: "base"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 2, len(installed), "base may not be uninstalled while modules require it")
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+// Package pluginloader installs the modules of Go plugins into a ServiceLocator.
+// It is kept apart from the ioc package since the plugin package needs cgo, so
+// that programs that do not load plugins can be built with CGO_ENABLED=0
+package pluginloader
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"plugin"
+)
+
+// PluginModuleSymbol is the name of the symbol LoadPlugin looks up in a plugin
+const PluginModuleSymbol = "DargoModule"
+
+// PluginModule is the module of services of a Go plugin.  A plugin built with
+// -buildmode=plugin exports a variable named DargoModule whose value implements
+// this interface, which LoadPlugin installs into a locator with ioc.Install.  The
+// services of the module have its name in their metadata under ioc.ModuleMetadata
+// and its version under ioc.ModuleVersionMetadata
+type PluginModule interface {
+	ioc.VersionedModule
+}
+
+// LoadPlugin opens the Go plugin at the path, looks up its DargoModule symbol
+// and installs the module into the locator with ioc.Install.  The modules it
+// requires must already be installed.  The module is returned so that it can
+// later be unloaded by its name
+func LoadPlugin(locator ioc.ServiceLocator, path string) (PluginModule, error) {
+	plug, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	symbol, err := plug.Lookup(PluginModuleSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	var module PluginModule
+	switch typed := symbol.(type) {
+	case *PluginModule:
+		module = *typed
+	case PluginModule:
+		module = typed
+	default:
+		return nil, fmt.Errorf("symbol %s of plugin %s is of type %T which is not a PluginModule",
+			PluginModuleSymbol, path, symbol)
+	}
+
+	err = ioc.Install(locator, module)
+	if err != nil {
+		return nil, err
+	}
+
+	return module, nil
+}
+
+// UnloadPlugin uninstalls the module with the given name with ioc.Uninstall.  Go
+// can not close a plugin, so the code of the plugin stays loaded, but a module
+// with the same name may be loaded again afterwards
+func UnloadPlugin(locator ioc.ServiceLocator, name string) error {
+	return ioc.Uninstall(locator, name)
+}
@@ -0,0 +1,178 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package pluginloader
+
+import (
+	"fmt"
+	"github.com/jwells131313/dargo/ioc"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	pluginLocator1 = "PluginLocator1"
+	pluginLocator2 = "PluginLocator2"
+	pluginLocator3 = "PluginLocator3"
+)
+
+type pluginGreeter struct {
+}
+
+type testPluginModule struct {
+	name, version string
+	fail          error
+}
+
+func (tpm *testPluginModule) Name() string {
+	return tpm.name
+}
+
+func (tpm *testPluginModule) Version() string {
+	return tpm.version
+}
+
+func (tpm *testPluginModule) Requires() []string {
+	return nil
+}
+
+func (tpm *testPluginModule) Configure(binder ioc.Binder) error {
+	binder.Bind("Greeter", pluginGreeter{}).WithMetadata("language", "en")
+	binder.BindConstant("Farewell", "goodbye").QualifiedBy("English")
+
+	return tpm.fail
+}
+
+func TestInstallAndUnloadPluginModule(t *testing.T) {
+	locator, err := ioc.CreateAndBind(pluginLocator1, func(binder ioc.Binder) error {
+		binder.BindConstant("Resident", "stays")
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	var module PluginModule = &testPluginModule{name: "greetings", version: "1.0.0"}
+	err = ioc.Install(locator, module)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	desc, err := locator.GetBestDescriptor(ioc.NewServiceKeyFilter(ioc.DSK("Greeter")))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	metadata := desc.GetMetadata()
+	assert.Equal(t, []string{"greetings"}, metadata[ioc.ModuleMetadata])
+	assert.Equal(t, []string{"1.0.0"}, metadata[ioc.ModuleVersionMetadata])
+	assert.Equal(t, []string{"en"}, metadata["language"])
+
+	desc, err = locator.GetBestDescriptor(ioc.NewServiceKeyFilter(ioc.DSK("Farewell", "English")))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"greetings"}, desc.GetMetadata()[ioc.ModuleMetadata])
+	}
+
+	err = UnloadPlugin(locator, "greetings")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Greeter")
+	assert.True(t, ioc.IsServiceNotFound(err))
+
+	_, err = locator.GetDService("Farewell", "English")
+	assert.True(t, ioc.IsServiceNotFound(err))
+
+	resident, err := locator.GetDService("Resident")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "stays", resident)
+	}
+
+	assert.NotNil(t, UnloadPlugin(locator, "greetings"))
+
+	// May be installed again once unloaded
+	assert.Nil(t, ioc.Install(locator, &testPluginModule{name: "greetings", version: "1.1.0"}))
+}
+
+func TestPluginModuleConflicts(t *testing.T) {
+	locator, err := ioc.NewServiceLocator(pluginLocator2, ioc.FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = ioc.Install(locator, &testPluginModule{name: "greetings", version: "1.0.0"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = ioc.Install(locator, &testPluginModule{name: "greetings", version: "2.0.0"})
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "version 2.0.0 conflicts with version 1.0.0")
+	}
+
+	all, err := locator.GetDescriptors(ioc.NewServiceKeyFilter(ioc.DSK("Greeter")))
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, len(all))
+	}
+}
+
+func TestPluginModuleInstallFailure(t *testing.T) {
+	locator, err := ioc.NewServiceLocator(pluginLocator3, ioc.FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = ioc.Install(locator, &testPluginModule{name: "broken", fail: fmt.Errorf("configure failed")})
+	assert.NotNil(t, err)
+
+	_, err = locator.GetDService("Greeter")
+	assert.True(t, ioc.IsServiceNotFound(err))
+
+	// The name is not taken by a module that failed to install
+	assert.Nil(t, ioc.Install(locator, &testPluginModule{name: "broken"}))
+
+	_, err = LoadPlugin(locator, filepath.Join(t.TempDir(), "missing.so"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,395 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Condition decides whether a service bound with Binder.When is available.
+// Conditions are evaluated whenever the services of the locator or of its
+// parents change or its active profiles change, while the locator is locked, so
+// they must not look up services of the locator themselves but should use the
+// information they are given.  A Condition that panics is false, and the panic
+// is given to the ErrorService as a ConditionFailure
+type Condition func(info ConditionInformation) bool
+
+// ConditionInformation is given to a Condition
+type ConditionInformation interface {
+	// GetDescriptor returns the descriptor of the service whose condition
+	// is being evaluated
+	GetDescriptor() Descriptor
+
+	// GetActiveProfiles returns the profiles active in the locator
+	GetActiveProfiles() []string
+
+	// IsProfileActive returns true if the profile is active in the locator
+	IsProfileActive(profile string) bool
+
+	// GetDescriptors returns the available services of the locator and its
+	// parents that match the filter, other than the service whose condition
+	// is being evaluated.  Services of the locator with conditions are only
+	// included if they were bound before this service and are available
+	GetDescriptors(filter Filter) []Descriptor
+}
+
+// LocatorOption is an option given to NewServiceLocator or NewChildServiceLocator.
+// Options only apply when the locator is created, not when an existing locator
+// is returned
+type LocatorOption func(*serviceLocatorData)
+
+// WithProfiles makes the given profiles the active profiles of a new locator,
+// in place of the profiles of the DARGO_PROFILES environment variable
+func WithProfiles(profiles ...string) LocatorOption {
+	return func(locator *serviceLocatorData) {
+		locator.profiles = newProfileSet(profiles)
+	}
+}
+
+// IfBound is a Condition that is true if a service with the key is available
+func IfBound(key ServiceKey) Condition {
+	return func(info ConditionInformation) bool {
+		return len(info.GetDescriptors(NewServiceKeyFilter(key))) > 0
+	}
+}
+
+// IfNotBound is a Condition that is true if no service with the key is available
+func IfNotBound(key ServiceKey) Condition {
+	return func(info ConditionInformation) bool {
+		return len(info.GetDescriptors(NewServiceKeyFilter(key))) == 0
+	}
+}
+
+// IfNoOtherBound is a Condition that is true if no other service is available
+// with the namespace and name of the service.  It is used to bind a default
+// implementation of a service that is only available when no other is bound
+func IfNoOtherBound() Condition {
+	return func(info ConditionInformation) bool {
+		desc := info.GetDescriptor()
+
+		return len(info.GetDescriptors(NewSingleFilter(desc.GetNamespace(), desc.GetName()))) == 0
+	}
+}
+
+// GetActiveProfiles returns the active profiles of the locator, sorted by name
+func GetActiveProfiles(locator ServiceLocator) ([]string, error) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("unknown service locator type")
+	}
+
+	iLocator.glock.ReadLock()
+	defer iLocator.glock.ReadUnlock()
+
+	return iLocator.profiles.names(), nil
+}
+
+// GetBoundDescriptors returns the descriptors bound into the locator, including
+// those that lookups do not find because none of their profiles is active or one
+// of their conditions failed.  The descriptors of parent locators are not returned
+func GetBoundDescriptors(locator ServiceLocator) ([]Descriptor, error) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("unknown service locator type")
+	}
+
+	iLocator.glock.ReadLock()
+	defer iLocator.glock.ReadUnlock()
+
+	return iLocator.descriptorData.getBound(), nil
+}
+
+// HasConditions returns true if the descriptor was bound with Binder.When
+func HasConditions(desc Descriptor) bool {
+	return len(getConditions(desc)) > 0
+}
+
+// SetActiveProfiles replaces the active profiles of the locator.  The services
+// bound with Binder.InProfile or Binder.When are evaluated again with the new
+// profiles and the ConfigurationListener services are told of the change.  Child
+// locators keep their own active profiles
+func SetActiveProfiles(locator ServiceLocator, profiles ...string) error {
+	dcs, err := getDCS(locator)
+	if err != nil {
+		return err
+	}
+
+	config, err := dcs.CreateDynamicConfiguration()
+	if err != nil {
+		return err
+	}
+
+	mod, ok := config.(*dynamicConfigModificationData)
+	if !ok {
+		return fmt.Errorf("unknown dynamic configuration type")
+	}
+
+	mod.profiles = newProfileSet(profiles)
+
+	return config.Commit()
+}
+
+type profileSet map[string]bool
+
+func newProfileSet(profiles []string) profileSet {
+	retVal := make(profileSet)
+	for _, profile := range profiles {
+		profile = strings.TrimSpace(profile)
+		if profile != "" {
+			retVal[profile] = true
+		}
+	}
+
+	return retVal
+}
+
+// environmentProfiles returns the profiles of the DARGO_PROFILES environment
+// variable, which is a comma separated list
+func environmentProfiles() profileSet {
+	return newProfileSet(strings.Split(os.Getenv(ProfilesEnvironmentVariable), ","))
+}
+
+func (ps profileSet) names() []string {
+	retVal := make([]string, 0, len(ps))
+	for profile := range ps {
+		retVal = append(retVal, profile)
+	}
+
+	sort.Strings(retVal)
+
+	return retVal
+}
+
+// inProfile returns true if the descriptor has no profiles or has at least
+// one of the active profiles
+func (ps profileSet) inProfile(desc Descriptor) bool {
+	profiles := desc.GetMetadata()[ProfileMetadata]
+	if len(profiles) == 0 {
+		return true
+	}
+
+	for _, profile := range profiles {
+		if ps[profile] {
+			return true
+		}
+	}
+
+	return false
+}
+
+type conditionInformationData struct {
+	locator  *serviceLocatorData
+	cache    *nameCache
+	desc     Descriptor
+	profiles profileSet
+}
+
+func (cid *conditionInformationData) GetDescriptor() Descriptor {
+	return cid.desc
+}
+
+func (cid *conditionInformationData) GetActiveProfiles() []string {
+	return cid.profiles.names()
+}
+
+func (cid *conditionInformationData) IsProfileActive(profile string) bool {
+	return cid.profiles[profile]
+}
+
+func (cid *conditionInformationData) GetDescriptors(filter Filter) []Descriptor {
+	retVal := make([]Descriptor, 0)
+	for _, desc := range cid.cache.lookup(filter) {
+		if desc.GetServiceID() == cid.desc.GetServiceID() && desc.GetLocatorID() == cid.desc.GetLocatorID() {
+			continue
+		}
+
+		retVal = append(retVal, desc)
+	}
+
+	for ancestor := cid.locator.parent; ancestor != nil; ancestor = ancestor.parent {
+		ancestor.glock.ReadLock()
+		fromAncestor := ancestor.descriptorData.lookup(filter)
+		ancestor.glock.ReadUnlock()
+
+		for _, desc := range fromAncestor {
+			if desc.GetVisibility() != LocalVisibility {
+				retVal = append(retVal, desc)
+			}
+		}
+	}
+
+	return retVal
+}
+
+// activate returns the name cache of the bound descriptors.  Descriptors not in
+// an active profile are inactive.  The conditions of the remaining descriptors are
+// then evaluated in the order they were bound, each seeing the descriptors without
+// conditions and those with conditions bound before it that are active.  Must be
+// called with the write lock held
+// conditionFailure is a Condition that panicked while being evaluated by activate
+type conditionFailure struct {
+	desc Descriptor
+	err  error
+}
+
+// activate returns the cache of the bound services along with the conditions that
+// panicked, which are given to the error services once the update has succeeded
+func (locator *serviceLocatorData) activate(bound []Descriptor, profiles profileSet) (*nameCache, []conditionFailure) {
+	retVal := newNameCache()
+	failures := make([]conditionFailure, 0)
+
+	conditional := make([]Descriptor, 0)
+	for _, desc := range bound {
+		if !profiles.inProfile(desc) {
+			retVal.addInactive(desc)
+			continue
+		}
+
+		if len(getConditions(desc)) == 0 {
+			retVal.add(desc)
+			continue
+		}
+
+		conditional = append(conditional, desc)
+	}
+
+	sort.SliceStable(conditional, func(i, j int) bool {
+		return conditional[i].GetServiceID() < conditional[j].GetServiceID()
+	})
+
+	for _, desc := range conditional {
+		info := &conditionInformationData{
+			locator:  locator,
+			cache:    retVal,
+			desc:     desc,
+			profiles: profiles,
+		}
+
+		passed := true
+		for _, condition := range getConditions(desc) {
+			ok, err := safeCondition(condition, info)
+			if err != nil {
+				failures = append(failures, conditionFailure{desc: desc, err: err})
+			}
+
+			if !ok {
+				passed = false
+				break
+			}
+		}
+
+		if passed {
+			retVal.add(desc)
+		} else {
+			retVal.addInactive(desc)
+		}
+	}
+
+	return retVal, failures
+}
+
+func getConditions(desc Descriptor) []Condition {
+	conditional, ok := desc.(conditionInformation)
+	if !ok {
+		return nil
+	}
+
+	return conditional.getConditions()
+}
+
+// safeCondition returns false and the error if the condition panics
+func safeCondition(condition Condition, info ConditionInformation) (retVal bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			retVal = false
+			err = fmt.Errorf("condition panicked: %v", r)
+		}
+	}()
+
+	return condition(info), nil
+}
+
+// reevaluateChildren evaluates the conditions of the child locators again after
+// this locator has changed, since their conditions also see the services of this
+// locator.  The children of the children are evaluated again by the commit of the child
+func (locator *serviceLocatorData) reevaluateChildren() {
+	locatorsLock.Lock()
+	children := make([]*serviceLocatorData, 0, len(locator.children))
+	for _, child := range locator.children {
+		children = append(children, child)
+	}
+	locatorsLock.Unlock()
+
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].ID < children[j].ID
+	})
+
+	for _, child := range children {
+		if child.getState() != LocatorStateRunning {
+			continue
+		}
+
+		if !child.hasConditions() {
+			child.reevaluateChildren()
+			continue
+		}
+
+		// A failed commit has already been given to the error services of the child
+		newModifier(child).Commit()
+	}
+}
+
+// hasConditions returns true if any service bound into this locator has conditions
+func (locator *serviceLocatorData) hasConditions() bool {
+	locator.glock.ReadLock()
+	defer locator.glock.ReadUnlock()
+
+	for _, desc := range locator.descriptorData.getBound() {
+		if len(getConditions(desc)) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
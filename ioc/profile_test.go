@@ -0,0 +1,362 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	profileLocator1 = "ProfileLocator1"
+	profileLocator2 = "ProfileLocator2"
+	profileLocator3 = "ProfileLocator3"
+	profileLocator4 = "ProfileLocator4"
+	profileLocator5 = "ProfileLocator5"
+	profileLocator6 = "ProfileLocator6"
+	profileLocator7 = "ProfileLocator7"
+)
+
+type countingConfigurationListener struct {
+	changes int32
+}
+
+func (ccl *countingConfigurationListener) ConfigurationChanged() {
+	atomic.AddInt32(&ccl.changes, 1)
+}
+
+func bindStores(binder Binder) error {
+	binder.BindConstant("Store", "postgres").InProfile("prod")
+	binder.BindConstant("Store", "memory").InProfile("dev", "test")
+	binder.BindConstant("Clock", "system")
+
+	return nil
+}
+
+func TestProfilesSelectServices(t *testing.T) {
+	locator, err := NewServiceLocator(profileLocator1, FailIfPresent, WithProfiles("prod"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	listener := &countingConfigurationListener{}
+
+	err = BindIntoLocator(locator, func(binder Binder) error {
+		binder.BindConstant(ConfigurationListenerName, listener).InNamespace(UserServicesNamespace).
+			InScope(Singleton)
+		return bindStores(binder)
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	store, err := locator.GetDService("Store")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "postgres", store)
+	}
+
+	all, err := locator.GetAllServices(DSK("Store"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, len(all))
+	}
+
+	profiles, err := GetActiveProfiles(locator)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"prod"}, profiles)
+	}
+
+	before := atomic.LoadInt32(&listener.changes)
+
+	err = SetActiveProfiles(locator, "test")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, before+1, atomic.LoadInt32(&listener.changes))
+
+	store, err = locator.GetDService("Store")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "memory", store)
+	}
+
+	err = SetActiveProfiles(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Store")
+	assert.True(t, IsServiceNotFound(err))
+
+	clock, err := locator.GetDService("Clock")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "system", clock)
+	}
+
+	// Inactive services may still be unbound
+	err = UnbindDServices(locator, "Store")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = SetActiveProfiles(locator, "prod", "dev")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Store")
+	assert.True(t, IsServiceNotFound(err))
+}
+
+func TestProfilesFromEnvironment(t *testing.T) {
+	t.Setenv(ProfilesEnvironmentVariable, " dev , extra,")
+
+	locator, err := CreateAndBind(profileLocator2, bindStores)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	profiles, err := GetActiveProfiles(locator)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"dev", "extra"}, profiles)
+	}
+
+	store, err := locator.GetDService("Store")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "memory", store)
+	}
+
+	// WithProfiles replaces the environment
+	child, err := NewChildServiceLocator(profileLocator3, FailIfPresent, locator, WithProfiles("prod"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	profiles, err = GetActiveProfiles(child)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"prod"}, profiles)
+	}
+}
+
+func TestDefaultImplementationCondition(t *testing.T) {
+	locator, err := CreateAndBind(profileLocator4, func(binder Binder) error {
+		binder.BindConstant("Mailer", "default").When(IfNoOtherBound())
+		binder.BindConstant("Audit", "audit").When(IfBound(DSK("Mailer")))
+		binder.BindConstant("Fallback", "fallback").When(IfNotBound(DSK("Mailer")))
+		binder.BindConstant("Broken", "broken").When(func(ConditionInformation) bool {
+			panic("condition failure")
+		})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	mailer, err := locator.GetDService("Mailer")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "default", mailer)
+	}
+
+	_, err = locator.GetDService("Audit")
+	assert.Nil(t, err)
+
+	_, err = locator.GetDService("Fallback")
+	assert.True(t, IsServiceNotFound(err))
+
+	_, err = locator.GetDService("Broken")
+	assert.True(t, IsServiceNotFound(err))
+
+	err = BindIntoLocator(locator, func(binder Binder) error {
+		binder.BindConstant("Mailer", "smtp")
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	all, err := locator.GetAllServices(DSK("Mailer"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, []interface{}{"smtp"}, all)
+	}
+
+	err = UnbindServices(locator, DSK("Mailer"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// Unbinding the name unbinds the default as well
+	_, err = locator.GetDService("Mailer")
+	assert.True(t, IsServiceNotFound(err))
+
+	_, err = locator.GetDService("Fallback")
+	assert.Nil(t, err)
+}
+
+func TestConditionsSeeParentAndProfiles(t *testing.T) {
+	parent, err := CreateAndBind(profileLocator5, func(binder Binder) error {
+		binder.BindConstant("Mailer", "smtp")
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(profileLocator5+"Child", FailIfPresent, parent, WithProfiles("prod"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(child, func(binder Binder) error {
+		binder.BindConstant("Mailer", "default").When(IfNoOtherBound())
+		binder.BindConstant("Metrics", "metrics").When(func(info ConditionInformation) bool {
+			return info.IsProfileActive("prod")
+		})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	mailer, err := child.GetDService("Mailer")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "smtp", mailer)
+	}
+
+	_, err = child.GetDService("Metrics")
+	assert.Nil(t, err)
+
+	err = SetActiveProfiles(child, "dev")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = child.GetDService("Metrics")
+	assert.True(t, IsServiceNotFound(err))
+}
+
+func TestChildConditionsFollowParentChanges(t *testing.T) {
+	parent, err := NewServiceLocator(profileLocator6, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(profileLocator6+"Child", FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	grandchild, err := NewChildServiceLocator(profileLocator6+"Grandchild", FailIfPresent, child)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(grandchild, func(binder Binder) error {
+		binder.BindConstant("Mailer", "default").When(IfNoOtherBound())
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	mailer, err := grandchild.GetDService("Mailer")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "default", mailer)
+	}
+
+	err = BindIntoLocator(parent, func(binder Binder) error {
+		binder.BindConstant("Mailer", "smtp")
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	mailer, err = grandchild.GetDService("Mailer")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "smtp", mailer)
+	}
+
+	mailers, err := grandchild.GetAllServices(DSK("Mailer"))
+	if assert.Nil(t, err) {
+		assert.Equal(t, 1, len(mailers))
+	}
+
+	err = UnbindDServices(parent, "Mailer")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	mailer, err = grandchild.GetDService("Mailer")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "default", mailer)
+	}
+}
+
+func TestConditionPanicReported(t *testing.T) {
+	errorService := &recordingErrorService{}
+
+	locator, err := CreateAndBind(profileLocator7, func(binder Binder) error {
+		binder.BindConstant(ErrorServiceName, errorService).InNamespace(UserServicesNamespace)
+		binder.BindConstant("Metrics", "metrics").When(func(info ConditionInformation) bool {
+			panic("condition failure")
+		})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("Metrics")
+	assert.True(t, IsServiceNotFound(err))
+
+	failures := errorService.getErrors()
+	if assert.Equal(t, 1, len(failures)) {
+		assert.Equal(t, ConditionFailure, failures[0].GetType())
+		assert.Equal(t, "Metrics", failures[0].GetDescriptor().GetName())
+		assert.Contains(t, failures[0].GetAssociatedError().Error(), "condition failure")
+	}
+}
@@ -0,0 +1,321 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// ProxyHandler is given to a proxy factory when a proxy is injected.  The
+// proxy calls Get or GetFor in every method to find the service that the
+// call should be forwarded to, which may be a different service each time
+// for services in scopes such as the ContextScope
+type ProxyHandler interface {
+	// GetDescriptor returns the descriptor of the service that was
+	// found when the proxy was injected
+	GetDescriptor() Descriptor
+
+	// Get looks up the service using the dargo context of the current
+	// goroutine, which is only set while a service is being looked up
+	// or created with a dargo context.  When there is no such context
+	// the context given by the ContextResolver services of the locator
+	// is used
+	Get() (interface{}, error)
+
+	// GetFor looks up the service using the dargo context of the given
+	// context.Context, which must be a context returned from NewDargoContext
+	// or a context derived from one
+	GetFor(ctx context.Context) (interface{}, error)
+}
+
+// ProxyFactory creates a proxy that forwards the methods of an interface
+// to the service returned by the handler
+type ProxyFactory func(handler ProxyHandler) (interface{}, error)
+
+// ContextResolver is a service that must be in namespace ioc.UserServicesNamespace and
+// have name ioc.ContextResolverName.  ProxyHandler.Get asks it for the context.Context
+// to use when it is called outside of a lookup done with a dargo context, such as from
+// a goroutine of the application that is handling a request
+type ContextResolver interface {
+	// CurrentContext returns the context.Context of the work being done by the caller,
+	// which must be a context returned from NewDargoContext or a context derived from
+	// one.  It returns false if the caller has no such context
+	CurrentContext() (context.Context, bool)
+}
+
+// RegisterProxyFactory registers the factory that creates proxies for the given
+// interface type in the given locator and its children.  A field or constructor
+// parameter of that type whose inject tag has the proxy option is injected with
+// a proxy rather than the service itself.  A proxy is also injected without the
+// proxy option when the service is in a scope whose ContextualScope descriptor has
+// the ProxiableMetadata and the service is being injected into a Singleton or
+// Immediate service.  Registering a factory for a type that already has one in the
+// locator replaces the previous factory
+func RegisterProxyFactory(locator ServiceLocator, ty reflect.Type, factory ProxyFactory) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+	if ty == nil || ty.Kind() != reflect.Interface {
+		return fmt.Errorf("proxies may only be registered for interface types, not %v", ty)
+	}
+	if factory == nil {
+		return fmt.Errorf("the proxy factory for %v may not be nil", ty)
+	}
+
+	iLocator.proxyFactories.Store(ty, factory)
+
+	return nil
+}
+
+// RegisterProxy is the type-safe version of RegisterProxyFactory, where the
+// factory returns a T that forwards its methods to the service of the handler
+func RegisterProxy[T any](locator ServiceLocator, factory func(handler ProxyHandler) T) error {
+	if factory == nil {
+		return fmt.Errorf("the proxy factory may not be nil")
+	}
+
+	return RegisterProxyFactory(locator, reflect.TypeOf((*T)(nil)).Elem(), func(handler ProxyHandler) (interface{}, error) {
+		return factory(handler), nil
+	})
+}
+
+// getProxyFactory returns the factory registered for the type in this
+// locator or the closest of its parents
+func (locator *serviceLocatorData) getProxyFactory(ty reflect.Type) ProxyFactory {
+	for current := locator; current != nil; current = current.parent {
+		raw, found := current.proxyFactories.Load(ty)
+		if found {
+			return raw.(ProxyFactory)
+		}
+	}
+
+	return nil
+}
+
+// resolveContext returns the context given by the first ContextResolver of
+// the locator that has one
+func (locator *serviceLocatorData) resolveContext() (context.Context, bool, error) {
+	raws, err := locator.GetAllServices(USK(ContextResolverName))
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, raw := range raws {
+		resolver, ok := raw.(ContextResolver)
+		if !ok {
+			return nil, false, fmt.Errorf("a service %v with context resolver key does not implement ContextResolver", raw)
+		}
+
+		ret := &errorReturn{}
+		ctx, found := safeCurrentContext(resolver, ret)
+		if ret.err != nil {
+			return nil, false, ret.err
+		}
+
+		if found && ctx != nil {
+			return ctx, true, nil
+		}
+	}
+
+	return nil, false, nil
+}
+
+type proxyHandlerData struct {
+	locator *serviceLocatorData
+	desc    Descriptor
+	typ     reflect.Type
+	pd      *parseData
+	forMe   Descriptor
+}
+
+func (handler *proxyHandlerData) GetDescriptor() Descriptor {
+	return handler.desc
+}
+
+func (handler *proxyHandlerData) Get() (interface{}, error) {
+	_, found := CurrentDargoContext()
+	if !found {
+		ctx, resolved, err := handler.locator.resolveContext()
+		if err != nil {
+			return nil, err
+		}
+
+		if resolved {
+			return handler.GetFor(ctx)
+		}
+	}
+
+	return handler.lookup()
+}
+
+// lookup finds the service with whatever dargo context the current goroutine has
+func (handler *proxyHandlerData) lookup() (interface{}, error) {
+	if handler.pd.byType {
+		return handler.locator.getServiceByTypeFor(handler.typ, handler.pd.qualifiers, handler.forMe)
+	}
+
+	return handler.locator.getServiceFor(handler.pd.serviceKey, handler.forMe)
+}
+
+func (handler *proxyHandlerData) GetFor(ctx context.Context) (interface{}, error) {
+	if ctx == nil {
+		return nil, fmt.Errorf("a context is required to find the service of proxy %v", handler.desc)
+	}
+
+	dgo, ok := ctx.Value(dargoContextKey{}).(*dargoContext)
+	if !ok {
+		return nil, fmt.Errorf("the context given to proxy %v was not created with NewDargoContext", handler.desc)
+	}
+
+	return dgo.call(handler.lookup)
+}
+
+// getProxyFor returns the proxy to inject into an injection point of forMe,
+// or nil if the injection point should not be proxied
+func (locator *serviceLocatorData) getProxyFor(ty reflect.Type, pd *parseData, forMe Descriptor) (*reflect.Value, error) {
+	if pd.all || isProvider(ty) || isTypedProvider(ty) {
+		if pd.proxy {
+			return nil, fmt.Errorf("a Provider or a collection of services can not be proxied")
+		}
+
+		return nil, nil
+	}
+
+	factory := locator.getProxyFactory(ty)
+	if factory == nil {
+		if pd.proxy {
+			return nil, fmt.Errorf("no proxy factory is registered for type %v", ty)
+		}
+
+		return nil, nil
+	}
+
+	if !pd.proxy && (forMe == nil || !isWideScope(forMe.GetScope())) {
+		return nil, nil
+	}
+
+	var filter Filter
+	if pd.byType {
+		filter = NewTypeFilter(ty, pd.qualifiers...)
+	} else {
+		key := pd.serviceKey
+		filter = NewSingleFilter(key.GetNamespace(), key.GetName(), key.GetQualifiers()...)
+	}
+
+	desc, err := locator.getBestDescriptorFor(filter, forMe)
+	if err != nil || desc == nil {
+		// Errors such as the service not being found are left to the normal lookup
+		return nil, err
+	}
+
+	if !pd.proxy && !locator.isProxiable(desc) {
+		return nil, nil
+	}
+
+	locator.recordDependency(forMe, desc)
+
+	ret := &errorReturn{}
+	proxy := safeProxyFactory(factory, &proxyHandlerData{
+		locator: locator,
+		desc:    desc,
+		typ:     ty,
+		pd:      pd,
+		forMe:   forMe,
+	}, ret)
+	if ret.err != nil {
+		return nil, ret.err
+	}
+
+	if proxy == nil {
+		return nil, fmt.Errorf("the proxy factory for %v returned nil", ty)
+	}
+
+	retVal := reflect.ValueOf(proxy)
+	if !retVal.Type().AssignableTo(ty) {
+		return nil, fmt.Errorf("the proxy factory for %v returned a proxy of type %v", ty, retVal.Type())
+	}
+
+	return &retVal, nil
+}
+
+// isProxiable returns true if the ContextualScope of the descriptor is
+// bound with the ProxiableMetadata
+func (locator *serviceLocatorData) isProxiable(desc Descriptor) bool {
+	scopeDesc, err := locator.getOwner(desc).GetBestDescriptor(
+		NewSingleFilter(ContextualScopeNamespace, desc.GetScope()))
+	if err != nil || scopeDesc == nil {
+		return false
+	}
+
+	for _, value := range scopeDesc.GetMetadata()[ProxiableMetadata] {
+		if value == "true" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func safeCurrentContext(resolver ContextResolver, ret *errorReturn) (context.Context, bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	return resolver.CurrentContext()
+}
+
+func safeProxyFactory(factory ProxyFactory, handler ProxyHandler, ret *errorReturn) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	proxy, err := factory(handler)
+	ret.err = err
+
+	return proxy
+}
@@ -0,0 +1,303 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"sync/atomic"
+	"testing"
+)
+
+const (
+	proxyLocator1 = "ProxyLocator1"
+	proxyLocator2 = "ProxyLocator2"
+	proxyLocator3 = "ProxyLocator3"
+
+	requestInfoName = "RequestInfo"
+)
+
+var requestGeneration int32
+
+type requestInfo interface {
+	RequestID(ctx context.Context) int32
+}
+
+type requestInfoData struct {
+	id int32
+}
+
+func (rid *requestInfoData) DargoInitialize(Descriptor) error {
+	rid.id = atomic.AddInt32(&requestGeneration, 1)
+	return nil
+}
+
+func (rid *requestInfoData) RequestID(ctx context.Context) int32 {
+	return rid.id
+}
+
+type requestInfoProxy struct {
+	handler ProxyHandler
+}
+
+func (rip *requestInfoProxy) RequestID(ctx context.Context) int32 {
+	raw, err := rip.handler.GetFor(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	return raw.(requestInfo).RequestID(ctx)
+}
+
+type requestSingleton struct {
+	Info     requestInfo `inject:"RequestInfo"`
+	Explicit requestInfo `inject:",proxy"`
+}
+
+type unproxied interface {
+	Unproxied()
+}
+
+type unproxiedData struct {
+}
+
+func (ud *unproxiedData) Unproxied() {
+}
+
+type unproxiedSingleton struct {
+	U unproxied `inject:"Unproxied,proxy"`
+}
+
+// requestInfoGetProxy finds the service with ProxyHandler.Get rather than
+// with the context given to its methods
+type requestInfoGetProxy struct {
+	handler ProxyHandler
+}
+
+func (rigp *requestInfoGetProxy) RequestID(ctx context.Context) int32 {
+	raw, err := rigp.handler.Get()
+	if err != nil {
+		panic(err)
+	}
+
+	return raw.(requestInfo).RequestID(ctx)
+}
+
+type currentContextResolver struct {
+	current context.Context
+}
+
+func (ccr *currentContextResolver) CurrentContext() (context.Context, bool) {
+	return ccr.current, ccr.current != nil
+}
+
+func TestProxyInjection(t *testing.T) {
+	locator, err := CreateAndBind(proxyLocator1, func(binder Binder) error {
+		binder.Bind(requestInfoName, &requestInfoData{}).InScope(ContextScope)
+		binder.Bind("Singleton", &requestSingleton{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = RegisterProxy[requestInfo](locator, func(handler ProxyHandler) requestInfo {
+		return &requestInfoProxy{
+			handler: handler,
+		}
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableDargoContextScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Nil(t, Verify(locator), "proxied injection points are not scope widening")
+
+	singleton, err := GetD[*requestSingleton](locator, "Singleton")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, isProxy := singleton.Info.(*requestInfoProxy)
+	assert.True(t, isProxy, "ContextScope is proxiable so the singleton should have a proxy")
+	_, isProxy = singleton.Explicit.(*requestInfoProxy)
+	assert.True(t, isProxy, "proxy option should inject a proxy")
+
+	parent1, cancel1 := context.WithCancel(context.Background())
+	defer cancel1()
+	parent2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	ctx1, err := NewDargoContext(parent1, locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+	ctx2, err := NewDargoContext(parent2, locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	id1 := singleton.Info.RequestID(ctx1)
+	id2 := singleton.Info.RequestID(ctx2)
+
+	assert.NotEqual(t, id1, id2, "each context should have its own service")
+	assert.Equal(t, id1, singleton.Info.RequestID(ctx1))
+	assert.Equal(t, id1, singleton.Explicit.RequestID(ctx1))
+	assert.Equal(t, id2, singleton.Explicit.RequestID(ctx2))
+
+	type derivedKey struct{}
+	derived := context.WithValue(ctx1, derivedKey{}, "value")
+	assert.Equal(t, id1, singleton.Info.RequestID(derived), "derived contexts find their dargo context")
+
+	assert.Panics(t, func() {
+		singleton.Info.RequestID(context.Background())
+	})
+}
+
+func TestProxyErrors(t *testing.T) {
+	locator, err := CreateAndBind(proxyLocator2, func(binder Binder) error {
+		binder.Bind("Unproxied", &unproxiedData{})
+		binder.Bind("UnproxiedSingleton", &unproxiedSingleton{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("UnproxiedSingleton")
+	assert.NotNil(t, err, "there is no proxy factory for unproxied")
+
+	verr := Verify(locator)
+	if assert.NotNil(t, verr) {
+		info, ok := verr.(MultiError).GetErrors()[0].(VerificationInfo)
+		if assert.True(t, ok) {
+			assert.Equal(t, VerificationInvalidInjectionPoint, info.GetVerificationType())
+		}
+	}
+
+	err = RegisterProxyFactory(locator, reflect.TypeOf(&unproxiedData{}), func(ProxyHandler) (interface{}, error) {
+		return nil, nil
+	})
+	assert.NotNil(t, err, "only interfaces may be proxied")
+
+	_, err = parseInjectString("Foo,all,proxy")
+	assert.NotNil(t, err)
+}
+
+func TestProxyContextResolver(t *testing.T) {
+	resolver := &currentContextResolver{}
+
+	locator, err := CreateAndBind(proxyLocator3, func(binder Binder) error {
+		binder.Bind(requestInfoName, &requestInfoData{}).InScope(ContextScope)
+		binder.Bind("Singleton", &requestSingleton{})
+		binder.BindConstant(ContextResolverName, resolver).InNamespace(UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = RegisterProxy[requestInfo](locator, func(handler ProxyHandler) requestInfo {
+		return &requestInfoGetProxy{
+			handler: handler,
+		}
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableDargoContextScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	singleton, err := GetD[*requestSingleton](locator, "Singleton")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	parent, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ctx, err := NewDargoContext(parent, locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	expected, err := GetInContext[requestInfo](ctx, DSK(requestInfoName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Panics(t, func() {
+		singleton.Info.RequestID(nil)
+	}, "there is no dargo context to find the service with")
+
+	resolver.current = ctx
+	assert.Equal(t, expected.RequestID(ctx), singleton.Info.RequestID(nil),
+		"the context of the ContextResolver should be used outside of a lookup")
+
+	other, err := NewChildServiceLocator(proxyLocator3+"Child", FailIfPresent, locator)
+	if assert.Nil(t, err) {
+		defer other.Shutdown()
+
+		iOther := other.(*serviceLocatorData)
+		assert.NotNil(t, iOther.getProxyFactory(reflect.TypeOf((*requestInfo)(nil)).Elem()),
+			"children use the proxy factories of their parents")
+	}
+
+	unrelated, err := NewServiceLocator(proxyLocator3+"Unrelated", FailIfPresent)
+	if assert.Nil(t, err) {
+		defer unrelated.Shutdown()
+
+		iUnrelated := unrelated.(*serviceLocatorData)
+		assert.Nil(t, iUnrelated.getProxyFactory(reflect.TypeOf((*requestInfo)(nil)).Elem()),
+			"proxy factories are registered per locator")
+	}
+}
@@ -0,0 +1,468 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/jwells131313/goethe/cache"
+	"github.com/pkg/errors"
+	"strconv"
+	"sync"
+)
+
+// RunLevelController starts and stops the services of the RunLevelScope one
+// level at a time.  It is bound into the system namespace by EnableRunLevelScope
+// and so may be injected with `inject:"system#RunLevelController"`
+type RunLevelController interface {
+	// ProceedTo starts or stops services until the given level is reached.  When
+	// going up all of the services of each level are started in parallel and the
+	// next level is not begun until they have all been started.  If a service of a
+	// level fails to start the services of that level are stopped again and the
+	// error is returned.  When going down the services of each level are stopped,
+	// services before the services they depend on, starting with the highest level.
+	// If the context is done before the level is reached ProceedTo stops at the last
+	// completed level and returns an error wrapping the error of the context
+	ProceedTo(ctx context.Context, level int) error
+
+	// ProceedToAsync is like ProceedTo but returns immediately.  The result of
+	// ProceedTo is sent on the returned channel, which is then closed
+	ProceedToAsync(ctx context.Context, level int) <-chan error
+
+	// GetCurrentLevel returns the last level that was completely reached, which
+	// is RunLevelNone if no level has been reached
+	GetCurrentLevel() int
+}
+
+// RunLevelListener is a service that must be in namespace ioc.UserServicesNamespace
+// and have name ioc.RunLevelListenerName.  All RunLevelListeners are told of the
+// progress of the RunLevelController.  A panic from a RunLevelListener is given to
+// the ErrorService as a ListenerFailure
+type RunLevelListener interface {
+	// OnProgress is called every time the RunLevelController reaches a
+	// level, whether going up or going down
+	OnProgress(level int)
+
+	// OnError is called when services of the given level failed to
+	// start.  The RunLevelController goes back to the level below it
+	OnError(level int, err error)
+
+	// OnCancelled is called when the context given to the RunLevelController
+	// is done before the level being proceeded to was reached.  The level is
+	// the level the RunLevelController stopped at
+	OnCancelled(level int)
+}
+
+// runLevelScopeData is the implementation of ContextualScope for RunLevelScope
+type runLevelScopeData struct {
+	Locator ServiceLocator `inject:"system#ServiceLocator"`
+	cache   cache.Cache
+
+	lock sync.Mutex
+	// current is the last level completely reached
+	current int
+	// allowed is the highest level services may currently be created at,
+	// which is one higher than current while a level is being started
+	allowed int
+}
+
+func (rls *runLevelScopeData) DargoInitialize(desc Descriptor) error {
+	c, err := cache.NewCache(rls, func(in interface{}) error {
+		return fmt.Errorf("cycle detected in run level scope involving %v", in)
+	})
+	if err != nil {
+		return err
+	}
+
+	rls.cache = c
+	rls.current = RunLevelNone
+	rls.allowed = RunLevelNone
+
+	return nil
+}
+
+func (rls *runLevelScopeData) GetScope() string {
+	return RunLevelScope
+}
+
+func (rls *runLevelScopeData) FindOrCreate(locator ServiceLocator, desc Descriptor) (interface{}, error) {
+	level, err := getRunLevel(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	rls.lock.Lock()
+	allowed := rls.allowed
+	rls.lock.Unlock()
+
+	if level > allowed {
+		return nil, fmt.Errorf("service %s is at run level %d but the RunLevelController is at level %d",
+			desc.GetFullName(), level, allowed)
+	}
+
+	return rls.cache.Compute(idKey{desc: desc})
+}
+
+func (rls *runLevelScopeData) ContainsKey(locator ServiceLocator, desc Descriptor) bool {
+	return rls.cache.HasKey(idKey{desc: desc})
+}
+
+func (rls *runLevelScopeData) DestroyOne(locator ServiceLocator, desc Descriptor) error {
+	return destroyOne(rls.Locator, rls.cache, desc)
+}
+
+func (rls *runLevelScopeData) GetSupportsNilCreation(locator ServiceLocator) bool {
+	return false
+}
+
+func (rls *runLevelScopeData) IsActive(locator ServiceLocator) bool {
+	return true
+}
+
+func (rls *runLevelScopeData) Shutdown(locator ServiceLocator) {
+	rls.ShutdownContext(context.Background(), locator)
+}
+
+// ShutdownContext stops every level, highest level first
+func (rls *runLevelScopeData) ShutdownContext(ctx context.Context, locator ServiceLocator) error {
+	errs := NewMultiError()
+
+	for level := rls.getCurrentLevel(); level > RunLevelNone; level-- {
+		err := rls.stopLevel(ctx, level)
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
+
+	return errs.GetFinalError()
+}
+
+func (rls *runLevelScopeData) Compute(in interface{}) (interface{}, error) {
+	key, ok := in.(idKey)
+	if !ok {
+		return nil, fmt.Errorf("incoming key not the expected type %v", in)
+	}
+
+	return rls.Locator.CreateServiceFromDescriptor(key.desc)
+}
+
+func (rls *runLevelScopeData) getCurrentLevel() int {
+	rls.lock.Lock()
+	defer rls.lock.Unlock()
+
+	return rls.current
+}
+
+func (rls *runLevelScopeData) setLevels(current, allowed int) {
+	rls.lock.Lock()
+	defer rls.lock.Unlock()
+
+	rls.current = current
+	rls.allowed = allowed
+}
+
+// startLevel creates all of the services of the level in parallel.  If any fail
+// the level is not reached and the errors are returned in a MultiError.  Services
+// not yet started when the context is done are not started
+func (rls *runLevelScopeData) startLevel(ctx context.Context, level int) error {
+	descs, err := rls.Locator.GetDescriptors(&runLevelFilter{level: level})
+	if err != nil {
+		return err
+	}
+
+	rls.setLevels(level-1, level)
+
+	errs := NewMultiError()
+	var wg sync.WaitGroup
+
+	for _, desc := range descs {
+		if ctx.Err() != nil {
+			errs.AddError(errors.Wrapf(ctx.Err(), "run level %d was not started", level))
+			break
+		}
+
+		localDesc := desc
+
+		wg.Add(1)
+		_, err = threadManager.Go(func() {
+			defer wg.Done()
+
+			if ctx.Err() != nil {
+				errs.AddError(errors.Wrapf(ctx.Err(), "%s at run level %d was not started", localDesc.GetFullName(), level))
+				return
+			}
+
+			_, err := rls.Locator.GetServiceFromDescriptor(localDesc)
+			if err != nil {
+				errs.AddError(errors.Wrapf(err, "could not start %s at run level %d", localDesc.GetFullName(), level))
+			}
+		})
+		if err != nil {
+			wg.Done()
+			errs.AddError(err)
+		}
+	}
+
+	wg.Wait()
+
+	if errs.HasError() {
+		return errs
+	}
+
+	rls.setLevels(level, level)
+
+	return nil
+}
+
+// stopLevel destroys all of the services of the level, which is then no longer reached
+func (rls *runLevelScopeData) stopLevel(ctx context.Context, level int) error {
+	rls.setLevels(level-1, level-1)
+
+	values := make(map[Descriptor]interface{})
+	rls.cache.Remove(func(key interface{}, value interface{}) bool {
+		idKey, ok := key.(idKey)
+		if !ok {
+			return false
+		}
+
+		descLevel, err := getRunLevel(idKey.desc)
+		if err != nil || descLevel < level {
+			return false
+		}
+
+		values[idKey.desc] = value
+
+		return true
+	})
+
+	return DestroyServices(ctx, rls.Locator, values)
+}
+
+type runLevelControllerData struct {
+	Locator ServiceLocator  `inject:"system#ServiceLocator"`
+	Scope   ContextualScope `inject:"sys/scope#RunLevelScope"`
+
+	// lock makes sure only one ProceedTo is running at a time
+	lock sync.Mutex
+}
+
+func (rlc *runLevelControllerData) ProceedTo(ctx context.Context, level int) error {
+	if level < RunLevelNone {
+		return fmt.Errorf("the run level %d may not be lower than %d", level, RunLevelNone)
+	}
+
+	scope, ok := rlc.Scope.(*runLevelScopeData)
+	if !ok {
+		return fmt.Errorf("unknown type of RunLevelScope %T", rlc.Scope)
+	}
+
+	rlc.lock.Lock()
+	defer rlc.lock.Unlock()
+
+	listeners := rlc.getListeners()
+
+	for current := scope.getCurrentLevel(); current < level; current = scope.getCurrentLevel() {
+		if ctx.Err() != nil {
+			return rlc.cancelled(ctx, listeners, level, current)
+		}
+
+		next := current + 1
+
+		err := scope.startLevel(ctx, next)
+		if err != nil {
+			scope.stopLevel(context.Background(), next)
+
+			if ctx.Err() != nil {
+				return rlc.cancelled(ctx, listeners, level, current)
+			}
+
+			rlc.notify(listeners, func(listener RunLevelListener) { listener.OnError(next, err) })
+
+			return err
+		}
+
+		if ctx.Err() != nil {
+			scope.stopLevel(context.Background(), next)
+
+			return rlc.cancelled(ctx, listeners, level, current)
+		}
+
+		rlc.progress(listeners, next)
+	}
+
+	errs := NewMultiError()
+	for current := scope.getCurrentLevel(); current > level; current = scope.getCurrentLevel() {
+		if ctx.Err() != nil {
+			errs.AddError(rlc.cancelled(ctx, listeners, level, current))
+			break
+		}
+
+		err := scope.stopLevel(ctx, current)
+		if err != nil {
+			errs.AddError(err)
+		}
+
+		rlc.progress(listeners, current-1)
+	}
+
+	return errs.GetFinalError()
+}
+
+func (rlc *runLevelControllerData) ProceedToAsync(ctx context.Context, level int) <-chan error {
+	retVal := make(chan error, 1)
+
+	_, err := threadManager.Go(func() {
+		retVal <- rlc.ProceedTo(ctx, level)
+		close(retVal)
+	})
+	if err != nil {
+		retVal <- err
+		close(retVal)
+	}
+
+	return retVal
+}
+
+func (rlc *runLevelControllerData) GetCurrentLevel() int {
+	scope, ok := rlc.Scope.(*runLevelScopeData)
+	if !ok {
+		return RunLevelNone
+	}
+
+	return scope.getCurrentLevel()
+}
+
+// boundRunLevelListener is a RunLevelListener and the descriptor it was created from
+type boundRunLevelListener struct {
+	desc     Descriptor
+	listener RunLevelListener
+}
+
+func (rlc *runLevelControllerData) getListeners() []*boundRunLevelListener {
+	descs, _ := rlc.Locator.GetDescriptors(NewSingleFilter(UserServicesNamespace, RunLevelListenerName))
+
+	retVal := make([]*boundRunLevelListener, 0, len(descs))
+	for _, desc := range descs {
+		raw, err := rlc.Locator.GetServiceFromDescriptor(desc)
+		if err != nil {
+			continue
+		}
+
+		listener, ok := raw.(RunLevelListener)
+		if ok {
+			retVal = append(retVal, &boundRunLevelListener{
+				desc:     desc,
+				listener: listener,
+			})
+		}
+	}
+
+	return retVal
+}
+
+// notify calls the function with every listener, giving any panic to the ErrorService
+func (rlc *runLevelControllerData) notify(listeners []*boundRunLevelListener, f func(RunLevelListener)) {
+	for _, bound := range listeners {
+		ret := &errorReturn{}
+		safeRunLevelListener(bound.listener, f, ret)
+		if ret.err != nil {
+			ReportFailure(rlc.Locator, ListenerFailure, bound.desc, ret.err)
+		}
+	}
+}
+
+func (rlc *runLevelControllerData) progress(listeners []*boundRunLevelListener, level int) {
+	rlc.notify(listeners, func(listener RunLevelListener) { listener.OnProgress(level) })
+}
+
+func (rlc *runLevelControllerData) cancelled(ctx context.Context, listeners []*boundRunLevelListener, proceedingTo, current int) error {
+	rlc.notify(listeners, func(listener RunLevelListener) { listener.OnCancelled(current) })
+
+	return errors.Wrapf(ctx.Err(), "proceeding to run level %d was stopped at level %d", proceedingTo, current)
+}
+
+type runLevelFilter struct {
+	level int
+}
+
+// Filter gets all the services in the RunLevelScope at the level
+func (filter *runLevelFilter) Filter(desc Descriptor) bool {
+	if desc.GetScope() != RunLevelScope {
+		return false
+	}
+
+	level, err := getRunLevel(desc)
+
+	return err == nil && level == filter.level
+}
+
+func (filter *runLevelFilter) GetNamespace() string {
+	return ""
+}
+
+func (filter *runLevelFilter) GetName() string {
+	return ""
+}
+
+// getRunLevel returns the level in the RunLevelMetadata of the descriptor
+func getRunLevel(desc Descriptor) (int, error) {
+	values := desc.GetMetadata()[RunLevelMetadata]
+	if len(values) == 0 {
+		return 0, fmt.Errorf("service %s in the %s has no %s metadata", desc.GetFullName(), RunLevelScope, RunLevelMetadata)
+	}
+
+	level, err := strconv.Atoi(values[0])
+	if err != nil || level <= RunLevelNone {
+		return 0, fmt.Errorf("service %s has an invalid run level %s", desc.GetFullName(), values[0])
+	}
+
+	return level, nil
+}
+
+func safeRunLevelListener(listener RunLevelListener, f func(RunLevelListener), ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	f(listener)
+}
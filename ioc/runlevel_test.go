@@ -0,0 +1,356 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+const (
+	runLevelLocator1 = "RunLevelLocator1"
+	runLevelLocator2 = "RunLevelLocator2"
+	runLevelLocator3 = "RunLevelLocator3"
+	runLevelLocator4 = "RunLevelLocator4"
+)
+
+type runLevelEvents struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (rle *runLevelEvents) add(event string) {
+	rle.lock.Lock()
+	defer rle.lock.Unlock()
+
+	rle.events = append(rle.events, event)
+}
+
+func (rle *runLevelEvents) get() []string {
+	rle.lock.Lock()
+	defer rle.lock.Unlock()
+
+	retVal := make([]string, len(rle.events))
+	copy(retVal, rle.events)
+
+	return retVal
+}
+
+func (rle *runLevelEvents) indexOf(event string) int {
+	for index, current := range rle.get() {
+		if current == event {
+			return index
+		}
+	}
+
+	return -1
+}
+
+var (
+	levelEvents    = &runLevelEvents{}
+	listenerEvents = &runLevelEvents{}
+	blockStart     chan struct{}
+	blockStarted   chan struct{}
+)
+
+type levelService struct {
+	name string
+}
+
+func (ls *levelService) DargoInitialize(desc Descriptor) error {
+	ls.name = desc.GetName()
+	if ls.name == "Fails" {
+		return fmt.Errorf(ExpectedPanicMessage)
+	}
+	if ls.name == "Blocks" {
+		close(blockStarted)
+		<-blockStart
+	}
+
+	levelEvents.add("start " + ls.name)
+	return nil
+}
+
+func (ls *levelService) DargoDestroy(desc Descriptor) error {
+	levelEvents.add("stop " + ls.name)
+	return nil
+}
+
+type dependentLevelService struct {
+	levelService
+	Dependency *levelService `inject:"LevelOneA"`
+}
+
+type runLevelListenerData struct {
+}
+
+func (rll *runLevelListenerData) OnProgress(level int) {
+	listenerEvents.add(fmt.Sprintf("progress %d", level))
+}
+
+func (rll *runLevelListenerData) OnError(level int, err error) {
+	listenerEvents.add(fmt.Sprintf("error %d", level))
+}
+
+func (rll *runLevelListenerData) OnCancelled(level int) {
+	listenerEvents.add(fmt.Sprintf("cancelled %d", level))
+}
+
+type panickingRunLevelListener struct {
+}
+
+func (prll *panickingRunLevelListener) OnProgress(level int) {
+	panic(ExpectedPanicMessage)
+}
+
+func (prll *panickingRunLevelListener) OnError(level int, err error) {
+}
+
+func (prll *panickingRunLevelListener) OnCancelled(level int) {
+}
+
+func TestRunLevels(t *testing.T) {
+	levelEvents = &runLevelEvents{}
+	listenerEvents = &runLevelEvents{}
+
+	locator, err := CreateAndBind(runLevelLocator1, func(binder Binder) error {
+		binder.Bind("LevelZero", &levelService{}).AtRunLevel(0)
+		binder.Bind("LevelOneA", &levelService{}).AtRunLevel(1)
+		binder.Bind("LevelOneB", &levelService{}).AtRunLevel(1)
+		binder.Bind("LevelTwo", &dependentLevelService{}).AtRunLevel(2)
+		binder.Bind(RunLevelListenerName, &runLevelListenerData{}).InNamespace(UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableRunLevelScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	controller, err := Get[RunLevelController](locator, SSK(RunLevelControllerName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, RunLevelNone, controller.GetCurrentLevel())
+	assert.Equal(t, 0, len(levelEvents.get()), "nothing is started until the controller proceeds")
+
+	_, err = locator.GetDService("LevelOneA")
+	assert.NotNil(t, err, "level one has not been reached")
+
+	err = controller.ProceedTo(context.Background(), 2)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, controller.GetCurrentLevel())
+
+	assert.Equal(t, 4, len(levelEvents.get()))
+	assert.Equal(t, 0, levelEvents.indexOf("start LevelZero"))
+	assert.Equal(t, 3, levelEvents.indexOf("start LevelTwo"))
+
+	err = controller.ProceedTo(context.Background(), 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 0, controller.GetCurrentLevel())
+
+	assert.Equal(t, 4, levelEvents.indexOf("stop LevelTwo"), "the highest level is stopped first")
+	assert.True(t, levelEvents.indexOf("stop LevelOneA") > 4)
+	assert.True(t, levelEvents.indexOf("stop LevelOneB") > 4)
+	assert.Equal(t, -1, levelEvents.indexOf("stop LevelZero"))
+
+	_, err = locator.GetDService("LevelOneA")
+	assert.NotNil(t, err, "level one has been stopped")
+
+	assert.Equal(t, []string{"progress 0", "progress 1", "progress 2", "progress 1", "progress 0"}, listenerEvents.get())
+
+	err = locator.ShutdownContext(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, "stop LevelZero", levelEvents.get()[len(levelEvents.get())-1], "shutdown stops the remaining levels")
+}
+
+func TestRunLevelFailure(t *testing.T) {
+	levelEvents = &runLevelEvents{}
+	listenerEvents = &runLevelEvents{}
+
+	locator, err := CreateAndBind(runLevelLocator2, func(binder Binder) error {
+		binder.Bind("LevelZero", &levelService{}).AtRunLevel(0)
+		binder.Bind("LevelOneA", &levelService{}).AtRunLevel(1)
+		binder.Bind("Fails", &levelService{}).AtRunLevel(1)
+		binder.Bind("LevelTwo", &levelService{}).AtRunLevel(2)
+		binder.Bind(RunLevelListenerName, &runLevelListenerData{}).InNamespace(UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableRunLevelScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	controller, err := Get[RunLevelController](locator, SSK(RunLevelControllerName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = controller.ProceedTo(context.Background(), 2)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, controller.GetCurrentLevel(), "should go back to the last level reached")
+
+	assert.Equal(t, []string{"start LevelZero", "start LevelOneA", "stop LevelOneA"}, levelEvents.get())
+	assert.Equal(t, []string{"progress 0", "error 1"}, listenerEvents.get())
+
+	assert.NotNil(t, controller.ProceedTo(context.Background(), -2))
+}
+
+func TestRunLevelCancelled(t *testing.T) {
+	levelEvents = &runLevelEvents{}
+	listenerEvents = &runLevelEvents{}
+	blockStart = make(chan struct{})
+	blockStarted = make(chan struct{})
+
+	locator, err := CreateAndBind(runLevelLocator3, func(binder Binder) error {
+		binder.Bind("LevelZero", &levelService{}).AtRunLevel(0)
+		binder.Bind("Blocks", &levelService{}).AtRunLevel(1)
+		binder.Bind("LevelTwo", &levelService{}).AtRunLevel(2)
+		binder.Bind(RunLevelListenerName, &runLevelListenerData{}).InNamespace(UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = EnableRunLevelScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	controller, err := Get[RunLevelController](locator, SSK(RunLevelControllerName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := controller.ProceedToAsync(ctx, 2)
+
+	<-blockStarted
+	cancel()
+	close(blockStart)
+
+	err = <-result
+	if assert.NotNil(t, err) {
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+	}
+
+	assert.Equal(t, 0, controller.GetCurrentLevel())
+	assert.Equal(t, []string{"start LevelZero", "start Blocks", "stop Blocks"}, levelEvents.get())
+	assert.Equal(t, []string{"progress 0", "cancelled 0"}, listenerEvents.get())
+
+	blockStarted = make(chan struct{})
+
+	err = <-controller.ProceedToAsync(context.Background(), 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, controller.GetCurrentLevel())
+}
+
+func TestRunLevelListenerPanics(t *testing.T) {
+	levelEvents = &runLevelEvents{}
+	listenerEvents = &runLevelEvents{}
+	lastErrorInformation = make([]ErrorInformation, 0)
+
+	locator, err := CreateAndBind(runLevelLocator4, func(binder Binder) error {
+		binder.Bind("LevelZero", &levelService{}).AtRunLevel(0)
+		binder.Bind(ErrorServiceName, errorServiceData{}).InNamespace(UserServicesNamespace)
+		binder.Bind(RunLevelListenerName, &panickingRunLevelListener{}).InNamespace(UserServicesNamespace).Ranked(1)
+		binder.Bind(RunLevelListenerName, &runLevelListenerData{}).InNamespace(UserServicesNamespace)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	err = EnableRunLevelScope(locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	controller, err := Get[RunLevelController](locator, SSK(RunLevelControllerName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = controller.ProceedTo(ctx, 0)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, context.Canceled, errors.Cause(err))
+	}
+	assert.Equal(t, 0, len(levelEvents.get()), "no service is started with a done context")
+
+	assert.Nil(t, controller.ProceedTo(context.Background(), 0))
+	assert.Equal(t, []string{"cancelled -1", "progress 0"}, listenerEvents.get(),
+		"a panicking listener does not stop the others")
+
+	panics := 0
+	for _, ei := range lastErrorInformation {
+		if ei.GetType() == ListenerFailure && ei.GetDescriptor().GetName() == RunLevelListenerName {
+			panics++
+		}
+	}
+	assert.Equal(t, 1, panics, "the panic should be given to the ErrorService")
+}
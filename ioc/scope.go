@@ -67,6 +67,8 @@ type ContextualScope interface {
 	IsActive(locator ServiceLocator) bool
 
 	// Shutdown will shut down all services in its warehouse since the ServiceLocator
-	// is shutting down
+	// is shutting down.  Services should be destroyed before the services they depend
+	// on.  A scope that can return the errors from destroying its services should
+	// also implement ContextAwareScope
 	Shutdown(locator ServiceLocator)
 }
@@ -41,11 +41,14 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
 	"github.com/jwells131313/goethe"
 	"github.com/pkg/errors"
 	"reflect"
 	"sort"
+	"sync"
+	"time"
 )
 
 // ServiceLocator The main registry for dargo.  Use it to get context sensitive lookups
@@ -65,6 +68,12 @@ type ServiceLocator interface {
 	// GetAllServices returns all the services matching the service key
 	GetAllServices(toMe ServiceKey) ([]interface{}, error)
 
+	// GetServiceByType gets the best service whose implementation type is the given
+	// type or, if the given type is an interface, implements the interface.  If more
+	// than one service has the same highest rank an error implementing
+	// AmbiguousServiceInfo is returned
+	GetServiceByType(ty reflect.Type, qualifiers ...string) (interface{}, error)
+
 	// GetService gets the service that is correct for the current context with the given
 	// descriptor and any other error if there was an error creating the interface
 	GetServiceFromDescriptor(desc Descriptor) (interface{}, error)
@@ -97,11 +106,33 @@ type ServiceLocator interface {
 	// GetID Gets the id of this ServiceLocator
 	GetID() int64
 
-	// Will shut down all services associated with this ServiceLocator
+	// GetParent returns the parent of this ServiceLocator, or nil if this
+	// ServiceLocator has no parent
+	GetParent() ServiceLocator
+
+	// Will shut down all services associated with this ServiceLocator.  Child
+	// locators are shut down first, then the ContextualScopes that have been
+	// created and finally the Singleton services.  Within each scope services
+	// are destroyed before the services they depend on.  Errors from destroying
+	// services are given to the ErrorService, use ShutdownContext to have them
+	// returned
 	Shutdown()
 
-	// GetState Returns LocatorStateRunning or LocatorStateShutdown depending on if this
-	// locator is currently running or it has been shut down
+	// ShutdownContext is like Shutdown but returns the errors from destroying
+	// services in a MultiError and stops waiting once the context is done.  Each
+	// destroy function or DargoDestroy method is given no more than the remaining
+	// time of the context, or DefaultDestroyerTimeout or the time given with
+	// WithDestroyerTimeout.  A context with neither a deadline nor a destroyer
+	// timeout lets each destroyer run for as long as it takes, as Shutdown does.
+	// If the context is done before the shutdown completes
+	// the returned error wraps the error of the context and the shutdown keeps
+	// running in the background, with the locator in the LocatorStateShuttingDown
+	// state until it completes
+	ShutdownContext(ctx context.Context) error
+
+	// GetState Returns LocatorStateRunning, LocatorStateShuttingDown or LocatorStateShutdown
+	// depending on if this locator is currently running, is being shut down or has been
+	// shut down
 	GetState() string
 }
 
@@ -133,8 +164,11 @@ type Provider interface {
 
 type serviceLocatorData struct {
 	glock              goethe.Lock
+	dependencies       *dependencyData
 	name               string
 	ID                 int64
+	parent             *serviceLocatorData
+	children           map[int64]*serviceLocatorData
 	descriptorData     *nameCache
 	nextServiceID      int64
 	perLookupContext   ContextualScope
@@ -144,11 +178,45 @@ type serviceLocatorData struct {
 	errorServices      []ErrorService
 	validationServices []ValidationService
 	injectionResolvers []InjectionResolver
+	interceptors       []InterceptionService
+	lifecycleListeners []InstanceLifecycleListener
+	jitResolvers       []JustInTimeResolver
+	wrapped            sync.Map
+	proxyFactories     sync.Map
+	interceptFactories sync.Map
+	modules            sync.Map
+	moduleLock         sync.Mutex
+	profiles           profileSet
 }
 
 // NewServiceLocator this will find or create a service locator with the given name, and
-// return errors based on the value of qos
-func NewServiceLocator(name string, qos int) (ServiceLocator, error) {
+// return errors based on the value of qos.  The options, such as WithProfiles, are only
+// used if the locator is created
+func NewServiceLocator(name string, qos int, options ...LocatorOption) (ServiceLocator, error) {
+	return newServiceLocator(name, qos, nil, options)
+}
+
+// NewChildServiceLocator this will find or create a service locator with the given name
+// whose parent is the given ServiceLocator, and return errors based on the value of qos.
+// Lookups and injections in the child will also consider the services of the parent
+// (and its parents) other than those with LocalVisibility.  Services from the parent
+// are created and cached by the parent, so a Singleton service of the parent is the
+// same whether it is found through the parent or any of its children.  When the parent
+// is shut down all of its children are also shut down
+func NewChildServiceLocator(name string, qos int, parent ServiceLocator, options ...LocatorOption) (ServiceLocator, error) {
+	if parent == nil {
+		return nil, fmt.Errorf("parent of child locator %s may not be nil", name)
+	}
+
+	parentData, ok := parent.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("parent of child locator %s is an unknown ServiceLocator type", name)
+	}
+
+	return newServiceLocator(name, qos, parentData, options)
+}
+
+func newServiceLocator(name string, qos int, parent *serviceLocatorData, options []LocatorOption) (ServiceLocator, error) {
 	locatorsLock.Lock()
 	defer locatorsLock.Unlock()
 
@@ -167,6 +235,10 @@ func NewServiceLocator(name string, qos int) (ServiceLocator, error) {
 			return nil, fmt.Errorf("Quality of service is FailIfPresent and there is a locator with name %s", name)
 		}
 
+		if retVal.parent != parent {
+			return nil, fmt.Errorf("The existing locator named %s does not have the requested parent", name)
+		}
+
 		return retVal, nil
 	}
 
@@ -175,6 +247,10 @@ func NewServiceLocator(name string, qos int) (ServiceLocator, error) {
 		return nil, fmt.Errorf("Quality of service is FailIfNotPresent and there is no locator named %s", name)
 	}
 
+	if parent != nil && parent.getState() != LocatorStateRunning {
+		return nil, fmt.Errorf("The parent %s of locator %s has been shut down", parent, name)
+	}
+
 	ID := currentID
 	currentID = currentID + 1
 
@@ -182,11 +258,22 @@ func NewServiceLocator(name string, qos int) (ServiceLocator, error) {
 		glock:              threadManager.NewGoetheLock(),
 		name:               name,
 		ID:                 ID,
+		parent:             parent,
+		children:           make(map[int64]*serviceLocatorData),
 		descriptorData:     newNameCache(),
 		perLookupContext:   newPerLookupContext(),
+		dependencies:       newDependencyData(),
 		state:              LocatorStateRunning,
 		errorServices:      make([]ErrorService, 0),
 		validationServices: make([]ValidationService, 0),
+		interceptors:       make([]InterceptionService, 0),
+		lifecycleListeners: make([]InstanceLifecycleListener, 0),
+		jitResolvers:       make([]JustInTimeResolver, 0),
+		profiles:           environmentProfiles(),
+	}
+
+	for _, option := range options {
+		option(retVal)
 	}
 
 	retVal.singletonContext, err = newSingletonScope(retVal)
@@ -227,12 +314,15 @@ func NewServiceLocator(name string, qos int) (ServiceLocator, error) {
 	retVal.nextServiceID = 3
 
 	locators[name] = retVal
+	if parent != nil {
+		parent.children[ID] = retVal
+	}
 
 	return retVal, nil
 }
 
 func (locator *serviceLocatorData) checkState() error {
-	if locator.state != LocatorStateRunning {
+	if locator.state != LocatorStateRunning && locator.state != LocatorStateShuttingDown {
 		return ErrLocatorIsShutdown
 	}
 
@@ -256,13 +346,107 @@ func (locator *serviceLocatorData) getServiceFor(toMe ServiceKey, forMe Descript
 		return nil, err
 	}
 
+	if desc == nil {
+		resolved, err := locator.resolveJustInTime(toMe, nil, forMe)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolved {
+			desc, err = locator.getBestDescriptorFor(f, forMe)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if desc == nil {
 		return nil, NewServiceNotFoundError(toMe)
 	}
 
+	locator.recordDependency(forMe, desc)
+
 	return locator.createService(desc)
 }
 
+func (locator *serviceLocatorData) GetServiceByType(ty reflect.Type, qualifiers ...string) (interface{}, error) {
+	return locator.getServiceByTypeFor(ty, qualifiers, nil)
+}
+
+func (locator *serviceLocatorData) getServiceByTypeFor(ty reflect.Type, qualifiers []string,
+	forMe Descriptor) (interface{}, error) {
+	err := locator.checkState()
+	if err != nil {
+		return nil, err
+	}
+
+	if ty == nil {
+		return nil, fmt.Errorf("type to look up may not be nil")
+	}
+
+	descs, err := locator.getDescriptorsFor(NewTypeFilter(ty, qualifiers...), forMe)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(descs) == 0 {
+		resolved, err := locator.resolveJustInTime(nil, ty, forMe)
+		if err != nil {
+			return nil, err
+		}
+
+		if resolved {
+			descs, err = locator.getDescriptorsFor(NewTypeFilter(ty, qualifiers...), forMe)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(descs) == 0 {
+		return nil, NewServiceTypeNotFoundError(ty)
+	}
+
+	best := descs[0]
+	ambiguous := []Descriptor{best}
+	for _, desc := range descs[1:] {
+		if desc.GetRank() != best.GetRank() || desc.GetLocatorID() != best.GetLocatorID() {
+			break
+		}
+
+		ambiguous = append(ambiguous, desc)
+	}
+
+	if len(ambiguous) > 1 {
+		return nil, NewAmbiguousServiceError(ty, ambiguous)
+	}
+
+	locator.recordDependency(forMe, best)
+
+	service, err := locator.createService(best)
+	if err != nil {
+		return nil, err
+	}
+
+	// A Decorator or interceptor may have replaced the service with a value
+	// that is not of the type it was found by
+	if service != nil && !reflect.TypeOf(service).AssignableTo(ty) {
+		return nil, NewServiceTypeError(descriptorKey(best), ty, service)
+	}
+
+	return service, nil
+}
+
+// descriptorKey returns the key of the service of the descriptor
+func descriptorKey(desc Descriptor) ServiceKey {
+	key, err := NewServiceKey(desc.GetNamespace(), desc.GetName(), desc.GetQualifiers()...)
+	if err != nil {
+		return DSK("Unknown")
+	}
+
+	return key
+}
+
 func (locator *serviceLocatorData) GetDService(name string, qualifiers ...string) (interface{}, error) {
 	err := locator.checkState()
 	if err != nil {
@@ -293,6 +477,8 @@ func (locator *serviceLocatorData) getAllServicesFor(toMe ServiceKey, forMe Desc
 	retErr := NewMultiError()
 
 	for _, desc := range descs {
+		locator.recordDependency(forMe, desc)
+
 		us, err := locator.createService(desc)
 		if err != nil {
 			retErr.AddError(err)
@@ -367,6 +553,14 @@ func (locator *serviceLocatorData) GetID() int64 {
 	return locator.ID
 }
 
+func (locator *serviceLocatorData) GetParent() ServiceLocator {
+	if locator.parent == nil {
+		return nil
+	}
+
+	return locator.parent
+}
+
 func (locator *serviceLocatorData) Inject(input interface{}) error {
 	ty := reflect.TypeOf(input)
 	if ty.Kind() != reflect.Ptr {
@@ -385,27 +579,130 @@ func (locator *serviceLocatorData) Inject(input interface{}) error {
 }
 
 func (locator *serviceLocatorData) Shutdown() {
-	defer func() {
-		locatorsLock.Lock()
-		defer locatorsLock.Unlock()
+	locator.ShutdownContext(context.Background())
+}
 
-		delete(locators, locator.name)
-	}()
+func (locator *serviceLocatorData) ShutdownContext(ctx context.Context) error {
+	errs := NewMultiError()
+
+	if !locator.compareAndSetState(LocatorStateRunning, LocatorStateShuttingDown) {
+		return nil
+	}
+
+	locatorsLock.Lock()
+	children := make([]*serviceLocatorData, 0, len(locator.children))
+	for _, child := range locator.children {
+		children = append(children, child)
+	}
+	locatorsLock.Unlock()
+
+	for _, child := range children {
+		err := child.ShutdownContext(ctx)
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
 
-	c := make(chan bool)
+	c := make(chan error, 1)
 
 	threadManager.Go(func() {
-		locator.singletonContext.Shutdown(locator)
+		locator.closeTopicPool()
 
-		locator.state = LocatorStateShutdown
+		err := locator.shutdownScopes(ctx)
 
-		c <- true
+		locator.compareAndSetState(LocatorStateShuttingDown, LocatorStateShutdown)
+
+		locatorsLock.Lock()
+		delete(locators, locator.name)
+		if locator.parent != nil {
+			delete(locator.parent.children, locator.ID)
+		}
+		locatorsLock.Unlock()
+
+		c <- err
 	})
 
-	<-c
+	select {
+	case err := <-c:
+		if err != nil {
+			errs.AddError(err)
+		}
+	case <-ctx.Done():
+		errs.AddError(errors.Wrapf(ctx.Err(), "shutdown of %v is still running", locator))
+	}
+
+	return errs.GetFinalError()
+}
+
+// shutdownScopes shuts down the ContextualScopes bound into this locator that
+// have been created, since their services may depend on Singleton services,
+// and then shuts down the Singleton scope
+func (locator *serviceLocatorData) shutdownScopes(ctx context.Context) error {
+	errs := NewMultiError()
+
+	locator.glock.ReadLock()
+	all := locator.descriptorData.getAll()
+	locator.glock.ReadUnlock()
+
+	scopeDescs := make([]Descriptor, 0)
+	for _, desc := range all {
+		if desc.GetNamespace() != ContextualScopeNamespace {
+			continue
+		}
+
+		if !locator.singletonContext.ContainsKey(locator, desc) {
+			continue
+		}
+
+		scopeDescs = append(scopeDescs, desc)
+	}
+
+	for _, desc := range orderForDestruction(locator, scopeDescs) {
+		raw, err := locator.singletonContext.FindOrCreate(locator, desc)
+		if err != nil {
+			errs.AddError(err)
+			continue
+		}
+
+		if aware, ok := raw.(ContextAwareScope); ok {
+			err = aware.ShutdownContext(ctx, locator)
+		} else if cs, ok := raw.(ContextualScope); ok {
+			cs.Shutdown(locator)
+		}
+
+		if err != nil {
+			errs.AddError(err)
+		}
+	}
+
+	err := locator.singletonContext.(ContextAwareScope).ShutdownContext(ctx, locator)
+	if err != nil {
+		errs.AddError(err)
+	}
+
+	return errs.GetFinalError()
+}
+
+// getOwner returns the locator in this locators hierarchy that the descriptor
+// was bound into, which is the locator that must create and cache the service
+func (locator *serviceLocatorData) getOwner(desc Descriptor) *serviceLocatorData {
+	locatorID := desc.GetLocatorID()
+
+	for current := locator; current != nil; current = current.parent {
+		if current.ID == locatorID {
+			return current
+		}
+	}
+
+	return locator
 }
 
 func (locator *serviceLocatorData) createService(desc Descriptor) (interface{}, error) {
+	owner := locator.getOwner(desc)
+	if owner != locator {
+		return owner.createService(desc)
+	}
+
 	scope := desc.GetScope()
 
 	var cs ContextualScope
@@ -457,6 +754,48 @@ func (locator *serviceLocatorData) channelGetDescriptors(filter Filter, forMe De
 }
 
 func (locator *serviceLocatorData) internalGetDescriptors(filter Filter, forMe Descriptor) ([]Descriptor, error) {
+	retVal, err := locator.internalGetLocalDescriptors(filter, forMe, false)
+	if err != nil {
+		return nil, err
+	}
+
+	for ancestor := locator.parent; ancestor != nil; ancestor = ancestor.parent {
+		fromAncestor, err := ancestor.internalGetLocalDescriptors(filter, forMe, true)
+		if err != nil {
+			return nil, err
+		}
+
+		retVal = append(retVal, fromAncestor...)
+	}
+
+	sort.Slice(retVal, func(i, j int) bool {
+		if retVal[i].GetRank() > retVal[j].GetRank() {
+			return true
+		} else if retVal[i].GetRank() < retVal[j].GetRank() {
+			return false
+		}
+
+		if retVal[i].GetLocatorID() > retVal[j].GetLocatorID() {
+			return true
+		} else if retVal[i].GetLocatorID() < retVal[j].GetLocatorID() {
+			return false
+		}
+
+		if retVal[i].GetServiceID() < retVal[j].GetServiceID() {
+			return true
+		}
+
+		return false
+	})
+
+	return retVal, nil
+}
+
+// internalGetLocalDescriptors returns the unsorted descriptors of this locator only.  If
+// fromChild is true the lookup is being done on behalf of a child locator, and so
+// descriptors with LocalVisibility are not returned
+func (locator *serviceLocatorData) internalGetLocalDescriptors(filter Filter, forMe Descriptor,
+	fromChild bool) ([]Descriptor, error) {
 	locator.glock.ReadLock()
 	defer locator.glock.ReadUnlock()
 
@@ -464,6 +803,10 @@ func (locator *serviceLocatorData) internalGetDescriptors(filter Filter, forMe D
 	candidates := locator.descriptorData.limitedLookup(filter)
 
 	for _, desc := range candidates {
+		if fromChild && desc.GetVisibility() == LocalVisibility {
+			continue
+		}
+
 		passedValidation := true
 
 		vi := newValidationInformation(LookupOperation, desc, forMe, filter)
@@ -505,26 +848,6 @@ func (locator *serviceLocatorData) internalGetDescriptors(filter Filter, forMe D
 		}
 	}
 
-	sort.Slice(retVal, func(i, j int) bool {
-		if retVal[i].GetRank() > retVal[j].GetRank() {
-			return true
-		} else if retVal[i].GetRank() < retVal[j].GetRank() {
-			return false
-		}
-
-		if retVal[i].GetLocatorID() > retVal[j].GetLocatorID() {
-			return true
-		} else if retVal[i].GetLocatorID() < retVal[j].GetLocatorID() {
-			return false
-		}
-
-		if retVal[i].GetServiceID() < retVal[j].GetServiceID() {
-			return true
-		}
-
-		return false
-	})
-
 	return retVal, nil
 }
 
@@ -588,11 +911,25 @@ func (locator *serviceLocatorData) CreateServiceFromDescriptor(desc Descriptor)
 
 	errRet := &errorReturn{}
 
+	start := locator.fireLifecycleEvent(InstancePreCreate, desc, nil, nil, time.Time{})
+
 	retVal, err := safeCreatorFunctions(cf, locator, desc, errRet)
 	if errRet.err != nil {
 		err = errRet.err
 	}
 
+	if err == nil {
+		retVal, err = locator.wrap(desc, retVal)
+	}
+
+	if err == nil {
+		locator.dependencies.addCreation(desc)
+
+		locator.fireLifecycleEvent(InstancePostCreate, desc, retVal, nil, start)
+	} else {
+		locator.fireLifecycleEvent(InstanceCreateFailed, desc, nil, err, start)
+	}
+
 	if err != nil {
 		var hasRunHandlers bool
 
@@ -619,7 +956,7 @@ func (locator *serviceLocatorData) CreateServiceFromDescriptor(desc Descriptor)
 
 // update returns true if the error handlers have already been run
 func (locator *serviceLocatorData) update(newDescs []Descriptor,
-	removers []Filter, originalGeneration uint64) (bool, error) {
+	removers []Filter, profiles profileSet, originalGeneration uint64) (bool, error) {
 	locator.glock.WriteLock()
 	defer locator.glock.WriteUnlock()
 
@@ -627,14 +964,21 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 		return false, fmt.Errorf("there was an update to the ServiceLocator after this DynamicConfiguration was created")
 	}
 
-	newDescriptorData := newNameCache()
+	if profiles == nil {
+		profiles = locator.profiles
+	}
+
+	bound := make([]Descriptor, 0)
 
 	var errorServiceUpdate bool
 	var validationServiceUpdate bool
 	var injectionResolverUpdate bool
+	var interceptionServiceUpdate bool
+	var lifecycleListenerUpdate bool
+	var jitResolverUpdate bool
 
 	removedDescriptors := make([]Descriptor, 0)
-	for _, myDesc := range locator.descriptorData.getAll() {
+	for _, myDesc := range locator.descriptorData.getBound() {
 		removeMe := false
 
 		for _, removeFilter := range removers {
@@ -642,11 +986,14 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 		}
 
 		if !removeMe {
-			newDescriptorData.add(myDesc)
+			bound = append(bound, myDesc)
 		} else {
 			errorServiceUpdate = errorServiceUpdate || isErrorService(myDesc)
 			validationServiceUpdate = validationServiceUpdate || isValidationService(myDesc)
 			injectionResolverUpdate = injectionResolverUpdate || isInjectionResolver(myDesc)
+			interceptionServiceUpdate = interceptionServiceUpdate || isInterceptionService(myDesc)
+			lifecycleListenerUpdate = lifecycleListenerUpdate || isInstanceLifecycleListener(myDesc)
+			jitResolverUpdate = jitResolverUpdate || isJustInTimeResolver(myDesc)
 
 			removedDescriptors = append(removedDescriptors, myDesc)
 		}
@@ -712,7 +1059,8 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 		}
 
 		if isErrorService(newDesc) || isValidationService(newDesc) || isConfigurationListener(newDesc) ||
-			isInjectionResolver(newDesc) {
+			isInjectionResolver(newDesc) || isInterceptionService(newDesc) || isInstanceLifecycleListener(newDesc) ||
+			isJustInTimeResolver(newDesc) {
 			if Singleton != newDesc.GetScope() {
 				return false, fmt.Errorf("implementations of %s must be in the singleton scope",
 					newDesc.GetName())
@@ -727,9 +1075,44 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 			if isInjectionResolver(newDesc) {
 				injectionResolverUpdate = true
 			}
+			if isInterceptionService(newDesc) {
+				interceptionServiceUpdate = true
+			}
+			if isInstanceLifecycleListener(newDesc) {
+				lifecycleListenerUpdate = true
+			}
+			if isJustInTimeResolver(newDesc) {
+				jitResolverUpdate = true
+			}
 		}
 
-		newDescriptorData.add(newDesc)
+		bound = append(bound, newDesc)
+	}
+
+	newDescriptorData, conditionFailures := locator.activate(bound, profiles)
+
+	// Special services that became available or unavailable must be found again
+	wasActive := make(map[Descriptor]bool)
+	for _, desc := range locator.descriptorData.getAll() {
+		wasActive[desc] = true
+	}
+
+	isActive := make(map[Descriptor]bool)
+	for _, desc := range newDescriptorData.getAll() {
+		isActive[desc] = true
+	}
+
+	for _, desc := range bound {
+		if wasActive[desc] == isActive[desc] {
+			continue
+		}
+
+		errorServiceUpdate = errorServiceUpdate || isErrorService(desc)
+		validationServiceUpdate = validationServiceUpdate || isValidationService(desc)
+		injectionResolverUpdate = injectionResolverUpdate || isInjectionResolver(desc)
+		interceptionServiceUpdate = interceptionServiceUpdate || isInterceptionService(desc)
+		lifecycleListenerUpdate = lifecycleListenerUpdate || isInstanceLifecycleListener(desc)
+		jitResolverUpdate = jitResolverUpdate || isJustInTimeResolver(desc)
 	}
 
 	var success bool
@@ -739,12 +1122,23 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 	oldErrorServices := locator.errorServices
 	oldValidationServices := locator.validationServices
 	oldInjectionResolvers := locator.injectionResolvers
+	oldInterceptors := locator.interceptors
+	oldLifecycleListeners := locator.lifecycleListeners
+	oldJITResolvers := locator.jitResolvers
+	oldProfiles := locator.profiles
 
 	locator.descriptorData = newDescriptorData
+	locator.profiles = profiles
 
 	defer func() {
 		if success {
 			locator.generation = locator.generation + 1
+			locator.dependencies.remove(removedDescriptors)
+
+			for _, failure := range conditionFailures {
+				locator.runErrorHandlers(ConditionFailure, failure.desc, nil, nil, failure.err)
+			}
+
 			return
 		}
 
@@ -752,6 +1146,10 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 		locator.errorServices = oldErrorServices
 		locator.descriptorData = oldDescriptorData
 		locator.injectionResolvers = oldInjectionResolvers
+		locator.interceptors = oldInterceptors
+		locator.lifecycleListeners = oldLifecycleListeners
+		locator.jitResolvers = oldJITResolvers
+		locator.profiles = oldProfiles
 	}()
 
 	if errorServiceUpdate {
@@ -832,6 +1230,69 @@ func (locator *serviceLocatorData) update(newDescs []Descriptor,
 		locator.injectionResolvers = newIRServices
 	}
 
+	if interceptionServiceUpdate {
+		// Must get all interception services again
+		raws, err := locator.GetAllServices(USK(InterceptionServiceName))
+		if err != nil {
+			return false, errors.Wrap(err, "creation of interception services failed")
+		}
+
+		newInterceptors := make([]InterceptionService, 0)
+		for _, interceptorRaw := range raws {
+			interceptor, ok := interceptorRaw.(InterceptionService)
+			if !ok {
+				return false, fmt.Errorf("a service %v with interception service key does not implement interception service",
+					interceptorRaw)
+			}
+
+			newInterceptors = append(newInterceptors, interceptor)
+		}
+
+		locator.interceptors = newInterceptors
+	}
+
+	if lifecycleListenerUpdate {
+		// Must get all lifecycle listeners again
+		raws, err := locator.GetAllServices(USK(InstanceLifecycleListenerName))
+		if err != nil {
+			return false, errors.Wrap(err, "creation of instance lifecycle listeners failed")
+		}
+
+		newLifecycleListeners := make([]InstanceLifecycleListener, 0)
+		for _, listenerRaw := range raws {
+			listener, ok := listenerRaw.(InstanceLifecycleListener)
+			if !ok {
+				return false, fmt.Errorf("a service %v with instance lifecycle listener key does not implement instance lifecycle listener",
+					listenerRaw)
+			}
+
+			newLifecycleListeners = append(newLifecycleListeners, listener)
+		}
+
+		locator.lifecycleListeners = newLifecycleListeners
+	}
+
+	if jitResolverUpdate {
+		// Must get all just in time resolvers again
+		raws, err := locator.GetAllServices(USK(JustInTimeResolverName))
+		if err != nil {
+			return false, errors.Wrap(err, "creation of just in time resolvers failed")
+		}
+
+		newJITResolvers := make([]JustInTimeResolver, 0)
+		for _, resolverRaw := range raws {
+			resolver, ok := resolverRaw.(JustInTimeResolver)
+			if !ok {
+				return false, fmt.Errorf("a service %v with just in time resolver key does not implement just in time resolver",
+					resolverRaw)
+			}
+
+			newJITResolvers = append(newJITResolvers, resolver)
+		}
+
+		locator.jitResolvers = newJITResolvers
+	}
+
 	success = true
 
 	return false, nil
@@ -846,9 +1307,54 @@ func (locator *serviceLocatorData) runErrorHandlers(typ string, desc Descriptor,
 }
 
 func (locator *serviceLocatorData) GetState() string {
+	return locator.getState()
+}
+
+// getState returns the state of the locator, read under its lock
+func (locator *serviceLocatorData) getState() string {
+	tid := threadManager.GetThreadID()
+	if tid < 0 {
+		c := make(chan string)
+
+		threadManager.Go(func(ret chan string) {
+			ret <- locator.getState()
+		}, c)
+
+		return <-c
+	}
+
+	locator.glock.ReadLock()
+	defer locator.glock.ReadUnlock()
+
 	return locator.state
 }
 
+// compareAndSetState changes the state of the locator to the to state if it is
+// in the from state, under its lock.  Returns true if the state was changed
+func (locator *serviceLocatorData) compareAndSetState(from, to string) bool {
+	tid := threadManager.GetThreadID()
+	if tid < 0 {
+		c := make(chan bool)
+
+		threadManager.Go(func(ret chan bool) {
+			ret <- locator.compareAndSetState(from, to)
+		}, c)
+
+		return <-c
+	}
+
+	locator.glock.WriteLock()
+	defer locator.glock.WriteUnlock()
+
+	if locator.state != from {
+		return false
+	}
+
+	locator.state = to
+
+	return true
+}
+
 func (locator *serviceLocatorData) String() string {
 	return fmt.Sprintf("ServiceLocator(%s,%d)", locator.name, locator.ID)
 }
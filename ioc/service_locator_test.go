@@ -41,9 +41,13 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -271,3 +275,764 @@ type InjectsSomeWeirdThingsService struct {
 func panicyCreator(ServiceLocator, Descriptor) (interface{}, error) {
 	panic(ExpectedPanicMessage)
 }
+
+const (
+	parentLocatorName  = "ParentLocator"
+	childLocatorName   = "ChildLocator"
+	hierarchyLocator1  = "HierarchyLocator1"
+	hierarchyLocator2  = "HierarchyLocator2"
+	hierarchyLocator3  = "HierarchyLocator3"
+	hierarchyLocator4  = "HierarchyLocator4"
+	hierarchyLocator5  = "HierarchyLocator5"
+	hierarchyLocator6  = "HierarchyLocator6"
+	hierarchyLocator7  = "HierarchyLocator7"
+	hierarchyLocator8  = "HierarchyLocator8"
+	hierarchyLocator9  = "HierarchyLocator9"
+	hierarchyLocator10 = "HierarchyLocator10"
+)
+
+type tenantService struct {
+	tenant string
+}
+
+type tenantUser struct {
+	Tenant  *tenantService `inject:"TenantService"`
+	Service *Service       `inject:"Service"`
+}
+
+func TestChildFindsParentServices(t *testing.T) {
+	parent, err := CreateAndBind(parentLocatorName, func(binder Binder) error {
+		binder.Bind("Service", &Service{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(childLocatorName, FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, parent, child.GetParent())
+	assert.Nil(t, parent.GetParent())
+
+	fromParent, err := parent.GetDService("Service")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	fromChild, err := child.GetDService("Service")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// The singleton is owned by the parent, so must be the same instance
+	assert.True(t, fromParent == fromChild, "parent singleton not shared with child")
+
+	_, err = NewChildServiceLocator(childLocatorName, ReturnExistingOrCreateNew, nil)
+	assert.NotNil(t, err, "nil parent should fail")
+
+	found, err := NewChildServiceLocator(childLocatorName, ReturnExistingOrCreateNew, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, child, found)
+
+	_, err = NewServiceLocator(childLocatorName, ReturnExistingOrCreateNew)
+	assert.NotNil(t, err, "existing locator has a different parent")
+}
+
+func TestChildOverridesParent(t *testing.T) {
+	parent, err := CreateAndBind(hierarchyLocator1, func(binder Binder) error {
+		binder.BindConstant("TenantService", &tenantService{tenant: "global"})
+		binder.Bind("Service", &Service{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(hierarchyLocator2, FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(child, func(binder Binder) error {
+		binder.BindConstant("TenantService", &tenantService{tenant: "child"})
+		binder.Bind("TenantUser", &tenantUser{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := child.GetDService("TenantUser")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	user := raw.(*tenantUser)
+	assert.Equal(t, "child", user.Tenant.tenant, "child should win equal rank")
+	assert.NotNil(t, user.Service, "should have gotten service from parent")
+
+	raw, err = parent.GetDService("TenantService")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "global", raw.(*tenantService).tenant, "parent does not see child services")
+
+	_, err = parent.GetDService("TenantUser")
+	assert.True(t, IsServiceNotFound(err), "parent does not see child services")
+
+	all, err := child.GetAllServices(DSK("TenantService"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Equal(t, 2, len(all)) {
+		return
+	}
+	assert.Equal(t, "child", all[0].(*tenantService).tenant)
+	assert.Equal(t, "global", all[1].(*tenantService).tenant)
+
+	// Now a higher ranked parent service should win over the child
+	err = BindIntoLocator(parent, func(binder Binder) error {
+		binder.BindConstant("TenantService", &tenantService{tenant: "ranked"}).Ranked(10)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err = child.GetDService("TenantService")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "ranked", raw.(*tenantService).tenant, "higher rank in parent should win")
+}
+
+func TestLocalVisibilityHiddenFromChild(t *testing.T) {
+	parent, err := CreateAndBind(hierarchyLocator3, func(binder Binder) error {
+		binder.BindConstant("TenantService", &tenantService{tenant: "local"}).WithVisibility(LocalVisibility)
+		binder.Bind("Service", &Service{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(hierarchyLocator4, FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := parent.GetDService("TenantService")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "local", raw.(*tenantService).tenant)
+
+	_, err = child.GetDService("TenantService")
+	assert.True(t, IsServiceNotFound(err), "local service should not be visible to child")
+
+	descs, err := child.GetDescriptors(NewServiceKeyFilter(DSK("TenantService")))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 0, len(descs))
+
+	descs, err = child.GetDescriptors(NewServiceKeyFilter(DSK("Service")))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 1, len(descs))
+}
+
+func TestGrandchildAndProvider(t *testing.T) {
+	parent, err := CreateAndBind(hierarchyLocator5, func(binder Binder) error {
+		binder.Bind(ColorServiceName, &colorServiceData{}).QualifiedBy(BRed)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(hierarchyLocator6, FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	grandchild, err := NewChildServiceLocator(hierarchyLocator7, FailIfPresent, child)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(grandchild, func(binder Binder) error {
+		binder.Bind(ColorServiceName, &colorServiceData{}).QualifiedBy(BBlue)
+		binder.Bind(RainbowName, &RainbowServiceData{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := grandchild.GetDService(RainbowName)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	all, err := raw.(*RainbowServiceData).ColorProvider.GetAll()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, 2, len(all), "provider should see grandchild and parent services")
+}
+
+func TestParentShutdownShutsDownChildren(t *testing.T) {
+	parent, err := NewServiceLocator(hierarchyLocator8, FailIfPresent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	child, err := NewChildServiceLocator(hierarchyLocator9, FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(child, func(binder Binder) error {
+		binder.BindWithCreator(ShutdownService, createShuttableService).AndDestroyWith(destroyShuttableService)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	raw, err := child.GetDService(ShutdownService)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	parent.Shutdown()
+
+	assert.True(t, raw.(*shuttableService).isShut, "child service should have been destroyed")
+	assert.Equal(t, LocatorStateShutdown, child.GetState())
+
+	_, err = NewChildServiceLocator(hierarchyLocator10, FailIfPresent, parent)
+	assert.NotNil(t, err, "may not create child of shut down parent")
+
+	_, err = NewServiceLocator(hierarchyLocator9, FailIfPresent)
+	assert.Nil(t, err, "child name should have been released")
+}
+
+const (
+	contractLocator = "ContractLocator"
+)
+
+type contractReader interface {
+	Read() string
+}
+
+type contractWriter interface {
+	Write(string)
+}
+
+type contractCache struct {
+	value string
+}
+
+func (cc *contractCache) Read() string {
+	return cc.value
+}
+
+func (cc *contractCache) Write(value string) {
+	cc.value = value
+}
+
+type contractUser struct {
+	Reader contractReader `inject:"Reader"`
+	Writer contractWriter `inject:"other/space#Writer"`
+}
+
+func TestContractsShareSingleton(t *testing.T) {
+	locator, err := CreateAndBind(contractLocator, func(binder Binder) error {
+		binder.Bind("Cache", &contractCache{}).AlsoAs("Reader").AlsoAs("other/space#Writer")
+		binder.Bind("User", &contractUser{}).InScope(PerLookup)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	user, err := GetD[*contractUser](locator, "User")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	user.Writer.Write("hello")
+	assert.Equal(t, "hello", user.Reader.Read(), "reader and writer must be the same instance")
+
+	cache, err := GetD[*contractCache](locator, "Cache")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "hello", cache.Read())
+
+	descs, err := locator.GetDescriptors(NewServiceKeyFilter(DSK("Reader")))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Equal(t, 1, len(descs)) {
+		return
+	}
+	assert.Equal(t, "Cache", descs[0].GetName())
+
+	err = UnbindDServices(locator, "Cache")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Reader")
+	assert.True(t, IsServiceNotFound(err))
+
+	assert.Panics(t, func() {
+		BindIntoLocator(locator, func(binder Binder) error {
+			binder.Bind("Cache", &contractCache{}).AlsoAs("Reader", "bad name")
+			return nil
+		})
+	}, "an invalid name given to AlsoAs should panic")
+
+	_, err = locator.GetDService("Reader")
+	assert.True(t, IsServiceNotFound(err), "nothing is bound when AlsoAs panics")
+}
+
+const (
+	typeLocator1 = "TypeLocator1"
+	typeLocator2 = "TypeLocator2"
+)
+
+type byTypeUser struct {
+	Reader   contractReader    `inject:""`
+	Cache    *contractCache    `inject:"type"`
+	Writer   contractWriter    `inject:",optional"`
+	Locator  ServiceLocator    `inject:""`
+	NotThere *shuttableService `inject:",optional"`
+}
+
+func TestGetServiceByType(t *testing.T) {
+	locator, err := CreateAndBind(typeLocator1, func(binder Binder) error {
+		binder.Bind("Cache", &contractCache{})
+		binder.Bind("User", &byTypeUser{})
+		binder.BindConstant("Ten", 10)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	readerType := reflect.TypeOf((*contractReader)(nil)).Elem()
+
+	raw, err := locator.GetServiceByType(readerType)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	cache, ok := raw.(*contractCache)
+	if !assert.True(t, ok) {
+		return
+	}
+
+	raw, err = locator.GetServiceByType(reflect.TypeOf(0))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 10, raw)
+
+	user, err := GetByType[*byTypeUser](locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.True(t, cache == user.Reader, "should be the same singleton")
+	assert.True(t, cache == user.Cache, "should be the same singleton")
+	assert.True(t, cache == user.Writer, "should be the same singleton")
+	assert.Equal(t, locator, user.Locator)
+	assert.Nil(t, user.NotThere)
+
+	_, err = locator.GetServiceByType(reflect.TypeOf(""))
+	assert.True(t, IsServiceNotFound(err))
+
+	_, err = GetByType[contractReader](locator, "NoSuchQualifier")
+	assert.True(t, IsServiceNotFound(err))
+}
+
+func TestAmbiguousServiceByType(t *testing.T) {
+	locator, err := CreateAndBind(typeLocator2, func(binder Binder) error {
+		binder.Bind("Cache1", &contractCache{})
+		binder.Bind("Cache2", &contractCache{}).QualifiedBy("Two")
+		binder.Bind("User", &byTypeUser{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = GetByType[contractReader](locator)
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, IsAmbiguousService(err))
+
+	info := err.(AmbiguousServiceInfo)
+	assert.Equal(t, 2, len(info.GetCandidates()))
+
+	_, err = locator.GetDService("User")
+	assert.True(t, IsAmbiguousService(err), "injection should also be ambiguous")
+
+	two, err := GetByType[contractReader](locator, "Two")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(locator, func(binder Binder) error {
+		binder.Bind("Cache3", &contractCache{}).Ranked(5)
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	three, err := GetByType[contractReader](locator)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.False(t, two == three, "higher ranked service should be chosen")
+
+	cache3, err := GetD[*contractCache](locator, "Cache3")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, cache3 == three)
+}
+
+const (
+	shutdownOrderLocator1 = "ShutdownOrderLocator1"
+	shutdownOrderLocator2 = "ShutdownOrderLocator2"
+	shutdownOrderLocator3 = "ShutdownOrderLocator3"
+)
+
+var destructionOrder []string
+
+type orderedPool struct {
+	name string
+}
+
+func (op *orderedPool) DargoInitialize(desc Descriptor) error {
+	op.name = desc.GetName()
+	return nil
+}
+
+func (op *orderedPool) DargoDestroy(Descriptor) error {
+	destructionOrder = append(destructionOrder, op.name)
+	return nil
+}
+
+type orderedRepository struct {
+	Pool *orderedPool `inject:"Pool"`
+}
+
+func (or *orderedRepository) DargoDestroy(Descriptor) error {
+	destructionOrder = append(destructionOrder, "Repository")
+	return nil
+}
+
+type orderedLazy struct {
+	PoolProvider Provider `inject:"LazyPool"`
+}
+
+func (ol *orderedLazy) DargoDestroy(Descriptor) error {
+	destructionOrder = append(destructionOrder, "Lazy")
+	return nil
+}
+
+func indexOf(all []string, find string) int {
+	for index, value := range all {
+		if value == find {
+			return index
+		}
+	}
+
+	return -1
+}
+
+func TestShutdownInDependencyOrder(t *testing.T) {
+	destructionOrder = make([]string, 0)
+
+	locator, err := CreateAndBind(shutdownOrderLocator1, func(binder Binder) error {
+		binder.Bind("Pool", &orderedPool{})
+		binder.Bind("LazyPool", &orderedPool{})
+		binder.Bind("Repository", &orderedRepository{})
+		binder.Bind("Lazy", &orderedLazy{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	lazy, err := GetD[*orderedLazy](locator, "Lazy")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// LazyPool is created after Lazy, but Lazy still depends on it
+	_, err = lazy.PoolProvider.Get()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = GetD[*orderedRepository](locator, "Repository")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = locator.ShutdownContext(context.Background())
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	if !assert.Equal(t, 4, len(destructionOrder)) {
+		return
+	}
+
+	assert.True(t, indexOf(destructionOrder, "Repository") < indexOf(destructionOrder, "Pool"),
+		"Repository must be destroyed before Pool %v", destructionOrder)
+	assert.True(t, indexOf(destructionOrder, "Lazy") < indexOf(destructionOrder, "LazyPool"),
+		"Lazy must be destroyed before LazyPool %v", destructionOrder)
+}
+
+func TestUnbindForgetsDependencies(t *testing.T) {
+	locator, err := CreateAndBind(shutdownOrderLocator3, func(binder Binder) error {
+		binder.Bind("Pool", &orderedPool{})
+		binder.Bind("Repository", &orderedRepository{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = GetD[*orderedRepository](locator, "Repository")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	repoDesc, err := locator.GetBestDescriptor(NewSingleFilter(DefaultNamespace, "Repository"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	iLocator := locator.(*serviceLocatorData)
+	assert.Equal(t, 1, len(iLocator.dependencies.getDependencies(repoDesc)))
+
+	dcs, err := Get[DynamicConfigurationService](locator, SSK(DynamicConfigurationServiceName))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	config, err := dcs.CreateDynamicConfiguration()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	config.AddRemoveFilter(NewSingleFilter(DefaultNamespace, "Repository"))
+	if !assert.Nil(t, config.Commit()) {
+		return
+	}
+
+	assert.Equal(t, 0, len(iLocator.dependencies.getDependencies(repoDesc)),
+		"the dependencies of an unbound service should be forgotten")
+	assert.Equal(t, uint64(0), iLocator.dependencies.getCreation(repoDesc))
+}
+
+func TestShutdownReturnsDestroyErrors(t *testing.T) {
+	locator, err := CreateAndBind(shutdownOrderLocator2, func(binder Binder) error {
+		binder.BindConstant("Failing1", &destroyableService{failWith: ExpectedPanic})
+		binder.BindConstant("Failing2", &destroyableService{panicWith: ExpectedPanic})
+		binder.BindConstant("Working", &destroyableService{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	services := make([]*destroyableService, 0)
+	for _, name := range []string{"Failing1", "Failing2", "Working"} {
+		service, err := GetD[*destroyableService](locator, name)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		services = append(services, service)
+	}
+
+	err = locator.ShutdownContext(context.Background())
+	if !assert.NotNil(t, err) {
+		return
+	}
+
+	multi, ok := err.(MultiError)
+	if !assert.True(t, ok, "expected a MultiError") {
+		return
+	}
+	assert.Equal(t, 2, len(multi.GetErrors()))
+
+	for _, service := range services {
+		assert.True(t, service.destroyed, "every service should be destroyed even when some fail")
+	}
+
+	assert.Equal(t, LocatorStateShutdown, locator.GetState())
+}
+
+const (
+	shutdownContextLocator1 = "ShutdownContextLocator1"
+	shutdownContextLocator2 = "ShutdownContextLocator2"
+)
+
+type hungService struct {
+	release chan bool
+}
+
+func (hs *hungService) DargoDestroy(Descriptor) error {
+	<-hs.release
+	return nil
+}
+
+type stateRecordingService struct {
+	Locator   ServiceLocator `inject:"system#ServiceLocator"`
+	stateSeen string
+}
+
+func (srs *stateRecordingService) DargoDestroy(Descriptor) error {
+	srs.stateSeen = srs.Locator.GetState()
+	return nil
+}
+
+func TestShutdownContextAbandonsHungDestroyer(t *testing.T) {
+	hung := &hungService{
+		release: make(chan bool),
+	}
+	defer close(hung.release)
+
+	locator, err := CreateAndBind(shutdownContextLocator1, func(binder Binder) error {
+		binder.BindConstant("Hung", hung)
+		binder.Bind("Recorder", &stateRecordingService{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, err = locator.GetDService("Hung")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	recorder, err := GetD[*stateRecordingService](locator, "Recorder")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	err = locator.ShutdownContext(WithDestroyerTimeout(ctx, 50*time.Millisecond))
+	elapsed := time.Since(start)
+
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, elapsed < 5*time.Second, "shutdown should not wait for the hung destroyer %v", elapsed)
+
+	multi, ok := err.(MultiError)
+	if !assert.True(t, ok, "expected a MultiError") {
+		return
+	}
+	if !assert.Equal(t, 1, len(multi.GetErrors())) {
+		return
+	}
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(multi.GetErrors()[0]))
+
+	assert.Equal(t, LocatorStateShuttingDown, recorder.stateSeen)
+	assert.Equal(t, LocatorStateShutdown, locator.GetState())
+
+	again, err := NewServiceLocator(shutdownContextLocator1, FailIfPresent)
+	if assert.Nil(t, err, "locator name should be released even though a destroyer timed out") {
+		again.Shutdown()
+	}
+}
+
+func TestShutdownContextCancelled(t *testing.T) {
+	locator, err := CreateAndBind(shutdownContextLocator2, func(binder Binder) error {
+		binder.BindConstant("Destroyable", &destroyableService{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	destroyable, err := GetD[*destroyableService](locator, "Destroyable")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = locator.ShutdownContext(ctx)
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.Equal(t, context.Canceled, errors.Cause(err.(MultiError).GetErrors()[0]))
+
+	assert.False(t, destroyable.destroyed, "destroyers should not be started once the context is done")
+
+	// the shutdown may still be finishing in the background
+	for lcv := 0; lcv < 200 && locator.GetState() != LocatorStateShutdown; lcv++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, LocatorStateShutdown, locator.GetState())
+
+	assert.Nil(t, locator.ShutdownContext(context.Background()), "a second shutdown should do nothing")
+}
+
+func TestDefaultDestroyerTimeout(t *testing.T) {
+	assert.Equal(t, time.Duration(0), destroyerTimeout(context.Background()),
+		"destroyers should be called directly when there is no deadline")
+
+	cancellable, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	assert.Equal(t, DefaultDestroyerTimeout, destroyerTimeout(cancellable))
+	assert.Equal(t, time.Second, destroyerTimeout(WithDestroyerTimeout(context.Background(), time.Second)))
+	assert.Equal(t, time.Duration(0), destroyerTimeout(WithDestroyerTimeout(context.Background(), 0)))
+
+	release := make(chan bool)
+	defer close(release)
+
+	err := callDestroyer(WithDestroyerTimeout(context.Background(), 20*time.Millisecond), nil, func() error {
+		<-release
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err),
+		"a destroyer should be abandoned even when shutdown has no deadline")
+}
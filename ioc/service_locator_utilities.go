@@ -175,13 +175,26 @@ func EnableDargoContextScope(locator ServiceLocator) error {
 	}
 
 	return BindIntoLocator(locator, func(binder Binder) error {
-		binder.BindWithCreator(ContextScope, contextCreator).InNamespace(ContextualScopeNamespace).QualifiedBy(ContextScope)
+		binder.BindWithCreator(ContextScope, contextCreator).InNamespace(ContextualScopeNamespace).QualifiedBy(ContextScope).
+			WithMetadata(ProxiableMetadata, "true")
 		binder.Bind(DargoContextCreationServiceName, dargoContextCreationServiceData{}).InScope(ContextScope)
 
 		return nil
 	})
 }
 
+// EnableRunLevelScope adds the RunLevelScope and the RunLevelController to the
+// locator.  RunLevelScope services are not started until the RunLevelController
+// proceeds to their level
+func EnableRunLevelScope(locator ServiceLocator) error {
+	return BindIntoLocator(locator, func(binder Binder) error {
+		binder.Bind(RunLevelScope, &runLevelScopeData{}).InNamespace(ContextualScopeNamespace).QualifiedBy(RunLevelScope)
+		binder.Bind(RunLevelControllerName, &runLevelControllerData{}).InNamespace(SystemNamespace)
+
+		return nil
+	})
+}
+
 // UnbindDServices unbinds the services with the given names from the
 // default namespace
 func UnbindDServices(locator ServiceLocator, serviceNames ...string) error {
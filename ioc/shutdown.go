@@ -0,0 +1,127 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// ContextAwareScope may be implemented by a ContextualScope whose Shutdown
+// can honor the deadline and cancellation of ServiceLocator.ShutdownContext
+// and can report the errors from destroying its services.  When it is
+// implemented ShutdownContext is called instead of Shutdown.  All of the
+// system scopes implement this interface
+type ContextAwareScope interface {
+	// ShutdownContext is like ContextualScope.Shutdown except that it should
+	// stop destroying services once the given context is done, should give
+	// each destroyer no more time than WithDestroyerTimeout allows and should
+	// return the errors from destroying its services, possibly in a MultiError
+	ShutdownContext(ctx context.Context, locator ServiceLocator) error
+}
+
+// DefaultDestroyerTimeout is the time each destroy function or DargoDestroy
+// method may take when the context has a deadline or can be cancelled but has
+// no timeout from WithDestroyerTimeout
+const DefaultDestroyerTimeout = 30 * time.Second
+
+type destroyerTimeoutKey struct{}
+
+// WithDestroyerTimeout returns a context to be given to ServiceLocator.ShutdownContext
+// which limits the time each destroy function or DargoDestroy method may take,
+// in place of DefaultDestroyerTimeout.  A destroyer that does not finish in time
+// is abandoned and a timeout error is returned from ShutdownContext for it.  A
+// timeout of zero or less lets each destroyer run for as long as it takes
+func WithDestroyerTimeout(parent context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(parent, destroyerTimeoutKey{}, timeout)
+}
+
+// destroyerTimeout returns the time each destroyer may take with the given context.
+// When the caller set neither a deadline nor a destroyer timeout it is zero, so
+// that destroyers are called directly as they were before ShutdownContext
+func destroyerTimeout(ctx context.Context) time.Duration {
+	timeout, hasTimeout := ctx.Value(destroyerTimeoutKey{}).(time.Duration)
+	if !hasTimeout {
+		if ctx.Done() == nil {
+			return 0
+		}
+
+		return DefaultDestroyerTimeout
+	}
+
+	return timeout
+}
+
+// callDestroyer calls the destroyer, abandoning it if it takes longer than the
+// budget in the context.  If the context has no deadline, cannot be cancelled and
+// the destroyer timeout is turned off the destroyer is called directly
+func callDestroyer(ctx context.Context, desc Descriptor, destroyer func() error) error {
+	budget := ctx
+
+	timeout := destroyerTimeout(ctx)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		budget, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	if budget.Done() == nil {
+		return destroyer()
+	}
+
+	reply := make(chan error, 1)
+
+	_, err := threadManager.Go(func() {
+		reply <- destroyer()
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err = <-reply:
+		return err
+	case <-budget.Done():
+		return errors.Wrapf(budget.Err(), "destruction of %v did not complete in time", desc)
+	}
+}
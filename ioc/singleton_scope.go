@@ -41,6 +41,7 @@
 package ioc
 
 import (
+	"context"
 	"fmt"
 	"github.com/jwells131313/goethe/cache"
 )
@@ -84,19 +85,7 @@ func (single *singletonContextualData) ContainsKey(locator ServiceLocator, desc
 }
 
 func (single *singletonContextualData) DestroyOne(locator ServiceLocator, desc Descriptor) error {
-	lookForMe := idKey{desc: desc}
-
-	single.cache.Remove(func(key interface{}, value interface{}) bool {
-		if key == lookForMe {
-			single.actualDestruction(desc, value)
-
-			return true
-		}
-
-		return false
-	})
-
-	return nil
+	return destroyOne(single.locator, single.cache, desc)
 }
 
 func (single *singletonContextualData) GetSupportsNilCreation(locator ServiceLocator) bool {
@@ -108,49 +97,29 @@ func (single *singletonContextualData) IsActive(locator ServiceLocator) bool {
 }
 
 func (single *singletonContextualData) Shutdown(locator ServiceLocator) {
+	single.ShutdownContext(context.Background(), locator)
+}
+
+func (single *singletonContextualData) ShutdownContext(ctx context.Context, locator ServiceLocator) error {
 	tid := threadManager.GetThreadID()
 	if tid < 0 {
-		c := make(chan bool)
-
-		threadManager.Go(single.channelShutdown, c)
+		c := make(chan error)
 
-		<-c
+		threadManager.Go(single.channelShutdown, ctx, c)
 
-		return
+		return <-c
 	}
 
-	single.internalShutdown()
+	return single.internalShutdown(ctx)
 
 }
 
-func (single *singletonContextualData) channelShutdown(replyChan chan bool) {
-	single.internalShutdown()
-
-	replyChan <- true
+func (single *singletonContextualData) channelShutdown(ctx context.Context, replyChan chan error) {
+	replyChan <- single.internalShutdown(ctx)
 }
 
-func (single *singletonContextualData) internalShutdown() {
-	single.cache.Remove(func(key interface{}, value interface{}) bool {
-		idKey := key.(idKey)
-
-		single.actualDestruction(idKey.desc, value)
-
-		return true
-	})
-
-}
-
-func (single *singletonContextualData) actualDestruction(desc Descriptor, value interface{}) {
-	if desc == nil {
-		return
-	}
-
-	df := desc.GetDestroyFunction()
-	if df == nil {
-		return
-	}
-
-	df(single.locator, desc, value)
+func (single *singletonContextualData) internalShutdown(ctx context.Context) error {
+	return destroyAll(ctx, single.locator, single.cache)
 }
 
 func (single *singletonContextualData) Compute(in interface{}) (interface{}, error) {
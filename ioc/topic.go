@@ -0,0 +1,563 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"github.com/jwells131313/goethe"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	topicPoolName       = "DargoTopicPool"
+	topicPoolMaxThreads = 16
+	topicPoolIdleDecay  = 30 * time.Second
+	topicQueueCapacity  = 1024
+)
+
+// Subscriber is implemented by services bound with Binder.SubscribesTo.  It is
+// given the events published to the topics it subscribes to
+type Subscriber interface {
+	// OnEvent is called with each event published to a topic the service
+	// subscribes to.  An error returned, or a panic, is given to the
+	// ErrorService as a SubscriberFailure and does not affect the publisher
+	// or the other subscribers
+	OnEvent(event TopicEvent) error
+}
+
+// TopicEvent is an event delivered to a Subscriber
+type TopicEvent interface {
+	// GetNamespace returns the namespace of the topic the event was published to
+	GetNamespace() string
+
+	// GetTopic returns the name of the topic the event was published to
+	GetTopic() string
+
+	// GetQualifiers returns the qualifiers of the topic the event was published to
+	GetQualifiers() []string
+
+	// GetEvent returns the event that was published
+	GetEvent() interface{}
+}
+
+// Topic is used to publish events to the services subscribed to it.  A field of
+// type Topic with an inject tag is injected with the topic named by the tag, which
+// may have a namespace and qualifiers, such as `inject:"Orders@Priority"` or
+// `inject:"billing#Orders"`.  A topic with no namespace is in the DefaultNamespace,
+// and only subscribers to a topic of the same namespace and name get its events.
+// A subscriber to Orders@Priority only gets the events published to topics with the
+// Priority qualifier, while a subscriber to Orders gets all of the events of Orders.
+// Subscribers in the PerLookup scope are created for each event and destroyed after
+// it is delivered, while those in any other scope, including Singleton, only get
+// events while they exist, such as once a Singleton subscriber has been looked up
+// or while the context of a ContextScope subscriber is the current context.
+// Subscribers bound with Binder.BindConstant always exist
+type Topic interface {
+	// GetNamespace returns the namespace of the topic
+	GetNamespace() string
+
+	// GetName returns the name of the topic
+	GetName() string
+
+	// GetQualifiers returns the qualifiers of the topic
+	GetQualifiers() []string
+
+	// QualifiedBy returns a topic with the same name and with the
+	// qualifier added to the qualifiers of this topic
+	QualifiedBy(qualifier string) Topic
+
+	// Publish delivers the event to each subscriber, highest rank first,
+	// returning once they have all been given the event
+	Publish(event interface{}) error
+
+	// PublishAsync finds the subscribers of the topic and then delivers the
+	// event to them, highest rank first, on a goethe thread pool of the locator.
+	// It returns without waiting for the event to be delivered.  The pool is
+	// closed when the locator is shut down, and events it has not yet
+	// delivered by then are dropped
+	PublishAsync(event interface{}) error
+}
+
+type topicEventData struct {
+	namespace  string
+	topic      string
+	qualifiers []string
+	event      interface{}
+}
+
+func (ted *topicEventData) GetNamespace() string {
+	return ted.namespace
+}
+
+func (ted *topicEventData) GetTopic() string {
+	return ted.topic
+}
+
+func (ted *topicEventData) GetQualifiers() []string {
+	retVal := make([]string, len(ted.qualifiers))
+	copy(retVal, ted.qualifiers)
+
+	return retVal
+}
+
+func (ted *topicEventData) GetEvent() interface{} {
+	return ted.event
+}
+
+type topicData struct {
+	locator    *serviceLocatorData
+	namespace  string
+	name       string
+	qualifiers []string
+}
+
+// NewTopic returns the topic of the locator with the given namespace, name and qualifiers
+func NewTopic(locator ServiceLocator, namespace, name string, qualifiers ...string) (Topic, error) {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return nil, fmt.Errorf("unknown service locator type")
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("a topic must have a name")
+	}
+
+	err := checkNamespaceCharacters(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTopic(iLocator, namespace, name, qualifiers), nil
+}
+
+// NewDTopic returns the topic of the locator in the default namespace with the
+// given name and qualifiers
+func NewDTopic(locator ServiceLocator, name string, qualifiers ...string) (Topic, error) {
+	return NewTopic(locator, DefaultNamespace, name, qualifiers...)
+}
+
+func newTopic(locator *serviceLocatorData, namespace, name string, qualifiers []string) Topic {
+	cpy := make([]string, len(qualifiers))
+	copy(cpy, qualifiers)
+
+	return &topicData{
+		locator:    locator,
+		namespace:  namespace,
+		name:       name,
+		qualifiers: cpy,
+	}
+}
+
+func (topic *topicData) GetNamespace() string {
+	return topic.namespace
+}
+
+func (topic *topicData) GetName() string {
+	return topic.name
+}
+
+func (topic *topicData) GetQualifiers() []string {
+	retVal := make([]string, len(topic.qualifiers))
+	copy(retVal, topic.qualifiers)
+
+	return retVal
+}
+
+func (topic *topicData) QualifiedBy(qualifier string) Topic {
+	return newTopic(topic.locator, topic.namespace, topic.name, append(topic.GetQualifiers(), qualifier))
+}
+
+func (topic *topicData) Publish(event interface{}) error {
+	deliveries, err := topic.getDeliveries()
+	if err != nil {
+		return err
+	}
+
+	topic.deliver(deliveries, event)
+
+	return nil
+}
+
+func (topic *topicData) PublishAsync(event interface{}) error {
+	deliveries, err := topic.getDeliveries()
+	if err != nil {
+		return err
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	pool, err := topic.locator.getTopicPool()
+	if err != nil {
+		return err
+	}
+
+	return pool.GetFunctionQueue().Enqueue(func() {
+		topic.deliver(deliveries, event)
+	})
+}
+
+// delivery is a subscriber found when an event was published
+type delivery struct {
+	desc       Descriptor
+	subscriber Subscriber
+	service    interface{}
+	perLookup  bool
+}
+
+// getDeliveries finds the subscribers of the topic in the scopes of the publisher
+func (topic *topicData) getDeliveries() ([]*delivery, error) {
+	descs, err := topic.locator.GetDescriptors(&subscriberFilter{
+		namespace:  topic.namespace,
+		topic:      topic.name,
+		qualifiers: topic.qualifiers,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]*delivery, 0, len(descs))
+	for _, desc := range descs {
+		scope := desc.GetScope()
+		if scope != PerLookup && !isConstantDescriptor(desc) && !topic.locator.isInstantiated(desc) {
+			continue
+		}
+
+		raw, err := topic.locator.GetServiceFromDescriptor(desc)
+		if err != nil {
+			topic.locator.runErrorHandlers(SubscriberFailure, desc, nil, nil,
+				fmt.Errorf("could not get subscriber %s of topic %s: %v", desc.GetFullName(), topic.name, err))
+			continue
+		}
+
+		subscriber, ok := raw.(Subscriber)
+		if !ok {
+			topic.locator.runErrorHandlers(SubscriberFailure, desc, nil, nil,
+				fmt.Errorf("service %s subscribes to topic %s but does not implement Subscriber",
+					desc.GetFullName(), topic.name))
+			continue
+		}
+
+		retVal = append(retVal, &delivery{
+			desc:       desc,
+			subscriber: subscriber,
+			service:    raw,
+			perLookup:  scope == PerLookup,
+		})
+	}
+
+	return retVal, nil
+}
+
+func (topic *topicData) deliver(deliveries []*delivery, event interface{}) {
+	topicEvent := &topicEventData{
+		namespace:  topic.namespace,
+		topic:      topic.name,
+		qualifiers: topic.GetQualifiers(),
+		event:      event,
+	}
+
+	for _, current := range deliveries {
+		ret := &errorReturn{}
+		safeOnEvent(current.subscriber, topicEvent, ret)
+		if ret.err != nil {
+			topic.locator.runErrorHandlers(SubscriberFailure, current.desc, reflect.TypeOf(event), nil,
+				fmt.Errorf("subscriber %s of topic %s failed: %v", current.desc.GetFullName(), topic.name, ret.err))
+		}
+
+		if current.perLookup {
+			// Errors are given to the error services by destroyService
+			destroyService(context.Background(), topic.locator, current.desc, current.service)
+		}
+	}
+}
+
+func safeOnEvent(subscriber Subscriber, event TopicEvent, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	ret.err = subscriber.OnEvent(event)
+}
+
+// subscriberFilter finds the services that subscribe to a topic
+type subscriberFilter struct {
+	namespace  string
+	topic      string
+	qualifiers []string
+}
+
+// Filter returns true if the descriptor subscribes to the topic in the same
+// namespace with qualifiers that are all qualifiers of the topic
+func (filter *subscriberFilter) Filter(desc Descriptor) bool {
+	for _, value := range desc.GetMetadata()[SubscribesToMetadata] {
+		namespace := DefaultNamespace
+		namespaceAndTopic := strings.SplitN(value, "#", 2)
+		if len(namespaceAndTopic) == 2 {
+			namespace = namespaceAndTopic[0]
+			value = namespaceAndTopic[1]
+		}
+
+		parts := strings.Split(value, "@")
+		if namespace != filter.namespace || parts[0] != filter.topic {
+			continue
+		}
+
+		if containsAll(filter.qualifiers, parts[1:]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (filter *subscriberFilter) GetNamespace() string {
+	return ""
+}
+
+func (filter *subscriberFilter) GetName() string {
+	return ""
+}
+
+func containsAll(have []string, want []string) bool {
+	for _, w := range want {
+		found := false
+		for _, h := range have {
+			if h == w {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+var topicPoolLock sync.Mutex
+
+func (locator *serviceLocatorData) getTopicPoolName() string {
+	return fmt.Sprintf("%s-%d", topicPoolName, locator.ID)
+}
+
+// getTopicPool returns the pool asynchronous events of this locator are delivered
+// on, starting it if needed
+func (locator *serviceLocatorData) getTopicPool() (goethe.Pool, error) {
+	topicPoolLock.Lock()
+	defer topicPoolLock.Unlock()
+
+	if locator.GetState() != LocatorStateRunning {
+		return nil, fmt.Errorf("events can not be published asynchronously once %s is shut down", locator.name)
+	}
+
+	pool, found := threadManager.GetPool(locator.getTopicPoolName())
+	if found {
+		return pool, nil
+	}
+
+	pool, err := threadManager.NewPool(locator.getTopicPoolName(), 0, topicPoolMaxThreads, topicPoolIdleDecay,
+		goethe.NewBoundedFunctionQueue(topicQueueCapacity), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pool.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// closeTopicPool closes the pool of this locator, if it was started
+func (locator *serviceLocatorData) closeTopicPool() {
+	topicPoolLock.Lock()
+	defer topicPoolLock.Unlock()
+
+	pool, found := threadManager.GetPool(locator.getTopicPoolName())
+	if found {
+		pool.Close()
+	}
+}
+
+// TypedTopic is the type-safe version of Topic.  A field of type TypedTopic[T]
+// (or *TypedTopic[T]) with an inject tag is injected in the same way as a
+// field of type Topic, but only events of type T may be published to it
+type TypedTopic[T any] struct {
+	topic Topic
+}
+
+type typedTopicSetter interface {
+	setTopic(Topic)
+}
+
+// NewTypedTopic wraps a Topic such that only events of type T may be published to it
+func NewTypedTopic[T any](topic Topic) TypedTopic[T] {
+	return TypedTopic[T]{
+		topic: topic,
+	}
+}
+
+// GetNamespace returns the namespace of the topic
+func (tt TypedTopic[T]) GetNamespace() string {
+	if tt.topic == nil {
+		return ""
+	}
+
+	return tt.topic.GetNamespace()
+}
+
+// GetName returns the name of the topic
+func (tt TypedTopic[T]) GetName() string {
+	if tt.topic == nil {
+		return ""
+	}
+
+	return tt.topic.GetName()
+}
+
+// GetQualifiers returns the qualifiers of the topic
+func (tt TypedTopic[T]) GetQualifiers() []string {
+	if tt.topic == nil {
+		return []string{}
+	}
+
+	return tt.topic.GetQualifiers()
+}
+
+// QualifiedBy returns a TypedTopic with the same name and with the
+// qualifier added to the qualifiers of this topic
+func (tt TypedTopic[T]) QualifiedBy(qualifier string) TypedTopic[T] {
+	if tt.topic == nil {
+		return tt
+	}
+
+	return TypedTopic[T]{
+		topic: tt.topic.QualifiedBy(qualifier),
+	}
+}
+
+// Publish delivers the event to each subscriber, returning once they
+// have all been given the event
+func (tt TypedTopic[T]) Publish(event T) error {
+	if tt.topic == nil {
+		return fmt.Errorf("this TypedTopic has not been injected")
+	}
+
+	return tt.topic.Publish(event)
+}
+
+// PublishAsync delivers the event to the subscribers on a goethe thread pool
+func (tt TypedTopic[T]) PublishAsync(event T) error {
+	if tt.topic == nil {
+		return fmt.Errorf("this TypedTopic has not been injected")
+	}
+
+	return tt.topic.PublishAsync(event)
+}
+
+func (tt *TypedTopic[T]) setTopic(topic Topic) {
+	tt.topic = topic
+}
+
+func isTopic(ty reflect.Type) bool {
+	if ty == nil {
+		return false
+	}
+
+	topicType := reflect.TypeOf((*Topic)(nil)).Elem()
+	return ty == topicType
+}
+
+// isTypedTopic returns true if the type is TypedTopic[T] or *TypedTopic[T]
+func isTypedTopic(ty reflect.Type) bool {
+	if ty == nil {
+		return false
+	}
+
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	if ty.Kind() != reflect.Struct {
+		return false
+	}
+
+	setterType := reflect.TypeOf((*typedTopicSetter)(nil)).Elem()
+	return reflect.PtrTo(ty).Implements(setterType)
+}
+
+// newTopicValue returns the value to inject into a Topic, TypedTopic[T]
+// or *TypedTopic[T] for the parsed inject tag
+func newTopicValue(locator *serviceLocatorData, ty reflect.Type, pd *parseData) (reflect.Value, error) {
+	if pd.byType || pd.all || pd.proxy {
+		return reflect.Value{}, fmt.Errorf("a topic must be injected with the name of the topic and no options")
+	}
+
+	topic := newTopic(locator, pd.serviceKey.GetNamespace(), pd.serviceKey.GetName(), pd.serviceKey.GetQualifiers())
+	if isTopic(ty) {
+		return reflect.ValueOf(&topic).Elem(), nil
+	}
+
+	isPointer := ty.Kind() == reflect.Ptr
+	if isPointer {
+		ty = ty.Elem()
+	}
+
+	retVal := reflect.New(ty)
+	retVal.Interface().(typedTopicSetter).setTopic(topic)
+
+	if isPointer {
+		return retVal, nil
+	}
+
+	return retVal.Elem(), nil
+}
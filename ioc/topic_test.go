@@ -0,0 +1,340 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	topicLocator1 = "TopicLocator1"
+	topicLocator2 = "TopicLocator2"
+	topicLocator3 = "TopicLocator3"
+)
+
+type order struct {
+	id int
+}
+
+type orderPublisher struct {
+	Orders   Topic              `inject:"Orders"`
+	Priority TypedTopic[*order] `inject:"Orders@Priority"`
+	Billing  Topic              `inject:"billing#Orders"`
+}
+
+type orderRecorder struct {
+	lock      sync.Mutex
+	events    []TopicEvent
+	delivered chan TopicEvent
+}
+
+func (or *orderRecorder) OnEvent(event TopicEvent) error {
+	or.lock.Lock()
+	or.events = append(or.events, event)
+	or.lock.Unlock()
+
+	if or.delivered != nil {
+		or.delivered <- event
+	}
+
+	return nil
+}
+
+func (or *orderRecorder) getEvents() []TopicEvent {
+	or.lock.Lock()
+	defer or.lock.Unlock()
+
+	retVal := make([]TopicEvent, len(or.events))
+	copy(retVal, or.events)
+
+	return retVal
+}
+
+var perLookupSubscribers struct {
+	lock      sync.Mutex
+	received  int
+	destroyed int
+}
+
+type perLookupSubscriber struct {
+}
+
+func (pls *perLookupSubscriber) OnEvent(event TopicEvent) error {
+	perLookupSubscribers.lock.Lock()
+	defer perLookupSubscribers.lock.Unlock()
+
+	perLookupSubscribers.received++
+
+	return nil
+}
+
+func (pls *perLookupSubscriber) DargoDestroy(Descriptor) error {
+	perLookupSubscribers.lock.Lock()
+	defer perLookupSubscribers.lock.Unlock()
+
+	perLookupSubscribers.destroyed++
+
+	return nil
+}
+
+type panickySubscriber struct {
+}
+
+func (ps *panickySubscriber) OnEvent(event TopicEvent) error {
+	panic("subscriber panic")
+}
+
+type failingSubscriber struct {
+}
+
+func (fs *failingSubscriber) OnEvent(event TopicEvent) error {
+	return fmt.Errorf("subscriber failure")
+}
+
+type recordingErrorService struct {
+	lock   sync.Mutex
+	errors []ErrorInformation
+}
+
+func (res *recordingErrorService) OnFailure(ei ErrorInformation) error {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+
+	res.errors = append(res.errors, ei)
+
+	return nil
+}
+
+func (res *recordingErrorService) getErrors() []ErrorInformation {
+	res.lock.Lock()
+	defer res.lock.Unlock()
+
+	retVal := make([]ErrorInformation, len(res.errors))
+	copy(retVal, res.errors)
+
+	return retVal
+}
+
+func TestTopicPublish(t *testing.T) {
+	all := &orderRecorder{}
+	priority := &orderRecorder{}
+	billing := &orderRecorder{}
+	errorService := &recordingErrorService{}
+
+	locator, err := CreateAndBind(topicLocator1, func(binder Binder) error {
+		binder.BindConstant(ErrorServiceName, errorService).InNamespace(UserServicesNamespace)
+		binder.Bind("Publisher", orderPublisher{})
+		binder.BindConstant("AllOrders", all).SubscribesTo("Orders").Ranked(10)
+		binder.BindConstant("PriorityOrders", priority).SubscribesTo("Orders@Priority")
+		binder.Bind("PerLookupOrders", perLookupSubscriber{}).InScope(PerLookup).SubscribesTo("Orders")
+		binder.Bind("Panicky", panickySubscriber{}).SubscribesTo("Orders").Ranked(20)
+		binder.Bind("Failing", failingSubscriber{}).SubscribesTo("Orders@Priority")
+		binder.BindConstant("Unrelated", &orderRecorder{}).SubscribesTo("Invoices")
+		binder.BindConstant("BillingOrders", billing).SubscribesTo("billing#Orders")
+		binder.Bind("Lazy", orderRecorder{}).SubscribesTo("Orders")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	raw, err := locator.GetDService("Publisher")
+	if !assert.Nil(t, err) {
+		return
+	}
+	publisher := raw.(*orderPublisher)
+
+	assert.Equal(t, "Orders", publisher.Orders.GetName())
+	assert.Equal(t, DefaultNamespace, publisher.Orders.GetNamespace())
+	assert.Equal(t, []string{"Priority"}, publisher.Priority.GetQualifiers())
+	assert.Equal(t, "billing", publisher.Billing.GetNamespace())
+
+	// Singleton subscribers only get events once they have been created
+	_, err = locator.GetDService("Panicky")
+	assert.Nil(t, err)
+	_, err = locator.GetDService("Failing")
+	assert.Nil(t, err)
+
+	perLookupSubscribers.lock.Lock()
+	perLookupSubscribers.received = 0
+	perLookupSubscribers.destroyed = 0
+	perLookupSubscribers.lock.Unlock()
+
+	assert.Nil(t, publisher.Orders.Publish(&order{id: 1}))
+	assert.Nil(t, publisher.Priority.Publish(&order{id: 2}))
+	assert.Nil(t, publisher.Billing.Publish(&order{id: 3}))
+
+	lazyDesc, err := locator.GetBestDescriptor(NewSingleFilter(DefaultNamespace, "Lazy"))
+	if assert.Nil(t, err) {
+		assert.False(t, locator.(*serviceLocatorData).isInstantiated(lazyDesc),
+			"publishing does not create Singleton subscribers")
+	}
+
+	allEvents := all.getEvents()
+	if assert.Equal(t, 2, len(allEvents)) {
+		assert.Equal(t, 1, allEvents[0].GetEvent().(*order).id)
+		assert.Equal(t, "Orders", allEvents[0].GetTopic())
+		assert.Equal(t, []string{}, allEvents[0].GetQualifiers())
+		assert.Equal(t, []string{"Priority"}, allEvents[1].GetQualifiers())
+	}
+
+	billingEvents := billing.getEvents()
+	if assert.Equal(t, 1, len(billingEvents), "a topic only reaches subscribers in its namespace") {
+		assert.Equal(t, 3, billingEvents[0].GetEvent().(*order).id)
+		assert.Equal(t, "billing", billingEvents[0].GetNamespace())
+	}
+
+	priorityEvents := priority.getEvents()
+	if assert.Equal(t, 1, len(priorityEvents)) {
+		assert.Equal(t, 2, priorityEvents[0].GetEvent().(*order).id)
+	}
+
+	perLookupSubscribers.lock.Lock()
+	assert.Equal(t, 2, perLookupSubscribers.received)
+	assert.Equal(t, 2, perLookupSubscribers.destroyed, "a PerLookup subscriber is destroyed after each event")
+	perLookupSubscribers.lock.Unlock()
+
+	failures := errorService.getErrors()
+	failed := make(map[string]int)
+	for _, failure := range failures {
+		if assert.Equal(t, SubscriberFailure, failure.GetType()) {
+			failed[failure.GetDescriptor().GetName()]++
+		}
+	}
+	assert.Equal(t, map[string]int{"Panicky": 2, "Failing": 1}, failed)
+
+	assert.Nil(t, Verify(locator))
+}
+
+type asyncPublisher struct {
+	orders *TypedTopic[int]
+}
+
+func newAsyncPublisher(orders *TypedTopic[int]) *asyncPublisher {
+	return &asyncPublisher{
+		orders: orders,
+	}
+}
+
+func TestTopicPublishAsync(t *testing.T) {
+	recorder := &orderRecorder{
+		delivered: make(chan TopicEvent, 1),
+	}
+
+	locator, err := CreateAndBind(topicLocator2, func(binder Binder) error {
+		binder.BindConstructor("Publisher", newAsyncPublisher, "Numbers")
+		binder.BindConstant("Recorder", recorder).SubscribesTo("Numbers")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	publisher, err := GetD[*asyncPublisher](locator, "Publisher")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Nil(t, publisher.orders.PublishAsync(42))
+
+	select {
+	case event := <-recorder.delivered:
+		assert.Equal(t, 42, event.GetEvent())
+		assert.Equal(t, "Numbers", event.GetTopic())
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "the event was not delivered")
+	}
+
+	poolName := locator.(*serviceLocatorData).getTopicPoolName()
+	_, found := threadManager.GetPool(poolName)
+	assert.True(t, found)
+
+	locator.Shutdown()
+
+	_, found = threadManager.GetPool(poolName)
+	assert.False(t, found, "the pool is closed when the locator is shut down")
+	assert.NotNil(t, publisher.orders.PublishAsync(43))
+}
+
+type badTopicUser struct {
+	Orders Topic `inject:"Orders,all"`
+}
+
+func TestTopicInjectionErrors(t *testing.T) {
+	locator, err := CreateAndBind(topicLocator3, func(binder Binder) error {
+		binder.Bind("BadTopicUser", badTopicUser{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	_, err = locator.GetDService("BadTopicUser")
+	assert.NotNil(t, err)
+
+	found := verifyErrorsOf(t, Verify(locator))
+	assert.Equal(t, 1, len(found[VerificationInvalidInjectionPoint]))
+
+	_, err = NewDTopic(locator, "")
+	assert.NotNil(t, err)
+
+	_, err = NewTopic(locator, "bad namespace", "Orders")
+	assert.NotNil(t, err)
+
+	topic, err := NewDTopic(locator, "Orders", "A")
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"A", "B"}, topic.QualifiedBy("B").GetQualifiers())
+		assert.Nil(t, topic.Publish("no subscribers"))
+	}
+
+	var unset TypedTopic[string]
+	assert.NotNil(t, unset.Publish("not injected"))
+}
@@ -0,0 +1,251 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// Get returns the best service with the given key as a T.  If the service
+// found does not implement T then an error implementing ServiceTypeInfo is
+// returned rather than causing a panic
+func Get[T any](locator ServiceLocator, key ServiceKey) (T, error) {
+	raw, err := locator.GetService(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return convertService[T](key, raw)
+}
+
+// GetD returns the best service with the given name in the default namespace
+// and with the provided qualifiers as a T.  If the service found does not
+// implement T then an error implementing ServiceTypeInfo is returned
+func GetD[T any](locator ServiceLocator, name string, qualifiers ...string) (T, error) {
+	return Get[T](locator, DSK(name, qualifiers...))
+}
+
+// GetInContext returns the best service with the given key as a T, as found
+// with the dargo context of ctx
+func GetInContext[T any](ctx context.Context, key ServiceKey) (T, error) {
+	raw, err := GetServiceInContext(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return convertService[T](key, raw)
+}
+
+// GetByType returns the best service whose implementation type is T or,
+// if T is an interface, implements T.  If the service found is not a T, such
+// as when a Decorator has replaced it with a value of another type, then an
+// error implementing ServiceTypeInfo is returned
+func GetByType[T any](locator ServiceLocator, qualifiers ...string) (T, error) {
+	var zero T
+
+	ty := reflect.TypeOf((*T)(nil)).Elem()
+	raw, err := locator.GetServiceByType(ty, qualifiers...)
+	if err != nil {
+		return zero, err
+	}
+
+	if raw == nil {
+		return zero, nil
+	}
+
+	return raw.(T), nil
+}
+
+// GetAll returns all the services matching the service key as a slice of T.
+// Any service that does not implement T is left out of the returned slice and
+// an error implementing ServiceTypeInfo is added to the returned MultiError
+func GetAll[T any](locator ServiceLocator, key ServiceKey) ([]T, error) {
+	raws, err := locator.GetAllServices(key)
+
+	return convertServices[T](key, raws, err)
+}
+
+// TypedProvider is the type-safe version of Provider.  A field of type
+// TypedProvider[T] (or *TypedProvider[T]) with an inject tag is injected in
+// the same way as a field of type Provider, but the services it returns
+// are returned as a T
+type TypedProvider[T any] struct {
+	provider Provider
+}
+
+type typedProviderSetter interface {
+	setProvider(Provider)
+}
+
+// NewTypedProvider wraps a Provider such that its services are returned
+// as a T
+func NewTypedProvider[T any](provider Provider) TypedProvider[T] {
+	return TypedProvider[T]{
+		provider: provider,
+	}
+}
+
+// Get gets the best implementation of the service associated with
+// this injected service as a T
+func (tp TypedProvider[T]) Get() (T, error) {
+	if tp.provider == nil {
+		var zero T
+		return zero, fmt.Errorf("this TypedProvider has not been injected")
+	}
+
+	raw, err := tp.provider.Get()
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return convertService[T](tp.getKey(), raw)
+}
+
+// GetAll gets all the implementations of the service associated with
+// this injected service as a slice of T
+func (tp TypedProvider[T]) GetAll() ([]T, error) {
+	if tp.provider == nil {
+		return nil, fmt.Errorf("this TypedProvider has not been injected")
+	}
+
+	raws, err := tp.provider.GetAll()
+
+	return convertServices[T](tp.getKey(), raws, err)
+}
+
+// QualifiedBy returns a further specified TypedProvider for which the Get
+// or GetAll methods will include the qualifier provided
+func (tp TypedProvider[T]) QualifiedBy(qualifier string) TypedProvider[T] {
+	if tp.provider == nil {
+		return tp
+	}
+
+	return TypedProvider[T]{
+		provider: tp.provider.QualifiedBy(qualifier),
+	}
+}
+
+func (tp *TypedProvider[T]) setProvider(provider Provider) {
+	tp.provider = provider
+}
+
+func (tp TypedProvider[T]) getKey() ServiceKey {
+	pd, ok := tp.provider.(*providerData)
+	if !ok {
+		return DSK("Unknown")
+	}
+
+	return pd.key
+}
+
+func convertService[T any](key ServiceKey, raw interface{}) (T, error) {
+	var zero T
+	if raw == nil {
+		return zero, nil
+	}
+
+	retVal, ok := raw.(T)
+	if !ok {
+		return zero, NewServiceTypeError(key, reflect.TypeOf((*T)(nil)).Elem(), raw)
+	}
+
+	return retVal, nil
+}
+
+func convertServices[T any](key ServiceKey, raws []interface{}, err error) ([]T, error) {
+	retErr := NewMultiError()
+	if err != nil {
+		retErr.AddError(err)
+	}
+
+	retVal := make([]T, 0, len(raws))
+	for _, raw := range raws {
+		typed, err := convertService[T](key, raw)
+		if err != nil {
+			retErr.AddError(err)
+		} else {
+			retVal = append(retVal, typed)
+		}
+	}
+
+	return retVal, retErr.GetFinalError()
+}
+
+// isTypedProvider returns true if the type is TypedProvider[T] or *TypedProvider[T]
+func isTypedProvider(ty reflect.Type) bool {
+	if ty == nil {
+		return false
+	}
+
+	if ty.Kind() == reflect.Ptr {
+		ty = ty.Elem()
+	}
+
+	if ty.Kind() != reflect.Struct {
+		return false
+	}
+
+	setterType := reflect.TypeOf((*typedProviderSetter)(nil)).Elem()
+	return reflect.PtrTo(ty).Implements(setterType)
+}
+
+// newTypedProviderValue creates a value of the given TypedProvider[T] or
+// *TypedProvider[T] type that wraps the given Provider
+func newTypedProviderValue(ty reflect.Type, provider Provider) reflect.Value {
+	isPointer := ty.Kind() == reflect.Ptr
+	if isPointer {
+		ty = ty.Elem()
+	}
+
+	retVal := reflect.New(ty)
+	retVal.Interface().(typedProviderSetter).setProvider(provider)
+
+	if isPointer {
+		return retVal
+	}
+
+	return retVal.Elem()
+}
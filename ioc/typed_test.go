@@ -0,0 +1,204 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+const (
+	typedLocator1 = "TypedLocator1"
+	typedLocator2 = "TypedLocator2"
+	typedLocator3 = "TypedLocator3"
+	typedLocator4 = "TypedLocator4"
+)
+
+type typedRainbowService struct {
+	Colors    TypedProvider[ColorService]  `inject:"ColorService"`
+	ColorsPtr *TypedProvider[ColorService] `inject:"ColorService"`
+	Wrong     TypedProvider[*Service]      `inject:"ColorService"`
+}
+
+func TestTypedGet(t *testing.T) {
+	locator, err := CreateAndBind(typedLocator1, func(binder Binder) error {
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BRed)
+		binder.Bind("Service", &Service{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	color, err := GetD[ColorService](locator, ColorServiceName)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, BRed, color.GetColor())
+
+	service, err := Get[*Service](locator, DSK("Service"))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.NotNil(t, service)
+
+	_, err = GetD[ColorService](locator, "Service")
+	if !assert.NotNil(t, err) {
+		return
+	}
+	assert.True(t, IsServiceTypeMismatch(err))
+
+	info, ok := err.(ServiceTypeInfo)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, "Service", info.GetServiceKey().GetName())
+	assert.Equal(t, "ColorService", info.GetExpectedType().Name())
+
+	_, err = GetD[ColorService](locator, "NotThere")
+	assert.True(t, IsServiceNotFound(err))
+	assert.False(t, IsServiceTypeMismatch(err))
+}
+
+func TestTypedGetAll(t *testing.T) {
+	locator, err := CreateAndBind(typedLocator2, func(binder Binder) error {
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BRed)
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BBlue)
+		binder.Bind(ColorServiceName, &Service{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	colors, err := GetAll[ColorService](locator, DSK(ColorServiceName))
+	assert.True(t, IsServiceTypeMismatch(err), "one of the services is not a ColorService")
+	assert.Equal(t, 2, len(colors))
+
+	colors, err = GetAll[ColorService](locator, DSK(ColorServiceName, BBlue))
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !assert.Equal(t, 1, len(colors)) {
+		return
+	}
+	assert.Equal(t, BBlue, colors[0].GetColor())
+}
+
+func TestTypedProviderInjection(t *testing.T) {
+	locator, err := CreateAndBind(typedLocator3, func(binder Binder) error {
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BRed)
+		binder.Bind(ColorServiceName, colorServiceData{}).QualifiedBy(BBlue)
+		binder.Bind(RainbowName, &typedRainbowService{})
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	rainbow, err := GetD[*typedRainbowService](locator, RainbowName)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	all, err := rainbow.Colors.GetAll()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, 2, len(all))
+
+	blue, err := rainbow.ColorsPtr.QualifiedBy(BBlue).Get()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, BBlue, blue.GetColor())
+
+	_, err = rainbow.Wrong.Get()
+	assert.True(t, IsServiceTypeMismatch(err))
+
+	var notInjected TypedProvider[ColorService]
+	_, err = notInjected.Get()
+	assert.NotNil(t, err)
+}
+
+type typedDecoratedImpl struct {
+}
+
+type typedDecoratedWrapper struct {
+	inner interface{}
+}
+
+type typedWrappingDecorator struct {
+}
+
+func (twd *typedWrappingDecorator) Decorate(desc Descriptor, service interface{}) (interface{}, error) {
+	return &typedDecoratedWrapper{inner: service}, nil
+}
+
+func TestTypedGetByTypeOfDecoratedService(t *testing.T) {
+	locator, err := CreateAndBind(typedLocator4, func(binder Binder) error {
+		binder.Bind("Impl", &typedDecoratedImpl{})
+		binder.Bind("Wrapper", &typedWrappingDecorator{}).Decorates("Impl")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	var impl *typedDecoratedImpl
+	assert.NotPanics(t, func() {
+		impl, err = GetByType[*typedDecoratedImpl](locator)
+	})
+	assert.Nil(t, impl)
+	if !assert.True(t, IsServiceTypeMismatch(err)) {
+		return
+	}
+
+	info := err.(ServiceTypeInfo)
+	assert.Equal(t, "Impl", info.GetServiceKey().GetName())
+	assert.Equal(t, reflect.TypeOf(&typedDecoratedWrapper{}), info.GetActualType())
+}
@@ -79,6 +79,60 @@ func IsServiceNotFound(e error) bool {
 	return false
 }
 
+// IsServiceTypeMismatch returns true if the given error is due to a service
+// being found that was not of the expected type.  If the incoming error is a
+// MultiError this will return true if any of the contained errors is
+// a ServiceTypeInfo
+func IsServiceTypeMismatch(e error) bool {
+	if e == nil {
+		return false
+	}
+
+	_, ok := e.(ServiceTypeInfo)
+	if ok {
+		return true
+	}
+
+	multi, ok := e.(MultiError)
+	if ok {
+		for _, e := range multi.GetErrors() {
+			_, ok = e.(ServiceTypeInfo)
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// IsAmbiguousService returns true if the given error is due to more than
+// one service matching a lookup by type.  If the incoming error is a
+// MultiError this will return true if any of the contained errors is
+// an AmbiguousServiceInfo
+func IsAmbiguousService(e error) bool {
+	if e == nil {
+		return false
+	}
+
+	_, ok := e.(AmbiguousServiceInfo)
+	if ok {
+		return true
+	}
+
+	multi, ok := e.(MultiError)
+	if ok {
+		for _, e := range multi.GetErrors() {
+			_, ok = e.(AmbiguousServiceInfo)
+			if ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 type stackData struct {
 	lock  sync.Mutex
 	stack []interface{}
@@ -216,26 +270,10 @@ func createAndInject(locator *serviceLocatorData, desc Descriptor, dity reflect.
 
 	iFace := retVal.Interface()
 
-	initializer, ok := iFace.(DargoInitializer)
-	if preCreated == nil && ok {
-		errRet := &errorReturn{}
-		safeDargoInitialize(initializer, desc, errRet)
-		err := errRet.err
-
+	if preCreated == nil {
+		err := initializeService(locator, desc, dity, iFace)
 		if err != nil {
-			_, isMulti := err.(MultiError)
-			if !isMulti {
-				err = NewMultiError(err)
-			}
-
-			locator.runErrorHandlers(ServiceCreationFailure, desc, dity, nil, err)
-
-			replyError := &hasRunHandlers{
-				hasRunHandlers:  true,
-				underlyingError: err.(MultiError),
-			}
-
-			return nil, replyError
+			return nil, err
 		}
 	}
 
@@ -331,6 +369,33 @@ func isConfigurationListener(desc Descriptor) bool {
 	return false
 }
 
+func isInterceptionService(desc Descriptor) bool {
+	if UserServicesNamespace == desc.GetNamespace() &&
+		InterceptionServiceName == desc.GetName() {
+		return true
+	}
+
+	return false
+}
+
+func isInstanceLifecycleListener(desc Descriptor) bool {
+	if UserServicesNamespace == desc.GetNamespace() &&
+		InstanceLifecycleListenerName == desc.GetName() {
+		return true
+	}
+
+	return false
+}
+
+func isJustInTimeResolver(desc Descriptor) bool {
+	if UserServicesNamespace == desc.GetNamespace() &&
+		JustInTimeResolverName == desc.GetName() {
+		return true
+	}
+
+	return false
+}
+
 func isInjectionResolver(desc Descriptor) bool {
 	if UserServicesNamespace == desc.GetNamespace() &&
 		InjectionResolverName == desc.GetName() {
@@ -347,14 +412,34 @@ func descriptorToIDString(desc Descriptor) string {
 type parseData struct {
 	serviceKey ServiceKey
 	isOptional bool
+	byType     bool
+	qualifiers []string
+	all        bool
+	strict     bool
+	mapKey     string
+	proxy      bool
 }
 
-func parseInjectString(parseMe string) (*parseData, error) {
-	if parseMe == "" {
-		return nil, fmt.Errorf("no injection string to parse")
-	}
+const (
+	// injectByTypeName is the name used in an inject tag to indicate that
+	// the service should be found by the type of the field, as does an empty name
+	injectByTypeName = "type"
+
+	// injectMapKeyOption is the option used in an inject tag to give the
+	// metadata key to use as the key of a map of services
+	injectMapKeyOption = "mapkey="
+)
 
+// parseInjectString parses namespace#name@qualifier,options.  When the name
+// is empty or is type, and no namespace is given, the service is looked up by
+// type, in which case the serviceKey will be nil.  The options are optional,
+// all, strict, mapkey=<metadata key> and proxy
+func parseInjectString(parseMe string) (*parseData, error) {
 	isOptional := false
+	all := false
+	proxy := false
+	strict := false
+	mapKey := ""
 
 	namespaceAndName := []string{}
 
@@ -362,15 +447,32 @@ func parseInjectString(parseMe string) (*parseData, error) {
 	for index, value := range namespaceNameQualifiersOptions {
 		if index == 0 {
 			namespaceAndName = strings.SplitN(value, "#", 2)
-		} else {
-			if value != "optional" {
-				return nil, fmt.Errorf("unknown option %s", value)
-			}
-
+		} else if value == "optional" {
 			isOptional = true
+		} else if value == "all" {
+			all = true
+		} else if value == "strict" {
+			strict = true
+		} else if value == "proxy" {
+			proxy = true
+		} else if strings.HasPrefix(value, injectMapKeyOption) {
+			mapKey = strings.TrimPrefix(value, injectMapKeyOption)
+			if mapKey == "" {
+				return nil, fmt.Errorf("the %s option must have a metadata key", injectMapKeyOption)
+			}
+		} else {
+			return nil, fmt.Errorf("unknown option %s", value)
 		}
 	}
 
+	if !all && (strict || mapKey != "") {
+		return nil, fmt.Errorf("the strict and %s options may only be used with the all option", injectMapKeyOption)
+	}
+
+	if all && proxy {
+		return nil, fmt.Errorf("the proxy option may not be used with the all option")
+	}
+
 	var namespace, name string
 	if len(namespaceAndName) == 2 {
 		namespace = namespaceAndName[0]
@@ -391,6 +493,25 @@ func parseInjectString(parseMe string) (*parseData, error) {
 		}
 	}
 
+	if len(namespaceAndName) == 1 && (name == "" || name == injectByTypeName) {
+		for _, qualifier := range qualifiers {
+			err := checkNameCharacters(qualifier)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return &parseData{
+			isOptional: isOptional,
+			byType:     true,
+			qualifiers: qualifiers,
+			all:        all,
+			strict:     strict,
+			mapKey:     mapKey,
+			proxy:      proxy,
+		}, nil
+	}
+
 	sk, err := NewServiceKey(namespace, name, qualifiers...)
 	if err != nil {
 		return nil, err
@@ -399,5 +520,10 @@ func parseInjectString(parseMe string) (*parseData, error) {
 	return &parseData{
 		serviceKey: sk,
 		isOptional: isOptional,
+		qualifiers: qualifiers,
+		all:        all,
+		strict:     strict,
+		mapKey:     mapKey,
+		proxy:      proxy,
 	}, nil
 }
@@ -245,3 +245,69 @@ func checkParseData(t *testing.T, pd *parseData, space, name string, qualifiers
 
 	return retVal1 && retVal2 && retVal3 && retVal4
 }
+
+func TestParseByType(t *testing.T) {
+	for _, injectString := range []string{"", "type", ",optional", "type,optional", "@bar@baz", "type@bar@baz"} {
+		pd, err := parseInjectString(injectString)
+		if !assert.Nil(t, err, "could not parse %s", injectString) {
+			return
+		}
+
+		assert.True(t, pd.byType, "%s should be by type", injectString)
+		assert.Nil(t, pd.serviceKey, "%s should not have a key", injectString)
+	}
+
+	pd, err := parseInjectString("type,optional")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, pd.isOptional)
+
+	pd, err = parseInjectString("@bar@baz")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"bar", "baz"}, pd.qualifiers)
+
+	pd, err = parseInjectString("default#type")
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !checkParseData(t, pd, DefaultNamespace, "type", nil, false) {
+		return
+	}
+	assert.False(t, pd.byType, "type with a namespace is a name")
+
+	for _, bad := range []string{",type", "foo,type", "space#", "@bad qualifier"} {
+		_, err = parseInjectString(bad)
+		assert.NotNil(t, err, "%s should not parse", bad)
+	}
+}
+
+func TestParseCollectionOptions(t *testing.T) {
+	pd, err := parseInjectString("foo@bar,all,strict")
+	if !assert.Nil(t, err) {
+		return
+	}
+	if !checkParseData(t, pd, DefaultNamespace, "foo", []string{"bar"}, false) {
+		return
+	}
+	assert.True(t, pd.all)
+	assert.True(t, pd.strict)
+	assert.Equal(t, "", pd.mapKey)
+
+	pd, err = parseInjectString(",all,mapkey=region,optional")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, pd.byType)
+	assert.True(t, pd.all)
+	assert.False(t, pd.strict)
+	assert.True(t, pd.isOptional)
+	assert.Equal(t, "region", pd.mapKey)
+
+	for _, bad := range []string{"foo,strict", "foo,mapkey=region", "foo,all,mapkey=", "foo,everything"} {
+		_, err = parseInjectString(bad)
+		assert.NotNil(t, err, "%s should not parse", bad)
+	}
+}
@@ -0,0 +1,422 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// injectionPoint is a struct field with an inject tag or a constructor
+// parameter of a service, which is known before the service is created
+type injectionPoint struct {
+	name       string
+	typ        reflect.Type
+	parsed     *parseData
+	parseError error
+}
+
+func (ip *injectionPoint) isProvider() bool {
+	return isProvider(ip.typ) || isTypedProvider(ip.typ)
+}
+
+func (ip *injectionPoint) isTopic() bool {
+	return isTopic(ip.typ) || isTypedTopic(ip.typ)
+}
+
+// structInjectionPoints returns the injection points of the fields of
+// the structure that have an inject tag
+func structInjectionPoints(ty reflect.Type) []*injectionPoint {
+	retVal := make([]*injectionPoint, 0)
+
+	for lcv := 0; lcv < ty.NumField(); lcv++ {
+		field := ty.Field(lcv)
+
+		injectString, hasTag := field.Tag.Lookup("inject")
+		if !hasTag {
+			continue
+		}
+
+		pd, err := parseInjectString(injectString)
+
+		retVal = append(retVal, &injectionPoint{
+			name:       field.Name,
+			typ:        field.Type,
+			parsed:     pd,
+			parseError: err,
+		})
+	}
+
+	return retVal
+}
+
+// Verify checks the services of the locator, and those of its parents, without
+// creating any of them.  Only services bound with Binder.Bind or Binder.BindConstructor
+// have their dependencies checked, since the dependencies of other services are
+// not known until they are created.  Verify finds inject tags and constructor
+// parameters that can not be parsed, dependencies that are not optional and for
+// which no service is bound, dependencies found by type that match more than one
+// service with the same rank and services in a scope for which no ContextualScope
+// is bound.  It also finds Singleton or Immediate services that are injected with
+// a service of a narrower scope, such as PerLookup or ContextScope, without using
+// a Provider or a proxy, and cycles of services that are injected into each other
+// without using a Provider.  Each Verifier bound in the locator is also given every
+// service to check.  The Verifiers are the exception to not creating services, since
+// they must be created to be run, so they should have as few dependencies as possible.
+// Every problem is returned as an error implementing
+// VerificationInfo in a MultiError.  Verify returns nil if no problems are found
+func Verify(locator ServiceLocator) error {
+	iLocator, ok := locator.(*serviceLocatorData)
+	if !ok {
+		return fmt.Errorf("unknown service locator type")
+	}
+
+	err := iLocator.checkState()
+	if err != nil {
+		return err
+	}
+
+	all, err := iLocator.GetDescriptors(AllFilter)
+	if err != nil {
+		return err
+	}
+
+	verifiers, err := iLocator.getVerifiers()
+	if err != nil {
+		return err
+	}
+
+	errs := NewMultiError()
+	edges := make(map[Descriptor][]Descriptor)
+
+	for _, desc := range all {
+		scope := desc.GetScope()
+		if scope != Singleton && scope != PerLookup {
+			scopeDesc, err := iLocator.getOwner(desc).GetBestDescriptor(
+				NewSingleFilter(ContextualScopeNamespace, scope))
+			if err != nil {
+				return err
+			}
+
+			if scopeDesc == nil {
+				errs.AddError(NewVerificationError(VerificationUnknownScope, desc, "",
+					fmt.Sprintf("no ContextualScope is bound for scope %s", scope)))
+			}
+		}
+
+		for _, verifier := range verifiers {
+			ret := &errorReturn{}
+			safeVerify(verifier, locator, desc, ret)
+			if ret.err == nil {
+				continue
+			}
+
+			if multi, isMulti := ret.err.(MultiError); isMulti {
+				for _, verr := range multi.GetErrors() {
+					errs.AddError(verr)
+				}
+			} else {
+				errs.AddError(ret.err)
+			}
+		}
+
+		info, ok := desc.(injectionInformation)
+		if !ok {
+			continue
+		}
+
+		for _, point := range info.getInjectionPoints() {
+			if point.isProvider() {
+				continue
+			}
+
+			dependencies, err := iLocator.resolveInjectionPoint(desc, point)
+			if err != nil {
+				errs.AddError(err)
+				continue
+			}
+
+			for _, dependency := range dependencies {
+				edges[desc] = append(edges[desc], dependency)
+
+				if isWideScope(desc.GetScope()) && !isWideScope(dependency.GetScope()) && !isConstantDescriptor(dependency) &&
+					!iLocator.getOwner(desc).isProxied(point, dependency) {
+					errs.AddError(NewVerificationError(VerificationScopeWidening, desc, point.name,
+						fmt.Sprintf("service %s in scope %s is injected into a service in scope %s, use a Provider or a proxy instead",
+							dependency.GetFullName(), dependency.GetScope(), desc.GetScope()), dependency))
+				}
+			}
+		}
+	}
+
+	for _, cycle := range findCycles(all, edges) {
+		names := make([]string, 0, len(cycle)+1)
+		for _, desc := range cycle {
+			names = append(names, desc.GetFullName())
+		}
+		names = append(names, cycle[0].GetFullName())
+
+		errs.AddError(NewVerificationError(VerificationCycle, cycle[0], "",
+			fmt.Sprintf("cycle of injected services %s", strings.Join(names, " -> ")), cycle...))
+	}
+
+	return errs.GetFinalError()
+}
+
+// Verifier is a service that must be in namespace ioc.UserServicesNamespace and have
+// name ioc.VerifierName.  Verify gives it every service of the locator, and its parents,
+// so that it can check things that Verify itself does not know about, such as
+// configuration that a service requires.  Unlike the services it checks, a Verifier
+// and the services injected into it are created by Verify
+type Verifier interface {
+	// VerifyService checks the service without creating it.  It returns nil if
+	// no problems are found, or an error for each problem, preferably created
+	// with NewVerificationError.  More than one problem may be returned in a MultiError
+	VerifyService(locator ServiceLocator, desc Descriptor) error
+}
+
+func (locator *serviceLocatorData) getVerifiers() ([]Verifier, error) {
+	raws, err := locator.GetAllServices(USK(VerifierName))
+	if err != nil {
+		return nil, err
+	}
+
+	retVal := make([]Verifier, 0, len(raws))
+	for _, raw := range raws {
+		verifier, ok := raw.(Verifier)
+		if !ok {
+			return nil, fmt.Errorf("a service %v with verifier key does not implement Verifier", raw)
+		}
+
+		retVal = append(retVal, verifier)
+	}
+
+	return retVal, nil
+}
+
+func safeVerify(verifier Verifier, locator ServiceLocator, desc Descriptor, ret *errorReturn) {
+	defer func() {
+		if r := recover(); r != nil {
+			ret.err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	ret.err = verifier.VerifyService(locator, desc)
+}
+
+// resolveInjectionPoint returns the descriptors that would be injected into the
+// point, which is empty if nothing would be injected.  For a Provider it is the
+// descriptor of the service that the Get method of the Provider would return, and
+// for a slice or map injected with the all option it is all of the services that
+// would be put into it
+func (locator *serviceLocatorData) resolveInjectionPoint(desc Descriptor, point *injectionPoint) ([]Descriptor, error) {
+	if point.parseError != nil {
+		return nil, NewVerificationError(VerificationInvalidInjectionPoint, desc, point.name, point.parseError.Error())
+	}
+
+	if point.isTopic() {
+		// Topics are not services, and having no subscribers is not an error
+		_, err := newTopicValue(nil, point.typ, point.parsed)
+		if err != nil {
+			return nil, NewVerificationError(VerificationInvalidInjectionPoint, desc, point.name, err.Error())
+		}
+
+		return []Descriptor{}, nil
+	}
+
+	owner := locator.getOwner(desc)
+	pd := point.parsed
+
+	if pd.proxy && owner.getProxyFactory(point.typ) == nil {
+		return nil, NewVerificationError(VerificationInvalidInjectionPoint, desc, point.name,
+			fmt.Sprintf("no proxy factory is registered for type %v", point.typ))
+	}
+
+	if pd.all {
+		elemType, err := collectionElementType(point.typ, pd)
+		if err != nil {
+			return nil, NewVerificationError(VerificationInvalidInjectionPoint, desc, point.name, err.Error())
+		}
+
+		candidates, err := owner.getDescriptorsFor(collectionFilter(elemType, pd), desc)
+		if err != nil {
+			return nil, err
+		}
+
+		if point.typ.Kind() != reflect.Map {
+			return candidates, nil
+		}
+
+		retVal := make([]Descriptor, 0, len(candidates))
+		keys := make(map[string]bool)
+		for _, candidate := range candidates {
+			key, hasKey := collectionKey(candidate, pd)
+			if !hasKey || keys[key] {
+				continue
+			}
+			keys[key] = true
+
+			retVal = append(retVal, candidate)
+		}
+
+		return retVal, nil
+	}
+
+	var filter Filter
+	if pd.byType {
+		filter = NewTypeFilter(point.typ, pd.qualifiers...)
+	} else {
+		key := pd.serviceKey
+		filter = NewSingleFilter(key.GetNamespace(), key.GetName(), key.GetQualifiers()...)
+	}
+
+	candidates, err := owner.getDescriptorsFor(filter, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(candidates) == 0 {
+		if pd.isOptional {
+			return []Descriptor{}, nil
+		}
+
+		var wanted string
+		if pd.byType {
+			wanted = fmt.Sprintf("of type %v", point.typ)
+		} else {
+			wanted = fmt.Sprintf("%v", pd.serviceKey)
+		}
+
+		return nil, NewVerificationError(VerificationUnresolvedDependency, desc, point.name,
+			fmt.Sprintf("no service is bound for %s", wanted))
+	}
+
+	best := candidates[0]
+	if pd.byType {
+		ambiguous := []Descriptor{best}
+		for _, candidate := range candidates[1:] {
+			if candidate.GetRank() != best.GetRank() || candidate.GetLocatorID() != best.GetLocatorID() {
+				break
+			}
+
+			ambiguous = append(ambiguous, candidate)
+		}
+
+		if len(ambiguous) > 1 {
+			return nil, NewVerificationError(VerificationAmbiguousDependency, desc, point.name,
+				NewAmbiguousServiceError(point.typ, ambiguous).Error(), ambiguous...)
+		}
+	}
+
+	return []Descriptor{best}, nil
+}
+
+// isProxied returns true if the dependency would be injected into the
+// point as a proxy
+func (locator *serviceLocatorData) isProxied(point *injectionPoint, dependency Descriptor) bool {
+	if point.parsed.all || locator.getProxyFactory(point.typ) == nil {
+		return false
+	}
+
+	return point.parsed.proxy || locator.isProxiable(dependency)
+}
+
+func isWideScope(scope string) bool {
+	return scope == Singleton || scope == ImmediateScope
+}
+
+func isConstantDescriptor(desc Descriptor) bool {
+	info, ok := desc.(injectionInformation)
+	if !ok {
+		return false
+	}
+
+	return info.isConstant()
+}
+
+// findCycles returns each cycle in the graph once, starting from the
+// descriptor in the cycle that comes first in the given order
+func findCycles(all []Descriptor, edges map[Descriptor][]Descriptor) [][]Descriptor {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make(map[Descriptor]int)
+	path := make([]Descriptor, 0)
+	retVal := make([][]Descriptor, 0)
+
+	var visit func(Descriptor)
+	visit = func(desc Descriptor) {
+		state[desc] = visiting
+		path = append(path, desc)
+
+		for _, dependency := range edges[desc] {
+			switch state[dependency] {
+			case unvisited:
+				visit(dependency)
+			case visiting:
+				start := len(path) - 1
+				for path[start] != dependency {
+					start--
+				}
+
+				cycle := make([]Descriptor, len(path)-start)
+				copy(cycle, path[start:])
+
+				retVal = append(retVal, cycle)
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[desc] = visited
+	}
+
+	for _, desc := range all {
+		if state[desc] == unvisited {
+			visit(desc)
+		}
+	}
+
+	return retVal
+}
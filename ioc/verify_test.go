@@ -0,0 +1,251 @@
+/*
+ * DO NOT ALTER OR REMOVE COPYRIGHT NOTICES OR THIS HEADER.
+ *
+ * Copyright (c) 2018 Oracle and/or its affiliates. All rights reserved.
+ *
+ * The contents of this file are subject to the terms of either the GNU
+ * General Public License Version 2 only ("GPL") or the Common Development
+ * and Distribution License("CDDL") (collectively, the "License").  You
+ * may not use this file except in compliance with the License.  You can
+ * obtain a copy of the License at
+ * https://glassfish.dev.java.net/public/CDDL+GPL_1_1.html
+ * or packager/legal/LICENSE.txt.  See the License for the specific
+ * language governing permissions and limitations under the License.
+ *
+ * When distributing the software, include this License Header Notice in each
+ * file and include the License file at packager/legal/LICENSE.txt.
+ *
+ * GPL Classpath Exception:
+ * Oracle designates this particular file as subject to the "Classpath"
+ * exception as provided by Oracle in the GPL Version 2 section of the License
+ * file that accompanied this code.
+ *
+ * Modifications:
+ * If applicable, add the following below the License Header, with the fields
+ * enclosed by brackets [] replaced by your own identifying information:
+ * "Portions Copyright [year] [name of copyright owner]"
+ *
+ * Contributor(s):
+ * If you wish your version of this file to be governed by only the CDDL or
+ * only the GPL Version 2, indicate your decision by adding "[Contributor]
+ * elects to include this software in this distribution under the [CDDL or GPL
+ * Version 2] license."  If you don't indicate a single choice of license, a
+ * recipient has the option to distribute your version of this file under
+ * either the CDDL, the GPL Version 2 or to extend the choice of license to
+ * its licensees as provided above.  However, if you add GPL Version 2 code
+ * and therefore, elected the GPL Version 2 license, then the option applies
+ * only if the new code is made subject to such option by the copyright
+ * holder.
+ */
+
+package ioc
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const (
+	verifyLocator1 = "VerifyLocator1"
+	verifyLocator2 = "VerifyLocator2"
+	verifyLocator3 = "VerifyLocator3"
+	verifyLocator4 = "VerifyLocator4"
+)
+
+var verifyCreatedSomething bool
+
+type verifyDatabase struct {
+}
+
+func (vd *verifyDatabase) DargoInitialize(Descriptor) error {
+	verifyCreatedSomething = true
+	return nil
+}
+
+type verifyRepository struct {
+	Locator  ServiceLocator  `inject:"system#ServiceLocator"`
+	Database *verifyDatabase `inject:"Database"`
+	Request  Provider        `inject:"Request"`
+	Audit    *verifyDatabase `inject:"Audit,optional"`
+}
+
+type verifyBroken struct {
+	Missing  *verifyDatabase `inject:"Missing"`
+	Request  *verifyDatabase `inject:"Request"`
+	BadTag   *verifyDatabase `inject:"Database,eventually"`
+	Database contractReader  `inject:""`
+}
+
+type verifyCycleA struct {
+	B *verifyCycleB `inject:"CycleB"`
+}
+
+type verifyCycleB struct {
+	A *verifyCycleA `inject:"CycleA"`
+}
+
+func verifyErrorsOf(t *testing.T, err error) map[string][]VerificationInfo {
+	retVal := make(map[string][]VerificationInfo)
+
+	multi, ok := err.(MultiError)
+	if !assert.True(t, ok, "Verify should return a MultiError") {
+		return retVal
+	}
+
+	for _, e := range multi.GetErrors() {
+		info, ok := e.(VerificationInfo)
+		if !assert.True(t, ok, "all errors should be VerificationInfo %v", e) {
+			continue
+		}
+
+		retVal[info.GetVerificationType()] = append(retVal[info.GetVerificationType()], info)
+	}
+
+	return retVal
+}
+
+func TestVerifyCleanLocator(t *testing.T) {
+	verifyCreatedSomething = false
+
+	locator, err := CreateAndBind(verifyLocator1, func(binder Binder) error {
+		binder.Bind("Database", &verifyDatabase{})
+		binder.Bind("Request", &verifyDatabase{}).InScope(PerLookup)
+		binder.Bind("Repository", &verifyRepository{})
+		binder.BindConstructor("Constructed", func(db *verifyDatabase, repo *verifyRepository) *verifyDatabase {
+			return db
+		}, "Database", "")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Nil(t, Verify(locator))
+	assert.False(t, verifyCreatedSomething, "Verify must not create any services")
+}
+
+func TestVerifyFindsProblems(t *testing.T) {
+	verifyCreatedSomething = false
+
+	locator, err := CreateAndBind(verifyLocator2, func(binder Binder) error {
+		binder.Bind("Database", &verifyDatabase{})
+		binder.Bind("Request", &verifyDatabase{}).InScope(PerLookup)
+		binder.Bind("Broken", &verifyBroken{})
+		binder.Bind("Cache1", &contractCache{})
+		binder.Bind("Cache2", &contractCache{})
+		binder.Bind("Scoped", &verifyDatabase{}).InScope("NoSuchScope")
+		binder.Bind("CycleA", &verifyCycleA{})
+		binder.Bind("CycleB", &verifyCycleB{})
+		binder.BindConstructor("Constructed", func(db *verifyDatabase) *verifyDatabase {
+			return db
+		}, "AlsoMissing")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	found := verifyErrorsOf(t, Verify(locator))
+	assert.False(t, verifyCreatedSomething, "Verify must not create any services")
+
+	unresolved := found[VerificationUnresolvedDependency]
+	if assert.Equal(t, 2, len(unresolved)) {
+		points := map[string]string{}
+		for _, info := range unresolved {
+			points[info.GetDescriptor().GetName()] = info.GetInjectionPoint()
+		}
+
+		assert.Equal(t, "Missing", points["Broken"])
+		assert.Equal(t, "parameter 0", points["Constructed"])
+	}
+
+	widening := found[VerificationScopeWidening]
+	if assert.Equal(t, 1, len(widening)) {
+		assert.Equal(t, "Request", widening[0].GetInjectionPoint())
+		assert.Equal(t, "Request", widening[0].GetRelated()[0].GetName())
+	}
+
+	invalid := found[VerificationInvalidInjectionPoint]
+	if assert.Equal(t, 1, len(invalid)) {
+		assert.Equal(t, "BadTag", invalid[0].GetInjectionPoint())
+	}
+
+	ambiguous := found[VerificationAmbiguousDependency]
+	if assert.Equal(t, 1, len(ambiguous)) {
+		assert.Equal(t, 2, len(ambiguous[0].GetRelated()))
+	}
+
+	scopes := found[VerificationUnknownScope]
+	if assert.Equal(t, 1, len(scopes)) {
+		assert.Equal(t, "Scoped", scopes[0].GetDescriptor().GetName())
+	}
+
+	cycles := found[VerificationCycle]
+	if assert.Equal(t, 1, len(cycles)) {
+		assert.Equal(t, 2, len(cycles[0].GetRelated()))
+	}
+}
+
+func TestVerifyChildUsesParentServices(t *testing.T) {
+	parent, err := CreateAndBind(verifyLocator3, func(binder Binder) error {
+		binder.Bind("Database", &verifyDatabase{})
+		binder.Bind("Request", &verifyDatabase{}).InScope(PerLookup)
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer parent.Shutdown()
+
+	child, err := NewChildServiceLocator(verifyLocator3+"Child", FailIfPresent, parent)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	err = BindIntoLocator(child, func(binder Binder) error {
+		binder.Bind("Repository", &verifyRepository{})
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Nil(t, Verify(child))
+}
+
+// deprecatedVerifier reports every service with deprecated metadata
+type deprecatedVerifier struct {
+}
+
+func (dv *deprecatedVerifier) VerifyService(locator ServiceLocator, desc Descriptor) error {
+	if _, found := desc.GetMetadata()["deprecated"]; !found {
+		return nil
+	}
+
+	return NewMultiError(NewVerificationError("DEPRECATED", desc, "", "service is deprecated"))
+}
+
+func TestVerifyWithVerifier(t *testing.T) {
+	locator, err := CreateAndBind(verifyLocator4, func(binder Binder) error {
+		binder.BindConstant(VerifierName, &deprecatedVerifier{}).InNamespace(UserServicesNamespace)
+		binder.Bind("Database", &verifyDatabase{})
+		binder.Bind("OldDatabase", &verifyDatabase{}).WithMetadata("deprecated", "true")
+
+		return nil
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer locator.Shutdown()
+
+	found := verifyErrorsOf(t, Verify(locator))
+	if assert.Equal(t, 1, len(found["DEPRECATED"])) {
+		info := found["DEPRECATED"][0]
+		assert.Equal(t, "OldDatabase", info.GetDescriptor().GetName())
+		assert.Equal(t, "DEPRECATED: default#OldDatabase: service is deprecated", info.(error).Error())
+	}
+	assert.Equal(t, 1, len(found))
+}